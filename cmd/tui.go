@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 
 	"github.com/spf13/cobra"
 	gmailapi "google.golang.org/api/gmail/v1"
@@ -14,6 +15,7 @@ import (
 	"go.withmatt.com/inbox/internal/links"
 	"go.withmatt.com/inbox/internal/log"
 	"go.withmatt.com/inbox/internal/oauth"
+	"go.withmatt.com/inbox/internal/outbox"
 	"go.withmatt.com/inbox/internal/tui"
 )
 
@@ -36,11 +38,11 @@ func runTUI(cmd *cobra.Command, args []string) error {
 	var accountNames []string
 	var accountBadges []tui.AccountBadge
 	for _, account := range cfg.Accounts {
-		srv, err := getGmailService(ctx, account.Email)
+		srv, httpClient, err := getGmailService(ctx, account.Email)
 		if err != nil {
 			return fmt.Errorf("unable to create Gmail service for %s: %w", account.Email, err)
 		}
-		clients = append(clients, gmail.NewClient(srv))
+		clients = append(clients, gmail.NewClient(srv, httpClient))
 		accountNames = append(accountNames, account.Name)
 		badgeFg, err := config.ResolveColor(account.BadgeFg, cfg.Theme)
 		if err != nil {
@@ -63,6 +65,11 @@ func runTUI(cmd *cobra.Command, args []string) error {
 	}
 	uiConfig := cfg.UI.WithDefaults()
 	linkResolver := links.NewResolver(cfg.Links, log.Printf)
+	actionQueue, err := outbox.Open()
+	if err != nil {
+		log.Printf("outbox open error: %v", err)
+	}
+	defer actionQueue.Close()
 	if err := tui.Run(
 		ctx,
 		clients,
@@ -73,24 +80,26 @@ func runTUI(cmd *cobra.Command, args []string) error {
 		cfg.Keys,
 		linkResolver,
 		cfg.Links.AutoScan,
+		cfg.Attachments,
+		actionQueue,
 	); err != nil {
 		return fmt.Errorf("error running TUI: %w", err)
 	}
 	return nil
 }
 
-func getGmailService(ctx context.Context, email string) (*gmailapi.Service, error) {
+func getGmailService(ctx context.Context, email string) (*gmailapi.Service, *http.Client, error) {
 	// Get OAuth token
 	client, err := oauth.GetClientQuiet(ctx, email)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Create Gmail service
 	srv, err := gmailapi.NewService(ctx, option.WithHTTPClient(client))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return srv, nil
+	return srv, client, nil
 }
@@ -0,0 +1,182 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// Format is a supported archive container.
+type Format int
+
+const (
+	FormatZip Format = iota + 1
+	FormatTar
+	FormatTarGz
+)
+
+// ErrNotFound is returned when an archive no longer has a listed entry.
+var ErrNotFound = errors.New("entry not found")
+
+// Entry is one file in an archive.
+type Entry struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+	// index is the entry's position in the archive, since names can repeat
+	index int
+}
+
+// Detect recognizes an archive by its filename, falling back to the MIME
+// type since senders rarely label archives consistently.
+func Detect(filename, mimeType string) (Format, bool) {
+	name := strings.ToLower(filename)
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip, true
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz, true
+	case strings.HasSuffix(name, ".tar"):
+		return FormatTar, true
+	}
+	switch strings.ToLower(mimeType) {
+	case "application/zip", "application/x-zip-compressed", "application/x-zip":
+		return FormatZip, true
+	case "application/x-tar":
+		return FormatTar, true
+	case "application/x-gtar", "application/x-compressed-tar":
+		return FormatTarGz, true
+	case "application/gzip", "application/x-gzip":
+		// A bare .gz is only an archive if it holds a tarball
+		return FormatTarGz, strings.Contains(name, ".tar")
+	}
+	return 0, false
+}
+
+// List returns the files in the archive at path, in archive order.
+// Directories are left out; their files carry the full path.
+func List(path string, format Format) ([]Entry, error) {
+	if format == FormatZip {
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		entries := make([]Entry, 0, len(r.File))
+		for i, f := range r.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			entries = append(entries, Entry{
+				Name:     f.Name,
+				Size:     int64(f.UncompressedSize64),
+				Modified: f.Modified,
+				index:    i,
+			})
+		}
+		return entries, nil
+	}
+
+	var entries []Entry
+	i := 0
+	err := walkTar(path, format, func(hdr *tar.Header, _ io.Reader) (bool, error) {
+		if hdr.Typeflag == tar.TypeReg {
+			entries = append(entries, Entry{Name: hdr.Name, Size: hdr.Size, Modified: hdr.ModTime, index: i})
+		}
+		i++
+		return true, nil
+	})
+	return entries, err
+}
+
+// Extract copies an entry List returned to w, refusing to write more than
+// limit bytes so a hostile archive can't fill memory or disk. Entries are
+// found by position, so files that share a name each get their own content.
+func Extract(path string, format Format, entry Entry, w io.Writer, limit int64) error {
+	copyEntry := func(r io.Reader) error {
+		n, err := io.Copy(w, io.LimitReader(r, limit+1))
+		if err != nil {
+			return err
+		}
+		if n > limit {
+			return fmt.Errorf("%s is larger than %d bytes", entry.Name, limit)
+		}
+		return nil
+	}
+
+	if format == FormatZip {
+		r, err := zip.OpenReader(path)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		if entry.index >= len(r.File) || r.File[entry.index].Name != entry.Name {
+			return fmt.Errorf("%w: %s", ErrNotFound, entry.Name)
+		}
+		rc, err := r.File[entry.index].Open()
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		return copyEntry(rc)
+	}
+
+	found := false
+	i := 0
+	err := walkTar(path, format, func(hdr *tar.Header, r io.Reader) (bool, error) {
+		index := i
+		i++
+		if index != entry.index {
+			return true, nil
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Name != entry.Name {
+			return false, nil
+		}
+		found = true
+		return false, copyEntry(r)
+	})
+	if err == nil && !found {
+		err = fmt.Errorf("%w: %s", ErrNotFound, entry.Name)
+	}
+	return err
+}
+
+// walkTar calls fn for each header until it returns false or an error.
+func walkTar(path string, format Format, fn func(*tar.Header, io.Reader) (bool, error)) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if format == FormatTarGz {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		more, err := fn(hdr, tr)
+		if err != nil || !more {
+			return err
+		}
+	}
+}
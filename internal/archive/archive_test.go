@@ -0,0 +1,101 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// files repeats a name, as archives built by appending often do.
+var files = []struct{ name, body string }{
+	{"report.txt", "first"},
+	{"notes.txt", "notes"},
+	{"report.txt", "second"},
+}
+
+func writeZip(t *testing.T, w io.Writer) {
+	zw := zip.NewWriter(w)
+	if _, err := zw.Create("dir/"); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		fw, err := zw.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write([]byte(f.body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTar(t *testing.T, w io.Writer) {
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0o755}); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range files {
+		hdr := &tar.Header{Name: f.name, Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(f.body))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(f.body))
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExtractDuplicateNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		write  func(*testing.T, io.Writer)
+	}{
+		{name: "zip", format: FormatZip, write: writeZip},
+		{name: "tar", format: FormatTar, write: writeTar},
+		{name: "tar.gz", format: FormatTarGz, write: func(t *testing.T, w io.Writer) {
+			gz := gzip.NewWriter(w)
+			writeTar(t, gz)
+			gz.Close()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test."+tt.name)
+			var buf bytes.Buffer
+			tt.write(t, &buf)
+			if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			entries, err := List(path, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != len(files) {
+				t.Fatalf("listed %d entries, want %d", len(entries), len(files))
+			}
+			for i, entry := range entries {
+				var out bytes.Buffer
+				if err := Extract(path, tt.format, entry, &out, 1<<20); err != nil {
+					t.Fatalf("extract %s: %v", entry.Name, err)
+				}
+				if entry.Name != files[i].name || out.String() != files[i].body {
+					t.Errorf("entry %d = %s %q, want %s %q", i, entry.Name, out.String(), files[i].name, files[i].body)
+				}
+			}
+
+			var out bytes.Buffer
+			if err := Extract(path, tt.format, entries[0], &out, 2); err == nil {
+				t.Error("extract past the limit succeeded")
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Package charset converts message text and encoded headers to UTF-8, so
+// every view of a message decodes it the same way.
+package charset
+
+import (
+	"io"
+	"mime"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// metaCharsetRe finds <meta charset="..."> and the older http-equiv form
+// within the start of an HTML part.
+var metaCharsetRe = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?\s*([a-z0-9_:.+-]+)`)
+
+// metaPrescanBytes is how far into an HTML part a <meta charset> is looked
+// for, like browsers do.
+const metaPrescanBytes = 1024
+
+// HeaderDecoder decodes RFC 2047 encoded words in any charset the HTML
+// encoding index knows, not just UTF-8 and ISO-8859-1.
+var HeaderDecoder = &mime.WordDecoder{
+	CharsetReader: func(charset string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(charset)
+		if err != nil {
+			return nil, err
+		}
+		return enc.NewDecoder().Reader(input), nil
+	},
+}
+
+// Decode converts a text part to UTF-8. The charset comes from the part's
+// Content-Type, then a <meta charset> for HTML. Undeclared text that isn't
+// valid UTF-8 is most often Windows-1252. Text in an unknown charset is
+// better shown as is than not at all.
+func Decode(data []byte, charset, mimeType string) string {
+	charset = strings.TrimSpace(charset)
+	if charset == "" && strings.EqualFold(mimeType, "text/html") {
+		if match := metaCharsetRe.FindSubmatch(data[:min(len(data), metaPrescanBytes)]); match != nil {
+			charset = string(match[1])
+		}
+	}
+	if charset == "" {
+		if utf8.Valid(data) {
+			return string(data)
+		}
+		charset = "windows-1252"
+	}
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return string(data)
+	}
+	if name, _ := htmlindex.Name(enc); name == "utf-8" {
+		return string(data)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
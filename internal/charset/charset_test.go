@@ -0,0 +1,38 @@
+package charset
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		charset  string
+		mimeType string
+		want     string
+	}{
+		{"declared", []byte("caf\xe9"), "ISO-8859-1", "text/plain", "café"},
+		{"utf-8", []byte("café"), "utf-8", "text/plain", "café"},
+		{"undeclared utf-8", []byte("café"), "", "text/plain", "café"},
+		{"undeclared windows-1252", []byte("\x93quoted\x94"), "", "text/plain", "“quoted”"},
+		{"meta charset", []byte(`<meta charset="iso-8859-1"><p>caf` + "\xe9"), "", "text/html", `<meta charset="iso-8859-1"><p>café`},
+		{"meta ignored for plain text", []byte(`<meta charset="iso-8859-1">café`), "", "text/plain", `<meta charset="iso-8859-1">café`},
+		{"unknown charset", []byte("caf\xe9"), "x-unknown", "text/plain", "caf\xe9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Decode(tt.data, tt.charset, tt.mimeType); got != tt.want {
+				t.Errorf("Decode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeaderDecoder(t *testing.T) {
+	got, err := HeaderDecoder.DecodeHeader("=?windows-1251?B?z/Do4uXy?=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Привет" {
+		t.Errorf("DecodeHeader() = %q, want %q", got, "Привет")
+	}
+}
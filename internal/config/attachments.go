@@ -0,0 +1,40 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/adrg/xdg"
+)
+
+type AttachmentConfig struct {
+	// DownloadDir is where attachments are saved. It defaults to the XDG
+	// download directory, usually ~/Downloads.
+	DownloadDir string `toml:"download_dir"`
+	// Handlers map MIME types to the rest of a mailcap line, like
+	// "zathura %s" or "less %s; needsterminal". They win over mailcap files.
+	Handlers map[string]string `toml:"handlers"`
+}
+
+// ResolveDownloadDir returns the download directory with ~ and environment
+// variables expanded.
+func (c AttachmentConfig) ResolveDownloadDir() (string, error) {
+	if c.DownloadDir == "" {
+		return xdg.UserDirs.Download, nil
+	}
+	return ExpandPath(os.ExpandEnv(c.DownloadDir))
+}
+
+// ExpandPath expands a leading ~ in path. Anything else is left as typed,
+// since a $ is as likely to be part of a filename as a variable.
+func ExpandPath(path string) (string, error) {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
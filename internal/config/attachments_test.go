@@ -0,0 +1,44 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "~", want: home},
+		{path: "~/invoice.pdf", want: filepath.Join(home, "invoice.pdf")},
+		{path: "invoice$2024.pdf", want: "invoice$2024.pdf"},
+		{path: "a$HOME.txt", want: "a$HOME.txt"},
+		{path: "~/$HOME/x", want: filepath.Join(home, "$HOME/x")},
+		{path: "/tmp/~x", want: "/tmp/~x"},
+	}
+	for _, tt := range tests {
+		got, err := ExpandPath(tt.path)
+		if err != nil {
+			t.Fatalf("ExpandPath(%q): %v", tt.path, err)
+		}
+		if got != tt.want {
+			t.Errorf("ExpandPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestResolveDownloadDirExpandsEnv(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("INBOX_TEST_DIR", "mail")
+	got, err := AttachmentConfig{DownloadDir: "~/$INBOX_TEST_DIR"}.ResolveDownloadDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, "mail"); got != want {
+		t.Errorf("ResolveDownloadDir = %q, want %q", got, want)
+	}
+}
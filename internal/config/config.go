@@ -21,11 +21,12 @@ type Account struct {
 
 // Config represents the inbox configuration
 type Config struct {
-	Accounts []Account  `toml:"accounts"`
-	Theme    Theme      `toml:"theme"`
-	UI       UIConfig   `toml:"ui"`
-	Keys     KeyMap     `toml:"keys"`
-	Links    LinkConfig `toml:"links"`
+	Accounts    []Account        `toml:"accounts"`
+	Theme       Theme            `toml:"theme"`
+	UI          UIConfig         `toml:"ui"`
+	Keys        KeyMap           `toml:"keys"`
+	Links       LinkConfig       `toml:"links"`
+	Attachments AttachmentConfig `toml:"attachments"`
 }
 
 // ConfigDir returns the directory where config files are stored
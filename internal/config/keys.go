@@ -1,12 +1,15 @@
 package config
 
 type KeyMap struct {
+	// Leader is the key substituted for <leader> in key sequences.
+	Leader           string                 `toml:"leader"`
 	List             ListKeyMap             `toml:"list"`
 	Detail           DetailKeyMap           `toml:"detail"`
 	Search           SearchKeyMap           `toml:"search"`
 	Attachment       AttachmentKeyMap       `toml:"attachment"`
 	Image            ImageKeyMap            `toml:"image"`
 	AttachmentsModal AttachmentsModalKeyMap `toml:"attachments_modal"`
+	LinkPicker       LinkPickerKeyMap       `toml:"link_picker"`
 }
 
 type ListKeyMap struct {
@@ -14,7 +17,12 @@ type ListKeyMap struct {
 	Down           []string `toml:"down"`
 	PageUp         []string `toml:"page_up"`
 	PageDown       []string `toml:"page_down"`
+	GoTop          []string `toml:"go_top"`
+	GoBottom       []string `toml:"go_bottom"`
+	GoInbox        []string `toml:"go_inbox"`
+	GoArchive      []string `toml:"go_archive"`
 	Open           []string `toml:"open"`
+	FocusPane      []string `toml:"focus_pane"`
 	ToggleRead     []string `toml:"toggle_read"`
 	ToggleSelect   []string `toml:"toggle_select"`
 	ClearSelection []string `toml:"clear_selection"`
@@ -24,6 +32,9 @@ type ListKeyMap struct {
 	Undo           []string `toml:"undo"`
 	Search         []string `toml:"search"`
 	Refresh        []string `toml:"refresh"`
+	FailedActions  []string `toml:"failed_actions"`
+	Palette        []string `toml:"palette"`
+	Command        []string `toml:"command"`
 	Help           []string `toml:"help"`
 	Quit           []string `toml:"quit"`
 }
@@ -31,9 +42,24 @@ type ListKeyMap struct {
 type DetailKeyMap struct {
 	Up           []string `toml:"up"`
 	Down         []string `toml:"down"`
+	GoTop        []string `toml:"go_top"`
+	GoBottom     []string `toml:"go_bottom"`
+	GoInbox      []string `toml:"go_inbox"`
+	GoArchive    []string `toml:"go_archive"`
 	ToggleExpand []string `toml:"toggle_expand"`
 	ToggleView   []string `toml:"toggle_view"`
+	ToggleQuotes []string `toml:"toggle_quotes"`
+	AllHeaders   []string `toml:"all_headers"`
 	Attachments  []string `toml:"attachments"`
+	InlineImage  []string `toml:"inline_image"`
+	Gallery      []string `toml:"gallery"`
+	SaveAll      []string `toml:"save_all"`
+	MimeTree     []string `toml:"mime_tree"`
+	Unsubscribe  []string `toml:"unsubscribe"`
+	Links        []string `toml:"links"`
+	FocusPane    []string `toml:"focus_pane"`
+	Palette      []string `toml:"palette"`
+	Command      []string `toml:"command"`
 	Back         []string `toml:"back"`
 	Help         []string `toml:"help"`
 	Quit         []string `toml:"quit"`
@@ -51,14 +77,32 @@ type AttachmentKeyMap struct {
 }
 
 type ImageKeyMap struct {
-	Back []string `toml:"back"`
-	Quit []string `toml:"quit"`
+	Back     []string `toml:"back"`
+	Quit     []string `toml:"quit"`
+	Pause    []string `toml:"pause"`
+	Step     []string `toml:"step"`
+	StepBack []string `toml:"step_back"`
+	Next     []string `toml:"next"`
+	Prev     []string `toml:"prev"`
+	Save     []string `toml:"save"`
+	SaveAll  []string `toml:"save_all"`
 }
 
 type AttachmentsModalKeyMap struct {
 	Up       []string `toml:"up"`
 	Down     []string `toml:"down"`
 	Download []string `toml:"download"`
+	SaveAs   []string `toml:"save_as"`
+	OpenWith []string `toml:"open_with"`
 	View     []string `toml:"view"`
 	Close    []string `toml:"close"`
 }
+
+type LinkPickerKeyMap struct {
+	Up             []string `toml:"up"`
+	Down           []string `toml:"down"`
+	Open           []string `toml:"open"`
+	Copy           []string `toml:"copy"`
+	ToggleOriginal []string `toml:"toggle_original"`
+	Close          []string `toml:"close"`
+}
@@ -1,9 +1,23 @@
 package config
 
 type LinkConfig struct {
-	UnwrapDomains []string `toml:"unwrap_domains"`
-	DoNotResolve  []string `toml:"do_not_resolve"`
-	DNSMode       string   `toml:"dns_mode"`
-	DNSServers    []string `toml:"dns_servers"`
-	AutoScan      bool     `toml:"auto_scan"`
+	UnwrapDomains []string          `toml:"unwrap_domains"`
+	DoNotResolve  []string          `toml:"do_not_resolve"`
+	DNSMode       string            `toml:"dns_mode"`
+	DNSServers    []string          `toml:"dns_servers"`
+	AutoScan      bool              `toml:"auto_scan"`
+	StripParams   StripParamsConfig `toml:"strip_params"`
+}
+
+// StripParamsConfig sets which tracking parameters are removed from links.
+// A name ending in * matches every parameter starting with the rest.
+type StripParamsConfig struct {
+	// Disabled leaves every parameter in place, built-in ones included
+	Disabled bool `toml:"disabled"`
+	// Params are stripped on every domain, on top of the built-in list
+	Params []string `toml:"params"`
+	// Keep lists parameters that would otherwise be stripped
+	Keep []string `toml:"keep"`
+	// Domains maps a domain and its subdomains to parameters stripped there
+	Domains map[string][]string `toml:"domains"`
 }
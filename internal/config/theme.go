@@ -35,14 +35,17 @@ type ThemeList struct {
 }
 
 type ThemeDetail struct {
-	SnippetFg      string `toml:"snippet_fg"`
-	BorderSelected string `toml:"border_selected"`
-	BorderNormal   string `toml:"border_normal"`
-	HeaderLabelFg  string `toml:"header_label_fg"`
-	HeaderValueFg  string `toml:"header_value_fg"`
-	LinkFg         string `toml:"link_fg"`
-	ViewModeBg     string `toml:"view_mode_bg"`
-	ViewModeFg     string `toml:"view_mode_fg"`
+	SnippetFg         string `toml:"snippet_fg"`
+	BorderSelected    string `toml:"border_selected"`
+	BorderNormal      string `toml:"border_normal"`
+	HeaderLabelFg     string `toml:"header_label_fg"`
+	HeaderValueFg     string `toml:"header_value_fg"`
+	LinkFg            string `toml:"link_fg"`
+	ViewModeBg        string `toml:"view_mode_bg"`
+	ViewModeFg        string `toml:"view_mode_fg"`
+	AuthPassFg        string `toml:"auth_pass_fg"`
+	AuthFailFg        string `toml:"auth_fail_fg"`
+	HeaderHighlightFg string `toml:"header_highlight_fg"`
 }
 
 type ThemeAttachment struct {
@@ -129,6 +132,15 @@ func themeFromPalette(palette *themes.Theme) Theme {
 		palette.Red,
 		palette.Foreground,
 	)
+	passFg := firstNonEmpty(
+		palette.Green,
+		palette.Foreground,
+	)
+	highlightFg := firstNonEmpty(
+		palette.Yellow,
+		palette.BrightYellow,
+		palette.Foreground,
+	)
 	return Theme{
 		Status: ThemeStatus{
 			Bg:     palette.Background,
@@ -146,14 +158,17 @@ func themeFromPalette(palette *themes.Theme) Theme {
 			SelectedBg: selectedBg,
 		},
 		Detail: ThemeDetail{
-			SnippetFg:      dim,
-			BorderSelected: modeBg,
-			BorderNormal:   borderNormal,
-			HeaderLabelFg:  dim,
-			HeaderValueFg:  palette.Foreground,
-			LinkFg:         linkFg,
-			ViewModeBg:     viewModeBg,
-			ViewModeFg:     viewModeFg,
+			SnippetFg:         dim,
+			BorderSelected:    modeBg,
+			BorderNormal:      borderNormal,
+			HeaderLabelFg:     dim,
+			HeaderValueFg:     palette.Foreground,
+			LinkFg:            linkFg,
+			ViewModeBg:        viewModeBg,
+			ViewModeFg:        viewModeFg,
+			AuthPassFg:        passFg,
+			AuthFailFg:        errorFg,
+			HeaderHighlightFg: highlightFg,
 		},
 		Attachment: ThemeAttachment{
 			TitleBg: modeBg,
@@ -193,6 +208,9 @@ func mergeTheme(base, override Theme) Theme {
 	fillIfEmpty(&out.Detail.LinkFg, base.Detail.LinkFg)
 	fillIfEmpty(&out.Detail.ViewModeBg, base.Detail.ViewModeBg)
 	fillIfEmpty(&out.Detail.ViewModeFg, base.Detail.ViewModeFg)
+	fillIfEmpty(&out.Detail.AuthPassFg, base.Detail.AuthPassFg)
+	fillIfEmpty(&out.Detail.AuthFailFg, base.Detail.AuthFailFg)
+	fillIfEmpty(&out.Detail.HeaderHighlightFg, base.Detail.HeaderHighlightFg)
 
 	fillIfEmpty(&out.Attachment.TitleBg, base.Attachment.TitleBg)
 	fillIfEmpty(&out.Attachment.TitleFg, base.Attachment.TitleFg)
@@ -266,6 +284,9 @@ func resolveThemeColorNames(theme Theme, palette *themes.Theme) Theme {
 	theme.Detail.LinkFg = resolveColorName(theme.Detail.LinkFg, palette)
 	theme.Detail.ViewModeBg = resolveColorName(theme.Detail.ViewModeBg, palette)
 	theme.Detail.ViewModeFg = resolveColorName(theme.Detail.ViewModeFg, palette)
+	theme.Detail.AuthPassFg = resolveColorName(theme.Detail.AuthPassFg, palette)
+	theme.Detail.AuthFailFg = resolveColorName(theme.Detail.AuthFailFg, palette)
+	theme.Detail.HeaderHighlightFg = resolveColorName(theme.Detail.HeaderHighlightFg, palette)
 
 	theme.Attachment.TitleBg = resolveColorName(theme.Attachment.TitleBg, palette)
 	theme.Attachment.TitleFg = resolveColorName(theme.Attachment.TitleFg, palette)
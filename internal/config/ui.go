@@ -1,8 +1,83 @@
 package config
 
+// Split layouts for showing the thread list and preview together.
+const (
+	SplitOff        = "off"
+	SplitVertical   = "vertical"
+	SplitHorizontal = "horizontal"
+)
+
+// Image protocols for the attachment viewer.
+const (
+	ImageProtocolAuto       = "auto"
+	ImageProtocolKitty      = "kitty"
+	ImageProtocolSixel      = "sixel"
+	ImageProtocolITerm2     = "iterm2"
+	ImageProtocolHalfBlocks = "halfblocks"
+)
+
+// List columns, in the order rows show them by default.
+const (
+	ColumnSender     = "sender"
+	ColumnCount      = "count"
+	ColumnAttachment = "attachment"
+	ColumnLabels     = "labels"
+	ColumnBadge      = "badge"
+	ColumnDate       = "date"
+	ColumnSubject    = "subject"
+	ColumnSnippet    = "snippet"
+)
+
+// List densities.
+const (
+	DensityComfortable = "comfortable"
+	DensityCompact     = "compact"
+)
+
+// Date formats; any other value is used as a Go time layout.
+const (
+	DateRelative = "relative"
+	DateAbsolute = "absolute"
+)
+
 type UIConfig struct {
 	ListSnippetLines       int `toml:"list_snippet_lines"`
 	RefreshIntervalSeconds int `toml:"refresh_interval_seconds"`
+	// Split shows a preview pane beside (vertical) or below (horizontal) the list.
+	Split string `toml:"split"`
+	// SplitRatio is the share of the screen given to the thread list.
+	SplitRatio float64 `toml:"split_ratio"`
+	// SplitMinWidth is the narrowest terminal a vertical split is used on.
+	SplitMinWidth int `toml:"split_min_width"`
+	// ImageProtocol picks how images are drawn; "auto" asks the terminal.
+	ImageProtocol string `toml:"image_protocol"`
+	// ExpandQuotes shows quoted replies and signatures instead of folding them.
+	ExpandQuotes bool       `toml:"expand_quotes"`
+	List         ListConfig `toml:"list"`
+}
+
+// ListConfig controls how rows in the thread list are laid out.
+type ListConfig struct {
+	// Columns to show, in order.
+	Columns []string `toml:"columns"`
+	// DateFormat is "relative", "absolute", or a Go time layout.
+	DateFormat string `toml:"date_format"`
+	// TimeZone is an IANA zone name; empty uses the local zone.
+	TimeZone string `toml:"time_zone"`
+	// Density is "comfortable" (multi-line cards) or "compact" (one line per thread).
+	Density string `toml:"density"`
+	// GroupByDate adds Today / Yesterday / This week / Older headers.
+	GroupByDate bool `toml:"group_by_date"`
+}
+
+// DefaultListColumns matches the original card layout.
+var DefaultListColumns = []string{
+	ColumnSender,
+	ColumnCount,
+	ColumnBadge,
+	ColumnDate,
+	ColumnSubject,
+	ColumnSnippet,
 }
 
 func (u UIConfig) WithDefaults() UIConfig {
@@ -12,5 +87,46 @@ func (u UIConfig) WithDefaults() UIConfig {
 	if u.RefreshIntervalSeconds == 0 {
 		u.RefreshIntervalSeconds = 300
 	}
+	switch u.Split {
+	case SplitVertical, SplitHorizontal:
+	default:
+		u.Split = SplitOff
+	}
+	if u.SplitRatio <= 0 || u.SplitRatio >= 1 {
+		u.SplitRatio = 0.4
+	}
+	if u.SplitMinWidth <= 0 {
+		u.SplitMinWidth = 120
+	}
+	switch u.ImageProtocol {
+	case ImageProtocolKitty, ImageProtocolSixel, ImageProtocolITerm2, ImageProtocolHalfBlocks:
+	default:
+		u.ImageProtocol = ImageProtocolAuto
+	}
+	u.List = u.List.WithDefaults()
 	return u
 }
+
+func (l ListConfig) WithDefaults() ListConfig {
+	if len(l.Columns) == 0 {
+		l.Columns = DefaultListColumns
+	}
+	if l.DateFormat == "" {
+		l.DateFormat = DateRelative
+	}
+	if l.Density != DensityCompact {
+		l.Density = DensityComfortable
+	}
+	return l
+}
+
+// IsListColumn reports whether name is a known list column.
+func IsListColumn(name string) bool {
+	switch name {
+	case ColumnSender, ColumnCount, ColumnAttachment, ColumnLabels,
+		ColumnBadge, ColumnDate, ColumnSubject, ColumnSnippet:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,222 @@
+package gmail
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/api/googleapi"
+)
+
+// errNoAttachmentData is returned when the response has no "data" field.
+var errNoAttachmentData = errors.New("attachment response has no data")
+
+// streamAttachment fetches an attachment and hands fn a reader over the
+// base64url text of its "data" field as it arrives, so the payload is never
+// held in memory.
+func (c *Client) streamAttachment(
+	ctx context.Context,
+	messageID, attachmentID string,
+	fn func(data io.Reader) error,
+) error {
+	// The same path the generated Users.Messages.Attachments.Get uses
+	endpoint := googleapi.ResolveRelative(
+		c.srv.BasePath,
+		"gmail/v1/users/{userId}/messages/{messageId}/attachments/{id}",
+	)
+	endpoint += "?" + url.Values{"alt": {"json"}, "prettyPrint": {"false"}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	googleapi.Expand(req.URL, map[string]string{
+		"userId":    "me",
+		"messageId": messageID,
+		"id":        attachmentID,
+	})
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+
+	r := bufio.NewReader(res.Body)
+	if err := seekDataField(r); err != nil {
+		return err
+	}
+	data := &jsonStringReader{r: r}
+	if err := fn(data); err != nil {
+		return err
+	}
+	// Make sure the whole value arrived rather than a truncated body
+	_, err = io.Copy(io.Discard, data)
+	return err
+}
+
+// seekDataField advances r to just past the opening quote of the top-level
+// "data" string, skipping the other fields.
+func seekDataField(r *bufio.Reader) error {
+	if err := expectByte(r, '{'); err != nil {
+		return err
+	}
+	for {
+		b, err := nextNonSpace(r)
+		if err != nil {
+			return err
+		}
+		switch b {
+		case '}':
+			return errNoAttachmentData
+		case ',':
+			continue
+		case '"':
+		default:
+			return fmt.Errorf("unexpected %q in attachment response", b)
+		}
+		key, err := io.ReadAll(&jsonStringReader{r: r})
+		if err != nil {
+			return err
+		}
+		if err := expectByte(r, ':'); err != nil {
+			return err
+		}
+		if string(key) == "data" {
+			return expectByte(r, '"')
+		}
+		if err := skipValue(r); err != nil {
+			return err
+		}
+	}
+}
+
+// skipValue skips one JSON value, including nested objects and arrays.
+func skipValue(r *bufio.Reader) error {
+	depth := 0
+	for {
+		b, err := nextNonSpace(r)
+		if err != nil {
+			return err
+		}
+		switch b {
+		case '"':
+			if _, err := io.Copy(io.Discard, &jsonStringReader{r: r}); err != nil {
+				return err
+			}
+		case ',', ':':
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		default:
+			// Numbers and literals run up to the next delimiter
+			for {
+				next, err := r.Peek(1)
+				if err != nil {
+					return err
+				}
+				if next[0] == ',' || next[0] == '}' || next[0] == ']' ||
+					next[0] == ' ' || next[0] == '\n' || next[0] == '\t' || next[0] == '\r' {
+					break
+				}
+				r.ReadByte()
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func nextNonSpace(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, noEOF(err)
+		}
+		switch b {
+		case ' ', '\n', '\t', '\r':
+			continue
+		}
+		return b, nil
+	}
+}
+
+func expectByte(r *bufio.Reader, want byte) error {
+	b, err := nextNonSpace(r)
+	if err != nil {
+		return err
+	}
+	if b != want {
+		return fmt.Errorf("unexpected %q in attachment response, want %q", b, want)
+	}
+	return nil
+}
+
+// noEOF turns a clean EOF into ErrUnexpectedEOF; the response is never
+// supposed to end mid-object.
+func noEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// jsonStringReader reads the contents of a JSON string whose opening quote
+// has been consumed, stopping at the closing quote. Base64url never needs
+// escaping, but simple escapes are handled in case the server adds them.
+type jsonStringReader struct {
+	r    *bufio.Reader
+	done bool
+}
+
+func (s *jsonStringReader) Read(p []byte) (int, error) {
+	if s.done {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			return n, noEOF(err)
+		}
+		switch b {
+		case '"':
+			s.done = true
+			if n == 0 {
+				return 0, io.EOF
+			}
+			return n, nil
+		case '\\':
+			esc, err := s.r.ReadByte()
+			if err != nil {
+				return n, noEOF(err)
+			}
+			switch esc {
+			case '"', '\\', '/':
+				b = esc
+			default:
+				return n, fmt.Errorf("unsupported escape \\%c in attachment data", esc)
+			}
+		}
+		p[n] = b
+		n++
+		// Return what's buffered rather than blocking on the network
+		if s.r.Buffered() == 0 {
+			break
+		}
+	}
+	return n, nil
+}
+
+// decodeAttachment wraps base64url text in a streaming decoder.
+func decodeAttachment(data io.Reader) io.Reader {
+	return base64.NewDecoder(base64.URLEncoding, data)
+}
@@ -0,0 +1,45 @@
+package gmail
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+func TestStreamAttachmentPath(t *testing.T) {
+	payload := []byte("attachment \xff\xfe bytes")
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.EscapedPath()
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"size": %d, "data": %q}`, len(payload), base64.URLEncoding.EncodeToString(payload))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	srv, err := gmail.NewService(ctx,
+		option.WithEndpoint(server.URL+"/"),
+		option.WithHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := NewClient(srv, server.Client())
+
+	got, err := client.DownloadAttachment(ctx, "msg/1", "att 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/gmail/v1/users/me/messages/msg%2F1/attachments/att%201"; gotPath != want {
+		t.Errorf("request path = %q, want %q", gotPath, want)
+	}
+	if string(got) != string(payload) {
+		t.Errorf("data = %q, want %q", got, payload)
+	}
+}
@@ -0,0 +1,48 @@
+package gmail
+
+import (
+	"mime"
+	"net/mail"
+	"strings"
+
+	"google.golang.org/api/gmail/v1"
+
+	"go.withmatt.com/inbox/internal/charset"
+)
+
+// addressParser parses address headers, decoding encoded display names with
+// the same charsets as other headers.
+var addressParser = &mail.AddressParser{WordDecoder: charset.HeaderDecoder}
+
+// decodeHeader decodes encoded words like =?ISO-8859-1?Q?caf=E9?=, leaving
+// the value as it was if it can't be decoded.
+func decodeHeader(value string) string {
+	if !strings.Contains(value, "=?") {
+		return value
+	}
+	decoded, err := charset.HeaderDecoder.DecodeHeader(value)
+	if err != nil {
+		return value
+	}
+	return decoded
+}
+
+// decodeBody converts a text part to UTF-8.
+func decodeBody(data []byte, part *gmail.MessagePart) string {
+	return charset.Decode(data, partCharset(part), part.MimeType)
+}
+
+// partCharset returns the charset parameter of a part's Content-Type.
+func partCharset(part *gmail.MessagePart) string {
+	for _, header := range part.Headers {
+		if !strings.EqualFold(header.Name, "Content-Type") {
+			continue
+		}
+		_, params, err := mime.ParseMediaType(header.Value)
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(params["charset"])
+	}
+	return ""
+}
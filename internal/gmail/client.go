@@ -1,11 +1,14 @@
 package gmail
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"errors"
 	"io"
+	"net/http"
 	"strings"
+	"time"
 
 	"google.golang.org/api/gmail/v1"
 )
@@ -13,11 +16,14 @@ import (
 // Client wraps Gmail API service
 type Client struct {
 	srv *gmail.Service
+	// httpClient is the authorized client behind srv, used to stream
+	// attachment responses the generated API would buffer
+	httpClient *http.Client
 }
 
 // NewClient creates a new Gmail client
-func NewClient(srv *gmail.Service) *Client {
-	return &Client{srv: srv}
+func NewClient(srv *gmail.Service, httpClient *http.Client) *Client {
+	return &Client{srv: srv, httpClient: httpClient}
 }
 
 // ListInbox fetches inbox thread IDs (without metadata for efficiency)
@@ -61,17 +67,41 @@ func (c *Client) SearchInbox(
 	query string,
 	limit int64,
 	pageToken string,
+) (*InboxResponse, error) {
+	return c.searchThreads(ctx, query, limit, pageToken, "INBOX")
+}
+
+// SearchAllMail fetches thread IDs that match the Gmail search query in
+// any mailbox, so queries like "-in:inbox" can find archived threads. Spam
+// and trash are left out unless the query asks for them.
+func (c *Client) SearchAllMail(
+	ctx context.Context,
+	query string,
+	limit int64,
+	pageToken string,
+) (*InboxResponse, error) {
+	return c.searchThreads(ctx, query, limit, pageToken)
+}
+
+func (c *Client) searchThreads(
+	ctx context.Context,
+	query string,
+	limit int64,
+	pageToken string,
+	labelIDs ...string,
 ) (*InboxResponse, error) {
 	req := c.srv.Users.Threads.List("me").
-		LabelIds("INBOX").
 		Q(query).
 		MaxResults(limit)
+	if len(labelIDs) > 0 {
+		req = req.LabelIds(labelIDs...)
+	}
 
 	if pageToken != "" {
 		req = req.PageToken(pageToken)
 	}
 
-	res, err := req.Do()
+	res, err := req.Context(ctx).Do()
 	if err != nil {
 		return nil, err
 	}
@@ -190,6 +220,13 @@ func (c *Client) MarkThreadUnread(ctx context.Context, threadID string) error {
 	return err
 }
 
+// SendMessage sends a raw RFC 822 message from the account.
+func (c *Client) SendMessage(ctx context.Context, raw []byte) error {
+	msg := &gmail.Message{Raw: base64.URLEncoding.EncodeToString(raw)}
+	_, err := c.srv.Users.Messages.Send("me", msg).Context(ctx).Do()
+	return err
+}
+
 // ArchiveThread removes the INBOX label from a thread.
 func (c *Client) ArchiveThread(ctx context.Context, threadID string) error {
 	req := &gmail.ModifyThreadRequest{
@@ -220,6 +257,48 @@ func (c *Client) UntrashThread(ctx context.Context, threadID string) error {
 	return err
 }
 
+// ModifyThreadLabels adds and removes label IDs on a thread.
+func (c *Client) ModifyThreadLabels(
+	ctx context.Context,
+	threadID string,
+	add []string,
+	remove []string,
+) error {
+	req := &gmail.ModifyThreadRequest{
+		AddLabelIds:    add,
+		RemoveLabelIds: remove,
+	}
+	_, err := c.srv.Users.Threads.Modify("me", threadID, req).Context(ctx).Do()
+	return err
+}
+
+// GetThreadState fetches the labels and newest message date of a thread.
+func (c *Client) GetThreadState(ctx context.Context, threadID string) (*ThreadState, error) {
+	thread, err := c.srv.Users.Threads.Get("me", threadID).Format("minimal").Context(ctx).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	state := &ThreadState{}
+	seen := make(map[string]struct{})
+	for _, msg := range thread.Messages {
+		for _, label := range msg.LabelIds {
+			if _, ok := seen[label]; ok {
+				continue
+			}
+			seen[label] = struct{}{}
+			state.Labels = append(state.Labels, label)
+		}
+		if msg.InternalDate > 0 {
+			date := time.UnixMilli(msg.InternalDate)
+			if date.After(state.LatestDate) {
+				state.LatestDate = date
+			}
+		}
+	}
+	return state, nil
+}
+
 // DeleteThread permanently deletes a thread.
 func (c *Client) DeleteThread(ctx context.Context, threadID string) error {
 	return c.srv.Users.Threads.Delete("me", threadID).Context(ctx).Do()
@@ -230,40 +309,24 @@ func (c *Client) DownloadAttachment(
 	ctx context.Context,
 	messageID, attachmentID string,
 ) ([]byte, error) {
-	attachment, err := c.srv.Users.Messages.Attachments.Get("me", messageID, attachmentID).
-		Context(ctx).
-		Do()
-	if err != nil {
-		return nil, err
-	}
-
-	// Decode the base64 data
-	data, err := base64.URLEncoding.DecodeString(attachment.Data)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := c.DownloadAttachmentToWriter(ctx, messageID, attachmentID, &buf); err != nil {
 		return nil, err
 	}
-
-	return data, nil
+	return buf.Bytes(), nil
 }
 
 // DownloadAttachmentToWriter downloads an attachment and writes it to the provided writer
-// This streams the decode to avoid double-buffering in memory
+// The response is decoded as it streams in, so only a small buffer is held in memory
 func (c *Client) DownloadAttachmentToWriter(
 	ctx context.Context,
 	messageID, attachmentID string,
 	w io.Writer,
 ) error {
-	attachment, err := c.srv.Users.Messages.Attachments.Get("me", messageID, attachmentID).
-		Context(ctx).
-		Do()
-	if err != nil {
+	return c.streamAttachment(ctx, messageID, attachmentID, func(data io.Reader) error {
+		_, err := io.Copy(w, decodeAttachment(data))
 		return err
-	}
-
-	// Stream decode the base64 data directly to the writer
-	decoder := base64.NewDecoder(base64.URLEncoding, strings.NewReader(attachment.Data))
-	_, err = io.Copy(w, decoder)
-	return err
+	})
 }
 
 // GetAttachmentData returns the raw base64url-encoded attachment data
@@ -272,11 +335,13 @@ func (c *Client) GetAttachmentData(
 	ctx context.Context,
 	messageID, attachmentID string,
 ) (string, error) {
-	attachment, err := c.srv.Users.Messages.Attachments.Get("me", messageID, attachmentID).
-		Context(ctx).
-		Do()
+	var data strings.Builder
+	err := c.streamAttachment(ctx, messageID, attachmentID, func(r io.Reader) error {
+		_, err := io.Copy(&data, r)
+		return err
+	})
 	if err != nil {
 		return "", err
 	}
-	return attachment.Data, nil
+	return data.String(), nil
 }
@@ -3,6 +3,7 @@ package gmail
 import (
 	"encoding/base64"
 	"slices"
+	"strings"
 	"time"
 
 	"google.golang.org/api/gmail/v1"
@@ -42,9 +43,9 @@ func GmailToThread(messages []*gmail.Message) *Thread {
 		for _, header := range latest.Payload.Headers {
 			switch header.Name {
 			case "Subject":
-				thread.Subject = header.Value
+				thread.Subject = decodeHeader(header.Value)
 			case "From":
-				thread.From = header.Value
+				thread.From = decodeHeader(header.Value)
 			}
 		}
 
@@ -68,22 +69,36 @@ func GmailToMessage(msg *gmail.Message) *Message {
 	if msg.Payload != nil {
 		// Extract headers
 		for _, header := range msg.Payload.Headers {
+			message.Headers = append(message.Headers, Header{
+				Name:  header.Name,
+				Value: decodeHeader(header.Value),
+			})
 			switch header.Name {
 			case "From":
-				message.From = header.Value
+				message.From = decodeHeader(header.Value)
+				// Parse the raw value, since a decoded display name like
+				// "Doe, Jane" is no longer a valid address
+				if addr, err := addressParser.Parse(header.Value); err == nil {
+					message.FromAddress = addr.Address
+				}
 			case "To":
-				message.To = header.Value
+				message.To = decodeHeader(header.Value)
 			case "Cc":
-				message.Cc = header.Value
+				message.Cc = decodeHeader(header.Value)
 			case "Subject":
-				message.Subject = header.Value
+				message.Subject = decodeHeader(header.Value)
+			case "List-Unsubscribe":
+				message.Unsubscribe = parseListUnsubscribe(header.Value)
+			case "List-Unsubscribe-Post":
+				message.UnsubscribeOneClick = strings.EqualFold(
+					strings.TrimSpace(header.Value), "List-Unsubscribe=One-Click",
+				)
 			}
 		}
 
 		// Extract body and attachments
-		message.BodyText, message.BodyHTML, message.Attachments = extractBodyAndAttachments(
-			msg.Payload,
-		)
+		message.BodyText, message.BodyHTML, message.Attachments, message.InlineImages =
+			extractBodyAndAttachments(msg.Payload)
 	}
 
 	return message
@@ -98,15 +113,32 @@ func hasAttachments(payload *gmail.MessagePart) bool {
 	return slices.ContainsFunc(payload.Parts, hasAttachments)
 }
 
-// extractBodyAndAttachments walks the MIME parts and extracts body content and attachments
+// extractBodyAndAttachments walks the MIME parts and extracts body content,
+// attachments and inline images
 func extractBodyAndAttachments(
 	payload *gmail.MessagePart,
-) (text string, html string, attachments []Attachment) {
+) (text string, html string, attachments []Attachment, inline []Attachment) {
+	// Images with a Content-ID that aren't explicitly attachments are
+	// referenced from the HTML body. Named ones, like photos pasted into
+	// Apple Mail, are attachments too so they can be listed and saved.
+	if img, ok := inlineImage(payload); ok {
+		inline = append(inline, img)
+		if img.Filename != "" && img.AttachmentID != "" {
+			attachments = append(attachments, Attachment{
+				Filename:     img.Filename,
+				MimeType:     img.MimeType,
+				Size:         img.Size,
+				AttachmentID: img.AttachmentID,
+			})
+		}
+		return
+	}
+
 	// If this part has a filename, it's an attachment
 	if payload.Filename != "" && payload.Body != nil {
 		if payload.Body.AttachmentId != "" {
 			attachments = append(attachments, Attachment{
-				Filename:     payload.Filename,
+				Filename:     decodeHeader(payload.Filename),
 				MimeType:     payload.MimeType,
 				Size:         payload.Body.Size,
 				AttachmentID: payload.Body.AttachmentId,
@@ -121,16 +153,16 @@ func extractBodyAndAttachments(
 		if err == nil {
 			switch payload.MimeType {
 			case "text/plain":
-				text = string(decoded)
+				text = decodeBody(decoded, payload)
 			case "text/html":
-				html = string(decoded)
+				html = decodeBody(decoded, payload)
 			}
 		}
 	}
 
 	// Recursively process parts
 	for _, part := range payload.Parts {
-		partText, partHTML, partAttachments := extractBodyAndAttachments(part)
+		partText, partHTML, partAttachments, partInline := extractBodyAndAttachments(part)
 		if partText != "" && text == "" {
 			text = partText
 		}
@@ -138,7 +170,62 @@ func extractBodyAndAttachments(
 			html = partHTML
 		}
 		attachments = append(attachments, partAttachments...)
+		inline = append(inline, partInline...)
 	}
 
 	return
 }
+
+// inlineImage recognizes an image part carrying a Content-ID.
+func inlineImage(payload *gmail.MessagePart) (Attachment, bool) {
+	if payload.Body == nil || !strings.HasPrefix(strings.ToLower(payload.MimeType), "image/") {
+		return Attachment{}, false
+	}
+	var contentID, disposition string
+	for _, header := range payload.Headers {
+		switch strings.ToLower(header.Name) {
+		case "content-id":
+			contentID = strings.Trim(strings.TrimSpace(header.Value), "<>")
+		case "content-disposition":
+			disposition = strings.ToLower(header.Value)
+		}
+	}
+	if contentID == "" || strings.HasPrefix(disposition, "attachment") {
+		return Attachment{}, false
+	}
+	if payload.Body.AttachmentId == "" && payload.Body.Data == "" {
+		return Attachment{}, false
+	}
+	return Attachment{
+		Filename:     decodeHeader(payload.Filename),
+		MimeType:     payload.MimeType,
+		Size:         payload.Body.Size,
+		AttachmentID: payload.Body.AttachmentId,
+		ContentID:    contentID,
+		Data:         payload.Body.Data,
+	}, true
+}
+
+// parseListUnsubscribe pulls the bracketed mailto and http(s) links out of
+// a List-Unsubscribe header, in the sender's order of preference.
+func parseListUnsubscribe(value string) []string {
+	var links []string
+	for {
+		start := strings.Index(value, "<")
+		if start < 0 {
+			return links
+		}
+		end := strings.Index(value[start:], ">")
+		if end < 0 {
+			return links
+		}
+		// Long links get folded across lines
+		link := strings.Join(strings.Fields(value[start+1:start+end]), "")
+		lower := strings.ToLower(link)
+		if strings.HasPrefix(lower, "mailto:") || strings.HasPrefix(lower, "https://") ||
+			strings.HasPrefix(lower, "http://") {
+			links = append(links, link)
+		}
+		value = value[start+end+1:]
+	}
+}
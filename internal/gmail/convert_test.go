@@ -0,0 +1,67 @@
+package gmail
+
+import (
+	"testing"
+
+	"google.golang.org/api/gmail/v1"
+)
+
+func TestExtractInlineImages(t *testing.T) {
+	imagePart := func(filename, disposition string) *gmail.MessagePart {
+		headers := []*gmail.MessagePartHeader{{Name: "Content-ID", Value: "<img1@x>"}}
+		if disposition != "" {
+			headers = append(headers, &gmail.MessagePartHeader{Name: "Content-Disposition", Value: disposition})
+		}
+		return &gmail.MessagePart{
+			MimeType: "image/png",
+			Filename: filename,
+			Headers:  headers,
+			Body:     &gmail.MessagePartBody{AttachmentId: "att-1", Size: 10},
+		}
+	}
+	tests := []struct {
+		name            string
+		part            *gmail.MessagePart
+		wantAttachments int
+		wantInline      int
+	}{
+		{"nameless cid image", imagePart("", ""), 0, 1},
+		{"named inline image", imagePart("photo.png", `inline; filename="photo.png"`), 1, 1},
+		{"cid image attachment", imagePart("photo.png", `attachment; filename="photo.png"`), 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := &gmail.MessagePart{MimeType: "multipart/related", Parts: []*gmail.MessagePart{tt.part}}
+			_, _, attachments, inline := extractBodyAndAttachments(payload)
+			if len(attachments) != tt.wantAttachments {
+				t.Errorf("attachments = %d, want %d", len(attachments), tt.wantAttachments)
+			}
+			if len(inline) != tt.wantInline {
+				t.Errorf("inline images = %d, want %d", len(inline), tt.wantInline)
+			}
+			if hasAttachments(payload) != (tt.part.Filename != "") {
+				t.Errorf("hasAttachments = %t", hasAttachments(payload))
+			}
+		})
+	}
+}
+
+func TestFromAddress(t *testing.T) {
+	tests := []struct {
+		from string
+		want string
+	}{
+		{"Jane <jane@example.com>", "jane@example.com"},
+		{"=?UTF-8?Q?Doe=2C_Jane?= <j@example.com>", "j@example.com"},
+		{"=?ISO-8859-2?Q?=A9koda?= <news@example.cz>", "news@example.cz"},
+		{"not an address", ""},
+	}
+	for _, tt := range tests {
+		msg := GmailToMessage(&gmail.Message{Payload: &gmail.MessagePart{
+			Headers: []*gmail.MessagePartHeader{{Name: "From", Value: tt.from}},
+		}})
+		if msg.FromAddress != tt.want {
+			t.Errorf("FromAddress for %q = %q, want %q", tt.from, msg.FromAddress, tt.want)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package gmail
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Thread represents a conversation thread in the inbox
 type Thread struct {
@@ -40,6 +43,23 @@ type Message struct {
 	Raw         string       `json:"raw,omitempty"`
 	Labels      []string     `json:"labels"`
 	Attachments []Attachment `json:"attachments,omitempty"`
+	// InlineImages are image parts referenced from the HTML body by Content-ID
+	InlineImages []Attachment `json:"inline_images,omitempty"`
+	// Headers holds every header in the order the message has them
+	Headers []Header `json:"headers,omitempty"`
+	// FromAddress is the bare address in From, empty if it doesn't parse
+	FromAddress string `json:"from_address,omitempty"`
+	// Unsubscribe lists the mailto and http(s) links from List-Unsubscribe
+	Unsubscribe []string `json:"unsubscribe,omitempty"`
+	// UnsubscribeOneClick is set when List-Unsubscribe-Post allows an RFC
+	// 8058 one-click POST to the https link
+	UnsubscribeOneClick bool `json:"unsubscribe_one_click,omitempty"`
+}
+
+// Header is one message header, with encoded words decoded.
+type Header struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
 }
 
 // Attachment represents a file attachment
@@ -49,6 +69,27 @@ type Attachment struct {
 	Size     int64  `json:"size"`
 	// AttachmentID for fetching the actual data later
 	AttachmentID string `json:"attachment_id,omitempty"`
+	// ContentID is the part's Content-ID without angle brackets, for cid: URLs
+	ContentID string `json:"content_id,omitempty"`
+	// Data holds small parts that Gmail returns inline, base64url-encoded
+	Data string `json:"data,omitempty"`
+}
+
+// InlineImage returns the inline image for a cid: reference, if any.
+func (m *Message) InlineImage(contentID string) (Attachment, int, bool) {
+	contentID = strings.Trim(strings.TrimPrefix(contentID, "cid:"), "<>")
+	for i, img := range m.InlineImages {
+		if strings.EqualFold(img.ContentID, contentID) {
+			return img, i, true
+		}
+	}
+	return Attachment{}, -1, false
+}
+
+// ThreadState is the server-side label state of a thread.
+type ThreadState struct {
+	Labels     []string  `json:"labels"`
+	LatestDate time.Time `json:"latest_date"`
 }
 
 // InboxResponse is what we'd return from ListInbox RPC
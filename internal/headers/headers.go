@@ -0,0 +1,168 @@
+package headers
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// AuthResult is one method's verdict from an Authentication-Results header.
+type AuthResult struct {
+	// Method is the check that ran, like spf, dkim or dmarc
+	Method string
+	// Result is its outcome, like pass, fail, softfail or none
+	Result string
+	// Properties are what the result applies to, like header.from=example.com
+	Properties string
+}
+
+// Passed reports whether the check passed.
+func (r AuthResult) Passed() bool {
+	return r.Result == "pass"
+}
+
+// Failed reports whether the check failed outright, as opposed to not
+// applying or not being able to run.
+func (r AuthResult) Failed() bool {
+	switch r.Result {
+	case "fail", "softfail", "permerror":
+		return true
+	}
+	return false
+}
+
+// Hop is one relay a message passed through, from its Received header.
+type Hop struct {
+	From string
+	By   string
+	// Time is when the relay received the message, zero if it didn't say
+	Time time.Time
+	// Delay is how long the message took to get here from the hop before,
+	// zero for the first hop or when either time is unknown
+	Delay time.Duration
+}
+
+// ParseAuthResults reads an Authentication-Results header (RFC 8601),
+// skipping the server that added it and any comments.
+func ParseAuthResults(value string) []AuthResult {
+	clauses := strings.Split(stripComments(value), ";")
+	var results []AuthResult
+	for _, clause := range clauses[1:] {
+		fields := strings.Fields(clause)
+		if len(fields) == 0 {
+			continue
+		}
+		method, result, ok := strings.Cut(fields[0], "=")
+		if !ok {
+			continue
+		}
+		// Methods may carry a version, like dkim/1
+		method, _, _ = strings.Cut(method, "/")
+		results = append(results, AuthResult{
+			Method:     strings.ToLower(method),
+			Result:     strings.ToLower(result),
+			Properties: strings.Join(fields[1:], " "),
+		})
+	}
+	return results
+}
+
+// Route orders Received headers, which each relay adds above the last,
+// into the path the message took from its sender.
+func Route(received []string) []Hop {
+	hops := make([]Hop, 0, len(received))
+	for i := len(received) - 1; i >= 0; i-- {
+		hop := parseReceived(received[i])
+		if n := len(hops); n > 0 && !hop.Time.IsZero() && !hops[n-1].Time.IsZero() {
+			// Skewed clocks can put a hop before the one that handed it over
+			hop.Delay = max(hop.Time.Sub(hops[n-1].Time), 0)
+		}
+		hops = append(hops, hop)
+	}
+	return hops
+}
+
+func parseReceived(value string) Hop {
+	var hop Hop
+	value = strings.Join(strings.Fields(stripComments(value)), " ")
+	// The date comes last, after a semicolon
+	if i := strings.LastIndex(value, ";"); i >= 0 {
+		if t, err := mail.ParseDate(strings.TrimSpace(value[i+1:])); err == nil {
+			hop.Time = t
+		}
+		value = value[:i]
+	}
+	fields := strings.Fields(value)
+	for i := 0; i+1 < len(fields); i++ {
+		switch strings.ToLower(fields[i]) {
+		case "from":
+			if hop.From == "" {
+				hop.From = fields[i+1]
+			}
+		case "by":
+			if hop.By == "" {
+				hop.By = fields[i+1]
+			}
+		}
+	}
+	return hop
+}
+
+// Domain returns the lower cased domain of the first address in a header
+// like From or Reply-To.
+func Domain(address string) string {
+	if list, err := mail.ParseAddressList(address); err == nil && len(list) > 0 {
+		address = list[0].Address
+	}
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(strings.Trim(address[at+1:], "<> \t"))
+}
+
+// SameDomain reports whether two domains are the same or one is a subdomain
+// of the other, as with mail.example.com and example.com.
+func SameDomain(a, b string) bool {
+	return a == b || strings.HasSuffix(a, "."+b) || strings.HasSuffix(b, "."+a)
+}
+
+// Warnings lists reasons to distrust a message: the receiving server's
+// DMARC check failing, or replies going to a different domain than the
+// one the message claims to be from.
+func Warnings(from, replyTo string, auth []AuthResult) []string {
+	var warnings []string
+	for _, result := range auth {
+		if result.Method == "dmarc" && result.Failed() {
+			warnings = append(warnings, "DMARC failed: the sender may be forged")
+			break
+		}
+	}
+	if replyTo != "" {
+		fromDomain, replyDomain := Domain(from), Domain(replyTo)
+		if fromDomain != "" && replyDomain != "" && !SameDomain(fromDomain, replyDomain) {
+			warnings = append(warnings, fmt.Sprintf(
+				"Replies go to %s, not the sender's domain %s", replyDomain, fromDomain,
+			))
+		}
+	}
+	return warnings
+}
+
+// stripComments removes parenthesized comments, which may nest.
+func stripComments(value string) string {
+	var b strings.Builder
+	depth := 0
+	for _, r := range value {
+		switch {
+		case r == '(':
+			depth++
+		case r == ')' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
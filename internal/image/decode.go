@@ -0,0 +1,38 @@
+package image
+
+import (
+	"encoding/base64"
+	"fmt"
+	"image"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Decode decodes base64url-encoded image data from Gmail into an image.
+func Decode(base64urlData string) (image.Image, error) {
+	translator := &charTranslator{r: strings.NewReader(base64urlData)}
+	decoder := base64.NewDecoder(base64.StdEncoding, translator)
+	img, _, err := image.Decode(decoder)
+	if err != nil {
+		return nil, fmt.Errorf("decoding image: %w", err)
+	}
+	return img, nil
+}
+
+// Scale resamples img to exactly width x height pixels.
+func Scale(img image.Image, width, height int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, max(width, 1), max(height, 1)))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
+
+// FitSize shrinks width x height to fit within maxWidth x maxHeight, keeping
+// the aspect ratio. It never enlarges.
+func FitSize(width, height, maxWidth, maxHeight int) (int, int) {
+	if width <= 0 || height <= 0 || (width <= maxWidth && height <= maxHeight) {
+		return width, height
+	}
+	scale := min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	return max(1, int(float64(width)*scale)), max(1, int(float64(height)*scale))
+}
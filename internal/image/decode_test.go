@@ -0,0 +1,80 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The fixtures are small images from golang.org/x/image's testdata.
+func TestDecodeFormats(t *testing.T) {
+	tests := []struct {
+		file        string
+		contentType string
+		format      string
+	}{
+		{"lossless.webp", "image/webp", "webp"},
+		{"lossy.webp", "image/webp", "webp"},
+		{"1bpp.bmp", "image/bmp", "bmp"},
+		{"packbits.tiff", "image/tiff", "tiff"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, format, err := image.DecodeConfig(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format {
+				t.Fatalf("fixture format = %q, want %q", format, tt.format)
+			}
+			// Gmail sends attachments as base64url, which the translator
+			// has to turn back into standard base64
+			data := base64.URLEncoding.EncodeToString(raw)
+			if !strings.ContainsAny(data, "-_") {
+				t.Fatal("fixture doesn't exercise the base64url alphabet")
+			}
+
+			img, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if got := img.Bounds().Size(); got.X != want.Width || got.Y != want.Height {
+				t.Errorf("Decode size = %v, want %dx%d", got, want.Width, want.Height)
+			}
+
+			transformer, width, height, err := NewImageTransformer(data, tt.contentType)
+			if err != nil {
+				t.Fatalf("NewImageTransformer: %v", err)
+			}
+			defer transformer.Close()
+			if width != want.Width || height != want.Height {
+				t.Errorf("transformer size = %dx%d, want %dx%d", width, height, want.Width, want.Height)
+			}
+			encoded, err := io.ReadAll(transformer)
+			if err != nil {
+				t.Fatalf("reading transformer: %v", err)
+			}
+			pngData, err := base64.StdEncoding.DecodeString(string(encoded))
+			if err != nil {
+				t.Fatalf("transformer output isn't base64: %v", err)
+			}
+			converted, err := png.Decode(bytes.NewReader(pngData))
+			if err != nil {
+				t.Fatalf("transformer output isn't PNG: %v", err)
+			}
+			if converted.Bounds() != img.Bounds() {
+				t.Errorf("PNG bounds = %v, want %v", converted.Bounds(), img.Bounds())
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package image
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultFrameDelay matches browsers, which treat delays under 20ms as
+	// unset
+	defaultFrameDelay = 100 * time.Millisecond
+	minFrameDelay     = 20 * time.Millisecond
+	// maxFramePixels caps the memory spent holding composed frames, at 4
+	// bytes a pixel about 80MB
+	maxFramePixels = 20 * 1000 * 1000
+)
+
+// Frame is one fully composed frame of an animation.
+type Frame struct {
+	Image image.Image
+	Delay time.Duration
+}
+
+// DecodeFrames decodes the frames of a base64url-encoded GIF, composing each
+// onto the canvas so frames can be shown on their own. Frames past the
+// memory cap are left out; total is how many the GIF has.
+func DecodeFrames(base64urlData string) (frames []Frame, total int, err error) {
+	translator := &charTranslator{r: strings.NewReader(base64urlData)}
+	decoder := base64.NewDecoder(base64.StdEncoding, translator)
+	g, err := gif.DecodeAll(decoder)
+	if err != nil {
+		return nil, 0, fmt.Errorf("decoding gif: %w", err)
+	}
+	if len(g.Image) == 0 {
+		return nil, 0, errors.New("decoding gif: no frames")
+	}
+
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if bounds.Empty() {
+		bounds = g.Image[0].Bounds()
+	}
+	count := min(len(g.Image), max(1, maxFramePixels/max(1, bounds.Dx()*bounds.Dy())))
+
+	canvas := image.NewRGBA(bounds)
+	frames = make([]Frame, 0, count)
+	for i, src := range g.Image[:count] {
+		disposal := byte(gif.DisposalNone)
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, src.Bounds(), src, src.Bounds().Min, draw.Over)
+		delay := defaultFrameDelay
+		if i < len(g.Delay) {
+			if d := time.Duration(g.Delay[i]) * 10 * time.Millisecond; d >= minFrameDelay {
+				delay = d
+			}
+		}
+		frames = append(frames, Frame{Image: cloneRGBA(canvas), Delay: delay})
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, src.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames, len(g.Image), nil
+}
+
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	out := image.NewRGBA(img.Bounds())
+	copy(out.Pix, img.Pix)
+	return out
+}
+
+// EncodePNG returns img as base64 PNG, downscaled to fit within maxWidth x
+// maxHeight pixels.
+func EncodePNG(img image.Image, maxWidth, maxHeight int) (string, error) {
+	bounds := img.Bounds()
+	width, height := FitSize(bounds.Dx(), bounds.Dy(), maxWidth, maxHeight)
+	if width < bounds.Dx() || height < bounds.Dy() {
+		img = Scale(img, width, height)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -0,0 +1,48 @@
+package image
+
+import (
+	"fmt"
+	"image"
+	"strings"
+)
+
+// RenderHalfBlocks draws img with upper half block characters, packing two
+// pixel rows into each terminal row with 24-bit foreground and background
+// colors. It works on any truecolor terminal, with no graphics protocol.
+func RenderHalfBlocks(img image.Image) string {
+	b := img.Bounds()
+	var out strings.Builder
+	for y := b.Min.Y; y < b.Max.Y; y += 2 {
+		if y > b.Min.Y {
+			out.WriteString("\n")
+		}
+		for x := b.Min.X; x < b.Max.X; x++ {
+			tr, tg, tb, _ := img.At(x, y).RGBA()
+			br, bg, bb := tr, tg, tb
+			if y+1 < b.Max.Y {
+				br, bg, bb, _ = img.At(x, y+1).RGBA()
+			}
+			fmt.Fprintf(
+				&out,
+				"\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+				tr>>8, tg>>8, tb>>8, br>>8, bg>>8, bb>>8,
+			)
+		}
+		out.WriteString("\x1b[0m")
+	}
+	return out.String()
+}
+
+// RenderThumbnail draws img in half blocks, scaled up or down to fit within
+// cols x rows cells while keeping its aspect ratio.
+func RenderThumbnail(img image.Image, cols, rows int) string {
+	b := img.Bounds()
+	if b.Empty() || cols < 1 || rows < 1 {
+		return ""
+	}
+	// Each cell holds two roughly square pixels stacked vertically
+	scale := min(float64(cols)/float64(b.Dx()), float64(rows*2)/float64(b.Dy()))
+	width := max(1, int(float64(b.Dx())*scale))
+	height := max(1, int(float64(b.Dy())*scale))
+	return RenderHalfBlocks(Scale(img, width, height))
+}
@@ -0,0 +1,164 @@
+package image
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"slices"
+)
+
+const (
+	// maxSixelColors is the largest palette most sixel terminals accept
+	maxSixelColors = 256
+	// quantizeSamples caps how many pixels median cut looks at
+	quantizeSamples = 64 * 1024
+)
+
+type rgb [3]uint8
+
+// Quantize builds a palette of at most n colors for img using median cut.
+func Quantize(img image.Image, n int) color.Palette {
+	b := img.Bounds()
+	step := max(1, int(math.Sqrt(float64(b.Dx()*b.Dy())/quantizeSamples)))
+	pixels := make([]rgb, 0, min(b.Dx()*b.Dy(), quantizeSamples*2))
+	for y := b.Min.Y; y < b.Max.Y; y += step {
+		for x := b.Min.X; x < b.Max.X; x += step {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			pixels = append(pixels, rgb{uint8(r >> 8), uint8(g >> 8), uint8(bl >> 8)})
+		}
+	}
+	if len(pixels) == 0 {
+		return color.Palette{color.Black}
+	}
+
+	boxes := [][]rgb{pixels}
+	for len(boxes) < n {
+		// Split the box with the widest spread in any channel
+		split, channel, spread := -1, 0, 0
+		for i, box := range boxes {
+			if len(box) < 2 {
+				continue
+			}
+			if c, s := widestChannel(box); s > spread {
+				split, channel, spread = i, c, s
+			}
+		}
+		if split < 0 {
+			break
+		}
+		box := boxes[split]
+		slices.SortFunc(box, func(a, b rgb) int {
+			return int(a[channel]) - int(b[channel])
+		})
+		mid := len(box) / 2
+		boxes[split] = box[:mid]
+		boxes = append(boxes, box[mid:])
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		var sum [3]int
+		for _, p := range box {
+			sum[0] += int(p[0])
+			sum[1] += int(p[1])
+			sum[2] += int(p[2])
+		}
+		palette = append(palette, color.RGBA{
+			R: uint8(sum[0] / len(box)),
+			G: uint8(sum[1] / len(box)),
+			B: uint8(sum[2] / len(box)),
+			A: 0xff,
+		})
+	}
+	return palette
+}
+
+func widestChannel(box []rgb) (channel, spread int) {
+	for c := range 3 {
+		lo, hi := uint8(255), uint8(0)
+		for _, p := range box {
+			lo = min(lo, p[c])
+			hi = max(hi, p[c])
+		}
+		if s := int(hi) - int(lo); s > spread {
+			channel, spread = c, s
+		}
+	}
+	return channel, spread
+}
+
+// EncodeSixel writes img as a DEC sixel image, quantized to at most 256
+// colors with Floyd-Steinberg dithering.
+func EncodeSixel(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	palette := Quantize(img, maxSixelColors)
+	paletted := image.NewPaletted(b, palette)
+	draw.FloydSteinberg.Draw(paletted, b, img, b.Min)
+
+	bw := bufio.NewWriter(w)
+	// P2=1 leaves unset pixels alone so each color can be drawn in its own pass
+	fmt.Fprintf(bw, "\x1bP0;1;0q\"1;1;%d;%d", b.Dx(), b.Dy())
+	for i, c := range palette {
+		r, g, bl, _ := c.RGBA()
+		fmt.Fprintf(bw, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, bl*100/0xffff)
+	}
+
+	width := b.Dx()
+	masks := make([][]byte, len(palette))
+	used := make([]uint8, 0, len(palette))
+	for band := b.Min.Y; band < b.Max.Y; band += 6 {
+		used = used[:0]
+		for row := 0; row < 6 && band+row < b.Max.Y; row++ {
+			offset := paletted.PixOffset(b.Min.X, band+row)
+			for x, c := range paletted.Pix[offset : offset+width] {
+				if masks[c] == nil {
+					masks[c] = make([]byte, width)
+				}
+				if !slices.Contains(used, c) {
+					used = append(used, c)
+				}
+				masks[c][x] |= 1 << row
+			}
+		}
+		for i, c := range used {
+			if i > 0 {
+				// Carriage return to draw the next color over the same band
+				bw.WriteByte('$')
+			}
+			fmt.Fprintf(bw, "#%d", c)
+			writeSixelRow(bw, masks[c])
+			clear(masks[c])
+		}
+		bw.WriteByte('-')
+	}
+	bw.WriteString("\x1b\\")
+	return bw.Flush()
+}
+
+// writeSixelRow run-length encodes one color's sixels across a band.
+func writeSixelRow(w *bufio.Writer, mask []byte) {
+	// Trailing empty sixels draw nothing
+	end := len(mask)
+	for end > 0 && mask[end-1] == 0 {
+		end--
+	}
+	for x := 0; x < end; {
+		run := 1
+		for x+run < end && mask[x+run] == mask[x] {
+			run++
+		}
+		ch := '?' + mask[x]
+		if run > 3 {
+			fmt.Fprintf(w, "!%d%c", run, ch)
+		} else {
+			for range run {
+				w.WriteByte(ch)
+			}
+		}
+		x += run
+	}
+}
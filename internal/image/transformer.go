@@ -9,12 +9,17 @@ import (
 	"image/png"
 	"io"
 	"strings"
+
+	_ "golang.org/x/image/bmp"  // Register BMP format
+	_ "golang.org/x/image/tiff" // Register TIFF format
+	_ "golang.org/x/image/webp" // Register WebP format
 )
 
 // ImageTransformer transforms image data for display in terminals
 // It implements io.Reader and handles format conversion:
 // - PNG: passes through with base64url -> base64 translation
-// - JPEG/GIF/etc: decodes -> encodes to PNG -> base64
+// - JPEG/GIF/WebP/BMP/TIFF: decodes -> encodes to PNG -> base64
+// Images larger than the FitWithin box are downscaled before encoding.
 type ImageTransformer struct {
 	source      *strings.Reader
 	contentType string
@@ -23,6 +28,9 @@ type ImageTransformer struct {
 	initialized bool
 	// For cleanup: if converted is a PipeReader, we need to close it
 	pipeReader *io.PipeReader
+	// maxWidth and maxHeight bound the encoded image; zero means full size
+	maxWidth  int
+	maxHeight int
 }
 
 // NewImageTransformer creates a new image transformer
@@ -47,6 +55,14 @@ func NewImageTransformer(
 	return t, width, height, nil
 }
 
+// FitWithin downscales the image to fit in width x height pixels before it
+// is encoded, keeping its aspect ratio. Smaller images are left alone. It
+// must be called before the first Read.
+func (t *ImageTransformer) FitWithin(width, height int) {
+	t.maxWidth = width
+	t.maxHeight = height
+}
+
 // getDimensions decodes just the image config to get dimensions
 func (t *ImageTransformer) getDimensions() (int, int, error) {
 	// Reset to start
@@ -95,6 +111,15 @@ func (t *ImageTransformer) convertToPNG() error {
 		return fmt.Errorf("decoding %s image: %w", t.contentType, err)
 	}
 
+	// Shrink to the display box so we don't transmit pixels nobody sees
+	if t.maxWidth > 0 && t.maxHeight > 0 {
+		bounds := img.Bounds()
+		width, height := FitSize(bounds.Dx(), bounds.Dy(), t.maxWidth, t.maxHeight)
+		if width < bounds.Dx() || height < bounds.Dy() {
+			img = Scale(img, width, height)
+		}
+	}
+
 	// Create pipe for streaming PNG encode → base64 encode
 	pr, pw := io.Pipe()
 
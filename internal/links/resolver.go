@@ -45,6 +45,8 @@ type Resolver struct {
 	logf        func(string, ...any)
 	dnsMode     string
 	dnsServers  []string
+	// stripper removes tracking parameters from links, nil when disabled
+	stripper *paramStripper
 }
 
 func NewResolver(cfg config.LinkConfig, logf func(string, ...any)) *Resolver {
@@ -103,6 +105,7 @@ func NewResolver(cfg config.LinkConfig, logf func(string, ...any)) *Resolver {
 		denySet:    make(map[string]struct{}),
 		dnsMode:    resolverDNSMode,
 		dnsServers: resolverDNSServers,
+		stripper:   newParamStripper(cfg.StripParams),
 	}
 	logf("link resolver dns=%s servers=%v", resolver.dnsMode, resolver.dnsServers)
 	resolver.addDenied(cfg.DoNotResolve)
@@ -115,12 +118,22 @@ func NewResolver(cfg config.LinkConfig, logf func(string, ...any)) *Resolver {
 			resolver.addDomains(learnedDomains)
 		}
 	}
-	if len(resolver.domains) == 0 && !cfg.AutoScan {
+	if len(resolver.domains) == 0 && !cfg.AutoScan && resolver.stripper == nil {
 		return nil
 	}
 	return resolver
 }
 
+// HTTPClient returns the client links are resolved with, which uses the
+// configured DNS servers and doesn't follow redirects. Without a resolver
+// it's a plain client with the same timeout.
+func (r *Resolver) HTTPClient() *http.Client {
+	if r == nil {
+		return &http.Client{Timeout: defaultTimeout}
+	}
+	return r.client
+}
+
 func (r *Resolver) ResolveText(ctx context.Context, text string) string {
 	if r == nil || text == "" || !strings.Contains(text, "http") {
 		return text
@@ -160,7 +173,7 @@ func (r *Resolver) ResolveText(ctx context.Context, text string) string {
 		if resolvedURL, ok := resolved[match.trimmed]; ok && resolvedURL != "" {
 			replacement = resolvedURL
 		}
-		b.WriteString(replacement)
+		b.WriteString(r.stripper.strip(replacement))
 		b.WriteString(match.suffix)
 		last = match.end
 	}
@@ -169,6 +182,39 @@ func (r *Resolver) ResolveText(ctx context.Context, text string) string {
 	return b.String()
 }
 
+// Link is a URL found in text, along with where it leads once unwrapped.
+// Resolved is the same as Original when there was nothing to unwrap.
+type Link struct {
+	Original string
+	Resolved string
+}
+
+// Links lists the distinct URLs in text in the order they appear, each
+// with the URL ResolveText would put in its place: unwrapped, and without
+// tracking parameters. A nil resolver changes nothing.
+func (r *Resolver) Links(text string) []Link {
+	matches := extractURLMatches(text)
+	seen := make(map[string]struct{}, len(matches))
+	links := make([]Link, 0, len(matches))
+	for _, match := range matches {
+		if _, ok := seen[match.trimmed]; ok {
+			continue
+		}
+		seen[match.trimmed] = struct{}{}
+		link := Link{Original: match.trimmed, Resolved: match.trimmed}
+		if r != nil && r.cache != nil && r.shouldResolve(match.trimmed) {
+			if entry, ok := r.cache.Get(match.trimmed); ok && !entry.NoChange && entry.Resolved != "" {
+				link.Resolved = entry.Resolved
+			}
+		}
+		if r != nil {
+			link.Resolved = r.stripper.strip(link.Resolved)
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
 type ScanMode int
 
 const (
@@ -176,8 +222,23 @@ const (
 	ScanModeLearn
 )
 
+// CanScan reports whether ScanText could resolve anything in mode, so
+// callers can skip preparing text for it. Known mode only follows links on
+// unwrap domains; a resolver kept just for stripping parameters has none.
+func (r *Resolver) CanScan(mode ScanMode) bool {
+	if r == nil {
+		return false
+	}
+	if mode == ScanModeLearn {
+		return true
+	}
+	r.domainsMu.RLock()
+	defer r.domainsMu.RUnlock()
+	return len(r.domains) > 0
+}
+
 func (r *Resolver) ScanText(ctx context.Context, text string, mode ScanMode) {
-	if r == nil || text == "" || !strings.Contains(text, "http") {
+	if !r.CanScan(mode) || text == "" || !strings.Contains(text, "http") {
 		return
 	}
 	if ctx == nil {
@@ -508,7 +569,8 @@ func (r *Resolver) resolveURL(ctx context.Context, rawURL string) (cacheEntry, e
 
 	if isRedirectStatus(resp.StatusCode) {
 		if location := resp.Header.Get("Location"); location != "" {
-			resolved := resolveLocation(rawURL, location)
+			// The cleaned link is cached so it's what gets shown and copied
+			resolved := r.stripper.strip(resolveLocation(rawURL, location))
 			if resolved != "" && resolved != rawURL {
 				entry.Resolved = resolved
 				entry.NoChange = false
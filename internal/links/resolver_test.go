@@ -0,0 +1,35 @@
+package links
+
+import (
+	"testing"
+
+	"go.withmatt.com/inbox/internal/config"
+)
+
+func TestCanScan(t *testing.T) {
+	newResolver := func(domains ...string) *Resolver {
+		r := &Resolver{
+			domainSet: make(map[string]struct{}),
+			denySet:   make(map[string]struct{}),
+			stripper:  newParamStripper(config.StripParamsConfig{}),
+		}
+		r.addDomains(domains)
+		return r
+	}
+	tests := []struct {
+		name     string
+		resolver *Resolver
+		mode     ScanMode
+		want     bool
+	}{
+		{name: "nil", resolver: nil, mode: ScanModeLearn, want: false},
+		{name: "stripping only", resolver: newResolver(), mode: ScanModeKnown, want: false},
+		{name: "auto-scan", resolver: newResolver(), mode: ScanModeLearn, want: true},
+		{name: "unwrap domains", resolver: newResolver("t.co"), mode: ScanModeKnown, want: true},
+	}
+	for _, tt := range tests {
+		if got := tt.resolver.CanScan(tt.mode); got != tt.want {
+			t.Errorf("%s: CanScan() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
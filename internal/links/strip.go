@@ -0,0 +1,162 @@
+package links
+
+import (
+	"net/url"
+	"strings"
+
+	"go.withmatt.com/inbox/internal/config"
+)
+
+// defaultStripParams are tracking parameters that never change where a
+// link goes.
+var defaultStripParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"gbraid",
+	"wbraid",
+	"msclkid",
+	"yclid",
+	"twclid",
+	"ttclid",
+	"igshid",
+	"mc_cid",
+	"mc_eid",
+	"_hsenc",
+	"_hsmi",
+	"__hssc",
+	"__hstc",
+	"__hsfp",
+	"hsctatracking",
+	"mkt_tok",
+	"oly_anon_id",
+	"oly_enc_id",
+	"vero_id",
+	"vero_conv",
+	"ml_subscriber",
+	"ml_subscriber_hash",
+}
+
+// defaultStripDomains are parameters only some sites use for tracking.
+var defaultStripDomains = map[string][]string{
+	"amazon.com":   {"ref_", "pd_rd_*", "pf_rd_*", "content-id"},
+	"youtube.com":  {"si", "feature"},
+	"youtu.be":     {"si", "feature"},
+	"x.com":        {"s", "t"},
+	"twitter.com":  {"s", "t"},
+	"linkedin.com": {"trk", "trkInfo", "lipi", "midToken", "midSig", "trkEmail", "eid"},
+}
+
+type paramStripper struct {
+	params  []string
+	domains map[string][]string
+}
+
+// newParamStripper combines the built-in rules with the configured ones.
+// It returns nil when stripping is turned off.
+func newParamStripper(cfg config.StripParamsConfig) *paramStripper {
+	if cfg.Disabled {
+		return nil
+	}
+	keep := make(map[string]struct{}, len(cfg.Keep))
+	for _, name := range cfg.Keep {
+		keep[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+	}
+	filter := func(names ...[]string) []string {
+		var out []string
+		for _, list := range names {
+			for _, name := range list {
+				name = strings.ToLower(strings.TrimSpace(name))
+				if name == "" {
+					continue
+				}
+				if _, ok := keep[name]; ok {
+					continue
+				}
+				out = append(out, name)
+			}
+		}
+		return out
+	}
+
+	s := &paramStripper{
+		params:  filter(defaultStripParams, cfg.Params),
+		domains: make(map[string][]string, len(defaultStripDomains)+len(cfg.Domains)),
+	}
+	for domain, names := range defaultStripDomains {
+		s.domains[domain] = filter(names)
+	}
+	for domain, names := range cfg.Domains {
+		domain = normalizeDomain(domain)
+		if domain == "" {
+			continue
+		}
+		s.domains[domain] = append(s.domains[domain], filter(names)...)
+	}
+	return s
+}
+
+// strip removes tracking parameters from a URL, keeping the rest of the
+// query exactly as written.
+func (s *paramStripper) strip(rawURL string) string {
+	if s == nil {
+		return rawURL
+	}
+	base, rest, ok := strings.Cut(rawURL, "?")
+	if !ok {
+		return rawURL
+	}
+	rawQuery, fragment, hasFragment := strings.Cut(rest, "#")
+	parsed, err := url.Parse(rawURL)
+	if err != nil || rawQuery == "" {
+		return rawURL
+	}
+	host := strings.ToLower(parsed.Hostname())
+	var domainParams []string
+	for domain, names := range s.domains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			domainParams = append(domainParams, names...)
+		}
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	kept := make([]string, 0, len(pairs))
+	for _, pair := range pairs {
+		name, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(name); err == nil {
+			name = unescaped
+		}
+		name = strings.ToLower(name)
+		if matchParam(s.params, name) || matchParam(domainParams, name) {
+			continue
+		}
+		kept = append(kept, pair)
+	}
+	if len(kept) == len(pairs) {
+		return rawURL
+	}
+	stripped := base
+	if len(kept) > 0 {
+		stripped += "?" + strings.Join(kept, "&")
+	}
+	if hasFragment {
+		stripped += "#" + fragment
+	}
+	return stripped
+}
+
+func matchParam(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+			continue
+		}
+		if name == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package links
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Suspicious reports why a link may not go where it appears to, or "" if
+// nothing about it stands out. text is what the message shows for the link
+// and may be empty.
+func Suspicious(text, href string) string {
+	parsed, err := url.Parse(strings.TrimSpace(href))
+	if err != nil || parsed.Host == "" {
+		return ""
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+
+	if parsed.User != nil {
+		return fmt.Sprintf("the part before @ hides that it goes to %s", displayHost(host))
+	}
+	if net.ParseIP(host) != nil {
+		return fmt.Sprintf("goes to the IP address %s instead of a domain", host)
+	}
+	if reason := homograph(displayHost(host)); reason != "" {
+		return fmt.Sprintf("domain %s %s (%s)", displayHost(host), reason, asciiHost(host))
+	}
+	if shown := textHost(text); shown != "" && !sameSite(shown, host) {
+		return fmt.Sprintf("shows %s but goes to %s", shown, displayHost(host))
+	}
+	return ""
+}
+
+// textHost returns the host link text names when the text is a URL or a
+// bare domain, like "https://example.com/login" or "www.example.com".
+func textHost(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text, " \t\n") {
+		return ""
+	}
+	candidate := text
+	if !strings.Contains(candidate, "://") {
+		candidate = "http://" + candidate
+	}
+	parsed, err := url.Parse(candidate)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	dot := strings.LastIndex(host, ".")
+	if dot <= 0 {
+		return ""
+	}
+	// Require a real looking TLD so text like "v1.2" or "e.g." isn't a domain
+	tld := host[dot+1:]
+	if len(tld) < 2 {
+		return ""
+	}
+	for _, r := range tld {
+		if !unicode.IsLetter(r) {
+			return ""
+		}
+	}
+	return host
+}
+
+// sameSite treats hosts under the same registrable domain as one, so
+// www.example.com and mail.example.com match but two sites under a shared
+// suffix like github.io don't.
+func sameSite(a, b string) bool {
+	a, b = asciiHost(a), asciiHost(b)
+	siteA, errA := publicsuffix.EffectiveTLDPlusOne(a)
+	siteB, errB := publicsuffix.EffectiveTLDPlusOne(b)
+	if errA != nil || errB != nil {
+		// A bare public suffix only matches itself
+		return a == b
+	}
+	return siteA == siteB
+}
+
+// scripts are the writing systems a domain label is checked against.
+var scripts = []struct {
+	name  string
+	table *unicode.RangeTable
+}{
+	{"Latin", unicode.Latin},
+	{"Cyrillic", unicode.Cyrillic},
+	{"Greek", unicode.Greek},
+	{"Armenian", unicode.Armenian},
+	{"Han", unicode.Han},
+	{"Hiragana", unicode.Hiragana},
+	{"Katakana", unicode.Katakana},
+	{"Hangul", unicode.Hangul},
+	{"Bopomofo", unicode.Bopomofo},
+	{"Arabic", unicode.Arabic},
+	{"Hebrew", unicode.Hebrew},
+	{"Thai", unicode.Thai},
+	{"Devanagari", unicode.Devanagari},
+	{"Georgian", unicode.Georgian},
+	{"Cherokee", unicode.Cherokee},
+}
+
+// cjkScripts are the script mixes that are normal in one language, the
+// "highly restrictive" profile of Unicode TS #39.
+var cjkScripts = [][]string{
+	{"Latin", "Han", "Hiragana", "Katakana"},
+	{"Latin", "Han", "Bopomofo"},
+	{"Latin", "Han", "Hangul"},
+}
+
+// latinLookalikes are letters from other scripts, and unusual Latin ones,
+// that are hard to tell apart from plain ASCII letters.
+const latinLookalikes = "аеорсухіјѕԁһӏԛԝк" + // Cyrillic
+	"αικνορτυχ" + // Greek
+	"օսոհզց" + // Armenian
+	"ıȷɑɡɩᴀᴄᴅᴇᴋᴍᴏᴘᴛᴜᴠᴡᴢ" // Latin
+
+// homograph reports how a domain's labels could imitate a different,
+// ASCII domain, or "" if they can't. Labels written wholly in one script,
+// like münchen or 日本, are fine; it's mixing scripts, or spelling a word
+// out of letters that look like Latin ones, that fools readers.
+func homograph(host string) string {
+	for label := range strings.SplitSeq(host, ".") {
+		if isASCII(label) {
+			continue
+		}
+		var used []string
+		lookalikes, imitates := true, false
+		for _, r := range label {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			name := "other"
+			for _, script := range scripts {
+				if unicode.Is(script.table, r) {
+					name = script.name
+					break
+				}
+			}
+			if !slices.Contains(used, name) {
+				used = append(used, name)
+			}
+			if r >= utf8.RuneSelf {
+				imitates = true
+				lookalikes = lookalikes && strings.ContainsRune(latinLookalikes, r)
+			}
+		}
+		if len(used) > 1 && !allowedMix(used) {
+			return fmt.Sprintf("mixes %s letters, which can imitate another", strings.Join(used, " and "))
+		}
+		if lookalikes && imitates {
+			return "uses letters that look like plain Latin ones, which can imitate another"
+		}
+	}
+	return ""
+}
+
+func allowedMix(used []string) bool {
+	for _, allowed := range cjkScripts {
+		ok := true
+		for _, name := range used {
+			if !slices.Contains(allowed, name) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// displayHost shows a host the way it looks to a reader.
+func displayHost(host string) string {
+	if unicodeHost, err := idna.ToUnicode(host); err == nil {
+		return unicodeHost
+	}
+	return host
+}
+
+func asciiHost(host string) string {
+	if asciiHost, err := idna.ToASCII(host); err == nil {
+		return asciiHost
+	}
+	return host
+}
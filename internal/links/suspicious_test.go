@@ -0,0 +1,73 @@
+package links
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSuspicious(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		href string
+		// want is a fragment of the warning, or "" for none
+		want string
+	}{
+		{name: "plain", href: "https://example.com/a"},
+		{name: "text matches", text: "example.com", href: "https://www.example.com/login"},
+		{name: "subdomain", text: "https://example.com", href: "https://mail.example.com/"},
+		{name: "registrable suffix", text: "bbc.co.uk", href: "https://news.bbc.co.uk/"},
+		{name: "words", text: "Click here", href: "https://tracker.example.net/"},
+		{name: "text differs", text: "paypal.com", href: "https://evil.example/", want: "shows paypal.com but goes to evil.example"},
+		{name: "shared suffix", text: "github.io", href: "https://attacker.github.io/", want: "shows github.io"},
+		{name: "sibling on suffix", text: "me.github.io", href: "https://attacker.github.io/", want: "shows me.github.io"},
+		{name: "same suffix site", text: "me.github.io", href: "https://me.github.io/page"},
+		{name: "userinfo", href: "https://paypal.com@evil.example/", want: "the part before @"},
+		{name: "ip", href: "http://192.0.2.1/login", want: "IP address 192.0.2.1"},
+		{name: "idn", text: "münchen.de", href: "https://xn--mnchen-3ya.de/"},
+		{name: "idn unicode", href: "https://münchen.de/"},
+		{name: "han", href: "https://日本.jp/"},
+		{name: "japanese mix", href: "https://xn--eckwd4c7c.xn--zckzah/"},
+		{name: "cyrillic word", href: "https://почта.рф/"},
+		{name: "mixed latin cyrillic", href: "https://pаypal.com/", want: "mixes Latin and Cyrillic"},
+		{name: "mixed punycode", href: "https://xn--pypal-4ve.com/", want: "mixes Latin and Cyrillic"},
+		{name: "all cyrillic lookalikes", href: "https://аррӏе.com/", want: "look like plain Latin"},
+		{name: "greek lookalikes", href: "https://ορκ.com/", want: "look like plain Latin"},
+		{name: "dotless i", href: "https://lınkedin.com/", want: "look like plain Latin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Suspicious(tt.text, tt.href)
+			switch {
+			case tt.want == "" && got != "":
+				t.Errorf("Suspicious(%q, %q) = %q, want no warning", tt.text, tt.href, got)
+			case tt.want != "" && !strings.Contains(got, tt.want):
+				t.Errorf("Suspicious(%q, %q) = %q, want it to mention %q", tt.text, tt.href, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTextHost(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "example.com", want: "example.com"},
+		{text: " WWW.Example.COM ", want: "www.example.com"},
+		{text: "https://example.com/login?x=1", want: "example.com"},
+		{text: "example.com.", want: "example.com"},
+		{text: "münchen.de", want: "münchen.de"},
+		{text: "Click here", want: ""},
+		{text: "v1.2", want: ""},
+		{text: "e.g.", want: ""},
+		{text: "localhost", want: ""},
+		{text: "file.x", want: ""},
+		{text: "", want: ""},
+	}
+	for _, tt := range tests {
+		if got := textHost(tt.text); got != tt.want {
+			t.Errorf("textHost(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package mailcap
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// testTimeout bounds how long a test= command may take before the entry
+// is skipped.
+const testTimeout = 2 * time.Second
+
+// ErrNoHandler is returned when nothing can open a MIME type.
+var ErrNoHandler = errors.New("no mailcap entry")
+
+// Entry is one mailcap line.
+type Entry struct {
+	// Type is the MIME type pattern, like "image/png" or "image/*".
+	Type    string
+	Command string
+	// NeedsTerminal handlers take over the terminal until they exit.
+	NeedsTerminal bool
+	// CopiousOutput handlers print to stdout, which should be paged.
+	CopiousOutput bool
+	// Test is a command that must succeed for the entry to be used.
+	Test string
+	// NameTemplate gives the temp file a name the handler recognizes, like
+	// "%s.pdf".
+	NameTemplate string
+}
+
+// Mailcap is an ordered list of entries; the first match wins.
+type Mailcap struct {
+	entries []Entry
+}
+
+// DefaultPaths are read in order, so the user's own entries win.
+func DefaultPaths() []string {
+	var paths []string
+	if env := os.Getenv("MAILCAPS"); env != "" {
+		return filepath.SplitList(env)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".mailcap"))
+	}
+	return append(paths, "/etc/mailcap", "/usr/etc/mailcap", "/usr/local/etc/mailcap")
+}
+
+// Load reads overrides followed by the mailcap files at paths. Overrides map
+// a MIME type to the rest of a mailcap line, such as "zathura %s" or
+// "less %s; needsterminal". Files that are missing or can't be read are
+// skipped, so one bad system file doesn't cost the user their handlers.
+func Load(overrides map[string]string, paths []string) (*Mailcap, error) {
+	mc := &Mailcap{}
+	// Exact types go ahead of wildcards so the more specific override wins
+	types := slices.SortedFunc(maps.Keys(overrides), func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(strings.Count(a, "*"), strings.Count(b, "*")),
+			cmp.Compare(a, b),
+		)
+	})
+	for _, mimeType := range types {
+		entry, err := parseLine(mimeType + ";" + overrides[mimeType])
+		if err != nil {
+			return nil, fmt.Errorf("handler for %s: %w", mimeType, err)
+		}
+		mc.entries = append(mc.entries, entry)
+	}
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		entries, err := Parse(f)
+		f.Close()
+		if err != nil {
+			continue
+		}
+		mc.entries = append(mc.entries, entries...)
+	}
+	return mc, nil
+}
+
+// Parse reads mailcap entries, skipping comments and lines it can't use.
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	scanner := bufio.NewScanner(r)
+	var line strings.Builder
+	for scanner.Scan() {
+		text := scanner.Text()
+		// A trailing backslash continues the entry on the next line
+		if cont, ok := strings.CutSuffix(text, "\\"); ok {
+			line.WriteString(cont)
+			continue
+		}
+		line.WriteString(text)
+		full := strings.TrimSpace(line.String())
+		line.Reset()
+		if full == "" || strings.HasPrefix(full, "#") {
+			continue
+		}
+		if entry, err := parseLine(full); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+func parseLine(line string) (Entry, error) {
+	fields := splitFields(line)
+	if len(fields) < 2 || fields[0] == "" {
+		return Entry{}, errors.New("expected a type and a command")
+	}
+	entry := Entry{
+		Type:    strings.ToLower(fields[0]),
+		Command: fields[1],
+	}
+	for _, field := range fields[2:] {
+		name, value, _ := strings.Cut(field, "=")
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "needsterminal":
+			entry.NeedsTerminal = true
+		case "copiousoutput":
+			entry.CopiousOutput = true
+		case "test":
+			entry.Test = strings.TrimSpace(value)
+		case "nametemplate":
+			entry.NameTemplate = strings.TrimSpace(value)
+		}
+	}
+	return entry, nil
+}
+
+// splitFields splits on semicolons, honoring \; escapes.
+func splitFields(line string) []string {
+	var fields []string
+	var field strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line):
+			i++
+			if line[i] != ';' {
+				field.WriteByte('\\')
+			}
+			field.WriteByte(line[i])
+		case line[i] == ';':
+			fields = append(fields, strings.TrimSpace(field.String()))
+			field.Reset()
+		default:
+			field.WriteByte(line[i])
+		}
+	}
+	return append(fields, strings.TrimSpace(field.String()))
+}
+
+// Lookup returns the first entry for mimeType whose test passes.
+func (mc *Mailcap) Lookup(ctx context.Context, mimeType string) (Entry, error) {
+	mimeType = strings.ToLower(mimeType)
+	for _, entry := range mc.entries {
+		if !matches(entry.Type, mimeType) {
+			continue
+		}
+		if entry.Test != "" && !runTest(ctx, entry.Test, mimeType) {
+			continue
+		}
+		return entry, nil
+	}
+	return Entry{}, fmt.Errorf("%w for %s", ErrNoHandler, mimeType)
+}
+
+func matches(pattern, mimeType string) bool {
+	if pattern == mimeType {
+		return true
+	}
+	major, _, _ := strings.Cut(mimeType, "/")
+	return pattern == major+"/*" || pattern == major
+}
+
+func runTest(ctx context.Context, test, mimeType string) bool {
+	ctx, cancel := context.WithTimeout(ctx, testTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, "sh", "-c", expand(test, "", mimeType)).Run() == nil
+}
+
+// TempName names the file an attachment is written to before opening it,
+// keeping the extension the handler expects.
+func (e Entry) TempName(filename string) string {
+	if e.NameTemplate == "" {
+		return filename
+	}
+	ext := strings.TrimPrefix(e.NameTemplate, "%s")
+	if strings.HasSuffix(filename, ext) {
+		return filename
+	}
+	return filename + ext
+}
+
+// Cmd builds the shell command that opens path. Handlers without %s read the
+// file on stdin, and copious output is piped through $PAGER.
+func (e Entry) Cmd(path, mimeType string) *exec.Cmd {
+	command := expand(e.Command, path, mimeType)
+	if !strings.Contains(e.Command, "%s") {
+		command = "(" + command + ") < " + shellQuote(path)
+	}
+	if e.CopiousOutput {
+		pager := os.Getenv("PAGER")
+		if pager == "" {
+			pager = "less"
+		}
+		command += " | " + pager
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// Terminal reports whether the handler needs the terminal while it runs.
+func (e Entry) Terminal() bool {
+	return e.NeedsTerminal || e.CopiousOutput
+}
+
+// expand substitutes %s with the quoted path and %t with the MIME type.
+// Parameters like %{charset} aren't known, so they become empty.
+func expand(command, path, mimeType string) string {
+	// Entries often quote %s themselves; it gets quoted here instead
+	command = strings.NewReplacer(`'%s'`, "%s", `"%s"`, "%s").Replace(command)
+	var b strings.Builder
+	for i := 0; i < len(command); i++ {
+		if command[i] != '%' || i+1 == len(command) {
+			b.WriteByte(command[i])
+			continue
+		}
+		i++
+		switch command[i] {
+		case 's':
+			b.WriteString(shellQuote(path))
+		case 't':
+			b.WriteString(shellQuote(mimeType))
+		case '%':
+			b.WriteByte('%')
+		case '{':
+			if end := strings.IndexByte(command[i:], '}'); end >= 0 {
+				i += end
+			}
+		default:
+			b.WriteByte('%')
+			b.WriteByte(command[i])
+		}
+	}
+	return b.String()
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,85 @@
+package mailcap
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	tests := []struct {
+		command string
+		path    string
+		want    string
+	}{
+		{command: "zathura %s", path: "/tmp/a.pdf", want: "zathura '/tmp/a.pdf'"},
+		{command: "zathura '%s'", path: "/tmp/a.pdf", want: "zathura '/tmp/a.pdf'"},
+		{command: `zathura "%s"`, path: "/tmp/a.pdf", want: "zathura '/tmp/a.pdf'"},
+		{command: "iconv -f %{charset} %s", path: "/tmp/a.txt", want: "iconv -f  '/tmp/a.txt'"},
+		{command: "printf 100%% %s", path: "/tmp/a", want: "printf 100% '/tmp/a'"},
+		{command: "file --mime-type %t", path: "", want: "file --mime-type 'application/pdf'"},
+		{command: "open %s", path: "/tmp/it's.pdf", want: `open '/tmp/it'\''s.pdf'`},
+		{command: "open '%s'", path: "/tmp/it's.pdf", want: `open '/tmp/it'\''s.pdf'`},
+		{command: "open %s", path: "/tmp/a;rm -rf ~.pdf", want: "open '/tmp/a;rm -rf ~.pdf'"},
+		{command: "open %s", path: "/tmp/$(reboot).pdf", want: "open '/tmp/$(reboot).pdf'"},
+		{command: "open %s", path: "/tmp/a\nb.pdf", want: "open '/tmp/a\nb.pdf'"},
+		{command: "open %z 50%", path: "/tmp/a", want: "open %z 50%"},
+	}
+	for _, tt := range tests {
+		if got := expand(tt.command, tt.path, "application/pdf"); got != tt.want {
+			t.Errorf("expand(%q, %q) = %q, want %q", tt.command, tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestExpandShell checks that the shell sees an awkward filename as exactly
+// one argument, whether or not the entry quoted %s itself.
+func TestExpandShell(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	paths := []string{
+		"/tmp/it's.pdf",
+		"/tmp/a;echo pwned.pdf",
+		"/tmp/$(echo pwned).pdf",
+		"/tmp/`echo pwned`.pdf",
+		"/tmp/a\nb.pdf",
+		`/tmp/"quoted" $HOME.pdf`,
+	}
+	for _, command := range []string{"printf '<%%s>' %s", "printf '<%%s>' '%s'", `printf '<%%s>' "%s"`} {
+		for _, path := range paths {
+			out, err := exec.Command("sh", "-c", expand(command, path, "application/pdf")).Output()
+			if err != nil {
+				t.Fatalf("%q with %q: %v", command, path, err)
+			}
+			if want := "<" + path + ">"; string(out) != want {
+				t.Errorf("%q with %q printed %q, want %q", command, path, out, want)
+			}
+		}
+	}
+}
+
+func TestLoadSkipsUnreadableFiles(t *testing.T) {
+	dir := t.TempDir()
+	unreadable := filepath.Join(dir, "unreadable")
+	if err := os.Mkdir(unreadable, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	good := filepath.Join(dir, "mailcap")
+	if err := os.WriteFile(good, []byte("image/*; feh %s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A directory opens but can't be read, like a file without permission
+	// does for a user who isn't root.
+	paths := []string{filepath.Join(dir, "missing"), unreadable, good}
+	mc, err := Load(map[string]string{"application/pdf": "zathura %s"}, paths)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for _, mimeType := range []string{"application/pdf", "image/png"} {
+		if _, err := mc.Lookup(t.Context(), mimeType); err != nil {
+			t.Errorf("Lookup(%s): %v", mimeType, err)
+		}
+	}
+}
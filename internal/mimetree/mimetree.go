@@ -0,0 +1,160 @@
+package mimetree
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+
+	"go.withmatt.com/inbox/internal/charset"
+)
+
+// Part is one node of a message's MIME tree.
+type Part struct {
+	// Section is the IMAP style part number, like "2.1". Multipart
+	// containers at the top of a message have none.
+	Section     string
+	Depth       int
+	ContentType string
+	Charset     string
+	// Encoding is the Content-Transfer-Encoding, lower cased
+	Encoding    string
+	Disposition string
+	Filename    string
+	// Size is the length of the body as it appears in the message
+	Size   int64
+	Header textproto.MIMEHeader
+	// Body is the part's content, still transfer encoded. Multipart
+	// containers have none.
+	Body     []byte
+	Children []*Part
+}
+
+// Parse reads a raw RFC 822 message into its tree of parts. Parts after a
+// malformed boundary are dropped rather than failing the whole message.
+func Parse(raw string) (*Part, error) {
+	msg, err := mail.ReadMessage(strings.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(msg.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseEntity(textproto.MIMEHeader(msg.Header), body, "", 0), nil
+}
+
+// Flatten lists a tree's parts in order, parents before their children.
+func Flatten(root *Part) []*Part {
+	parts := []*Part{root}
+	for _, child := range root.Children {
+		parts = append(parts, Flatten(child)...)
+	}
+	return parts
+}
+
+// parseEntity parses a message or embedded message. Its own parts are
+// numbered under prefix.
+func parseEntity(header textproto.MIMEHeader, body []byte, prefix string, depth int) *Part {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") {
+		return parsePart(header, body, "", prefix, depth)
+	}
+	section := joinSection(prefix, 1)
+	return parsePart(header, body, section, section, depth)
+}
+
+func parsePart(header textproto.MIMEHeader, body []byte, section, prefix string, depth int) *Part {
+	part := &Part{
+		Section:     section,
+		Depth:       depth,
+		ContentType: "text/plain",
+		Encoding:    strings.ToLower(strings.TrimSpace(header.Get("Content-Transfer-Encoding"))),
+		Size:        int64(len(body)),
+		Header:      header,
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err == nil {
+		part.ContentType = mediaType
+		part.Charset = params["charset"]
+	}
+	if disposition, dispParams, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil {
+		part.Disposition = disposition
+		part.Filename = dispParams["filename"]
+	}
+	if part.Filename == "" {
+		part.Filename = params["name"]
+	}
+	if decoded, err := charset.HeaderDecoder.DecodeHeader(part.Filename); err == nil {
+		part.Filename = decoded
+	}
+
+	switch {
+	case strings.HasPrefix(part.ContentType, "multipart/") && params["boundary"] != "":
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for i := 1; ; i++ {
+			// Raw parts keep their transfer encoding, unlike NextPart
+			child, err := reader.NextRawPart()
+			if err != nil {
+				break
+			}
+			data, err := io.ReadAll(child)
+			if err != nil {
+				break
+			}
+			childSection := joinSection(prefix, i)
+			part.Children = append(part.Children, parsePart(child.Header, data, childSection, childSection, depth+1))
+		}
+	case part.ContentType == "message/rfc822" && !isEncoded(part.Encoding):
+		part.Body = body
+		if msg, err := mail.ReadMessage(bytes.NewReader(body)); err == nil {
+			if inner, err := io.ReadAll(msg.Body); err == nil {
+				part.Children = []*Part{parseEntity(textproto.MIMEHeader(msg.Header), inner, section, depth+1)}
+			}
+		}
+	default:
+		part.Body = body
+	}
+	return part
+}
+
+// Decode undoes the part's transfer encoding.
+func (p *Part) Decode() ([]byte, error) {
+	switch p.Encoding {
+	case "base64":
+		// Senders wrap lines and sometimes drop the padding
+		cleaned := strings.TrimRight(strings.Join(strings.Fields(string(p.Body)), ""), "=")
+		return base64.RawStdEncoding.DecodeString(cleaned)
+	case "quoted-printable":
+		return io.ReadAll(quotedprintable.NewReader(bytes.NewReader(p.Body)))
+	default:
+		return p.Body, nil
+	}
+}
+
+// Text decodes a part and converts it to UTF-8 the same way the message
+// body is.
+func (p *Part) Text() (string, error) {
+	data, err := p.Decode()
+	if err != nil {
+		return "", err
+	}
+	return charset.Decode(data, p.Charset, p.ContentType), nil
+}
+
+func isEncoded(encoding string) bool {
+	return encoding == "base64" || encoding == "quoted-printable"
+}
+
+func joinSection(prefix string, n int) string {
+	if prefix == "" {
+		return strconv.Itoa(n)
+	}
+	return prefix + "." + strconv.Itoa(n)
+}
@@ -0,0 +1,411 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/adrg/xdg"
+	_ "modernc.org/sqlite"
+)
+
+const queueFileName = "outbox.sqlite"
+
+// ErrUnavailable is returned when the queue could not be opened.
+var ErrUnavailable = errors.New("action queue unavailable")
+
+// ErrConflict marks queued operations that can no longer be applied safely.
+var ErrConflict = errors.New("conflict")
+
+// Kind identifies a queued thread operation.
+type Kind string
+
+const (
+	KindArchive      Kind = "archive"
+	KindUnarchive    Kind = "unarchive"
+	KindTrash        Kind = "trash"
+	KindUntrash      Kind = "untrash"
+	KindMarkRead     Kind = "mark_read"
+	KindMarkUnread   Kind = "mark_unread"
+	KindModifyLabels Kind = "modify_labels"
+)
+
+// State tracks where an operation is in its lifecycle.
+type State string
+
+const (
+	StatePending State = "pending"
+	StateFailed  State = "failed"
+)
+
+// Op is a thread operation waiting to be applied to Gmail.
+type Op struct {
+	ID           int64
+	Kind         Kind
+	Account      string
+	ThreadID     string
+	Subject      string
+	AddLabels    []string
+	RemoveLabels []string
+	CreatedAt    time.Time
+	Attempts     int
+	State        State
+	LastError    string
+}
+
+// Queue is a durable, ordered list of thread operations.
+type Queue struct {
+	db *sql.DB
+	mu sync.Mutex
+}
+
+// Open opens (or creates) the queue in the XDG state directory.
+func Open() (*Queue, error) {
+	path, err := xdg.StateFile(filepath.Join("inbox", queueFileName))
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(context.Background(), `
+		CREATE TABLE IF NOT EXISTS outbox (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			account TEXT NOT NULL,
+			thread_id TEXT NOT NULL,
+			subject TEXT NOT NULL,
+			add_labels TEXT NOT NULL,
+			remove_labels TEXT NOT NULL,
+			created_at INTEGER NOT NULL,
+			attempts INTEGER NOT NULL,
+			state TEXT NOT NULL,
+			last_error TEXT NOT NULL
+		)
+	`); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &Queue{db: db}, nil
+}
+
+// Close releases the underlying database.
+func (q *Queue) Close() error {
+	if q == nil || q.db == nil {
+		return nil
+	}
+	return q.db.Close()
+}
+
+// Enqueue records a pending operation. Queuing the inverse of an operation
+// that is still pending cancels both, and queuing a duplicate is a no-op.
+// The returned bool reports whether an operation is now pending.
+func (q *Queue) Enqueue(op Op) (bool, error) {
+	if q == nil || q.db == nil {
+		return false, ErrUnavailable
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	ctx := context.Background()
+	if inverse, ok := op.Kind.Inverse(); ok {
+		res, err := q.db.ExecContext(
+			ctx,
+			`DELETE FROM outbox WHERE state = ? AND account = ? AND thread_id = ? AND kind = ?`,
+			StatePending, op.Account, op.ThreadID, inverse,
+		)
+		if err != nil {
+			return false, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			return false, nil
+		}
+	}
+	if op.Kind != KindModifyLabels {
+		var count int
+		err := q.db.QueryRowContext(
+			ctx,
+			`SELECT COUNT(*) FROM outbox
+				WHERE state = ? AND account = ? AND thread_id = ? AND kind = ?`,
+			StatePending, op.Account, op.ThreadID, op.Kind,
+		).Scan(&count)
+		if err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+
+	createdAt := op.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	if _, err := q.db.ExecContext(ctx, `
+		INSERT INTO outbox (
+			kind, account, thread_id, subject, add_labels, remove_labels,
+			created_at, attempts, state, last_error
+		) VALUES (?, ?, ?, ?, ?, ?, ?, 0, ?, '')
+	`,
+		op.Kind,
+		op.Account,
+		op.ThreadID,
+		op.Subject,
+		joinLabels(op.AddLabels),
+		joinLabels(op.RemoveLabels),
+		createdAt.UnixMilli(),
+		StatePending,
+	); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the operations in the given state, oldest first.
+func (q *Queue) List(state State) ([]Op, error) {
+	if q == nil || q.db == nil {
+		return nil, nil
+	}
+
+	rows, err := q.db.QueryContext(context.Background(), `
+		SELECT id, kind, account, thread_id, subject, add_labels, remove_labels,
+			created_at, attempts, state, last_error
+		FROM outbox WHERE state = ? ORDER BY id ASC
+	`, state)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ops []Op
+	for rows.Next() {
+		var op Op
+		var addLabels, removeLabels string
+		var createdAt int64
+		if err := rows.Scan(
+			&op.ID,
+			&op.Kind,
+			&op.Account,
+			&op.ThreadID,
+			&op.Subject,
+			&addLabels,
+			&removeLabels,
+			&createdAt,
+			&op.Attempts,
+			&op.State,
+			&op.LastError,
+		); err != nil {
+			return nil, err
+		}
+		op.AddLabels = splitLabels(addLabels)
+		op.RemoveLabels = splitLabels(removeLabels)
+		op.CreatedAt = time.UnixMilli(createdAt)
+		ops = append(ops, op)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// Delete removes an operation, typically after it has been applied.
+func (q *Queue) Delete(id int64) error {
+	return q.exec(`DELETE FROM outbox WHERE id = ?`, id)
+}
+
+// Cancel removes a pending operation of the given kind for a thread.
+// It reports whether anything was removed.
+func (q *Queue) Cancel(account, threadID string, kind Kind) (bool, error) {
+	if q == nil || q.db == nil {
+		return false, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	res, err := q.db.ExecContext(
+		context.Background(),
+		`DELETE FROM outbox WHERE state = ? AND account = ? AND thread_id = ? AND kind = ?`,
+		StatePending, account, threadID, kind,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RecordAttempt notes a replay attempt that failed transiently.
+func (q *Queue) RecordAttempt(id int64, reason string) error {
+	return q.exec(
+		`UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE id = ?`,
+		reason, id,
+	)
+}
+
+// MarkFailed moves an operation out of the replay queue for good.
+func (q *Queue) MarkFailed(id int64, reason string) error {
+	return q.exec(
+		`UPDATE outbox SET attempts = attempts + 1, state = ?, last_error = ? WHERE id = ?`,
+		StateFailed, reason, id,
+	)
+}
+
+// RequeueFailed moves every failed operation back into the pending queue.
+func (q *Queue) RequeueFailed() error {
+	return q.exec(
+		`UPDATE outbox SET state = ?, attempts = 0, last_error = '' WHERE state = ?`,
+		StatePending, StateFailed,
+	)
+}
+
+// ClearFailed drops every failed operation.
+func (q *Queue) ClearFailed() error {
+	return q.exec(`DELETE FROM outbox WHERE state = ?`, StateFailed)
+}
+
+func (q *Queue) exec(query string, args ...any) error {
+	if q == nil || q.db == nil {
+		return ErrUnavailable
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	_, err := q.db.ExecContext(context.Background(), query, args...)
+	return err
+}
+
+// Check compares a queued operation against the thread's current labels and
+// the date of its newest message. It reports whether the server already
+// reflects the operation, or returns an ErrConflict when replaying it would
+// clobber mail that arrived after the operation was queued. Label operations
+// must be checked with label IDs.
+func (op Op) Check(labels []string, latest time.Time) (bool, error) {
+	newer := !latest.IsZero() && latest.After(op.CreatedAt)
+	switch op.Kind {
+	case KindArchive:
+		if !slices.Contains(labels, "INBOX") {
+			return true, nil
+		}
+		if newer {
+			return false, fmt.Errorf("%w: new messages arrived after archiving", ErrConflict)
+		}
+	case KindTrash:
+		if slices.Contains(labels, "TRASH") {
+			return true, nil
+		}
+		if newer {
+			return false, fmt.Errorf("%w: new messages arrived after trashing", ErrConflict)
+		}
+	case KindUnarchive:
+		return slices.Contains(labels, "INBOX"), nil
+	case KindUntrash:
+		return !slices.Contains(labels, "TRASH"), nil
+	case KindMarkRead:
+		if !slices.Contains(labels, "UNREAD") {
+			return true, nil
+		}
+		if newer {
+			return false, fmt.Errorf("%w: new messages arrived after marking read", ErrConflict)
+		}
+	case KindMarkUnread:
+		return slices.Contains(labels, "UNREAD"), nil
+	case KindModifyLabels:
+		for _, label := range op.AddLabels {
+			if !slices.Contains(labels, label) {
+				return false, nil
+			}
+		}
+		for _, label := range op.RemoveLabels {
+			if slices.Contains(labels, label) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// Describe returns a short human label for the operation.
+func (op Op) Describe() string {
+	switch op.Kind {
+	case KindArchive:
+		return "archive"
+	case KindUnarchive:
+		return "unarchive"
+	case KindTrash:
+		return "trash"
+	case KindUntrash:
+		return "untrash"
+	case KindMarkRead:
+		return "mark read"
+	case KindMarkUnread:
+		return "mark unread"
+	case KindModifyLabels:
+		return "label " + formatLabelChange(op.AddLabels, op.RemoveLabels)
+	default:
+		return string(op.Kind)
+	}
+}
+
+func formatLabelChange(add, remove []string) string {
+	parts := make([]string, 0, len(add)+len(remove))
+	for _, label := range add {
+		parts = append(parts, "+"+label)
+	}
+	for _, label := range remove {
+		parts = append(parts, "-"+label)
+	}
+	return strings.Join(parts, " ")
+}
+
+// Inverse returns the operation that undoes kind, if there is one.
+func (kind Kind) Inverse() (Kind, bool) {
+	switch kind {
+	case KindArchive:
+		return KindUnarchive, true
+	case KindUnarchive:
+		return KindArchive, true
+	case KindTrash:
+		return KindUntrash, true
+	case KindUntrash:
+		return KindTrash, true
+	case KindMarkRead:
+		return KindMarkUnread, true
+	case KindMarkUnread:
+		return KindMarkRead, true
+	case KindModifyLabels:
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+// Label names can't contain newlines, so they make a safe separator.
+func joinLabels(labels []string) string {
+	return strings.Join(labels, "\n")
+}
+
+func splitLabels(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, "\n")
+}
@@ -0,0 +1,675 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// keyAction is a named operation that can be triggered by a key binding, the
+// command palette, or the `:` command line.
+type keyAction struct {
+	name    string
+	desc    string
+	binding func(keyMap) key.Binding
+	run     func(Model) (Model, tea.Cmd)
+	// counted runs the action with a count prefix; nil ignores the count
+	counted func(Model, int) (Model, tea.Cmd)
+	// passthrough also forwards the key to the viewport when run returns no command
+	passthrough bool
+}
+
+func listActions() []keyAction {
+	return []keyAction{
+		{
+			name:    "quit",
+			desc:    "Quit inbox",
+			binding: func(k keyMap) key.Binding { return k.list.Quit },
+			run:     func(m Model) (Model, tea.Cmd) { return m, tea.Quit },
+		},
+		{
+			name:    "help",
+			desc:    "Show keyboard shortcuts",
+			binding: func(k keyMap) key.Binding { return k.list.Help },
+			run:     Model.showHelp,
+		},
+		{
+			name:    "palette",
+			desc:    "Open the command palette",
+			binding: func(k keyMap) key.Binding { return k.list.Palette },
+			run:     Model.openPalette,
+		},
+		{
+			name:    "command",
+			desc:    "Open the command line",
+			binding: func(k keyMap) key.Binding { return k.list.Command },
+			run:     func(m Model) (Model, tea.Cmd) { return m.openCommandLine("") },
+		},
+		{
+			name:    "failed-actions",
+			desc:    "Review queued actions that failed",
+			binding: func(k keyMap) key.Binding { return k.list.FailedActions },
+			run:     Model.showFailedActions,
+		},
+		{
+			name:    "refresh",
+			desc:    "Refresh the inbox",
+			binding: func(k keyMap) key.Binding { return k.list.Refresh },
+			run:     Model.refreshInbox,
+		},
+		{
+			name:    "toggle-read",
+			desc:    "Toggle read/unread",
+			binding: func(k keyMap) key.Binding { return k.list.ToggleRead },
+			run:     Model.toggleRead,
+		},
+		{
+			name:    "toggle-select",
+			desc:    "Select or deselect the thread",
+			binding: func(k keyMap) key.Binding { return k.list.ToggleSelect },
+			run:     Model.toggleSelect,
+			counted: Model.selectDown,
+		},
+		{
+			name:    "clear-selection",
+			desc:    "Clear the selection",
+			binding: func(k keyMap) key.Binding { return k.list.ClearSelection },
+			run: func(m Model) (Model, tea.Cmd) {
+				m.clearSelection()
+				return m, nil
+			},
+		},
+		{
+			name:    "archive",
+			desc:    "Archive selected threads",
+			binding: func(k keyMap) key.Binding { return k.list.Archive },
+			run: func(m Model) (Model, tea.Cmd) {
+				return m.confirmThreadAction(deleteActionArchive)
+			},
+		},
+		{
+			name:    "trash",
+			desc:    "Move selected threads to trash",
+			binding: func(k keyMap) key.Binding { return k.list.Delete },
+			run: func(m Model) (Model, tea.Cmd) {
+				return m.confirmThreadAction(deleteActionTrash)
+			},
+		},
+		{
+			name:    "delete-forever",
+			desc:    "Permanently delete selected threads",
+			binding: func(k keyMap) key.Binding { return k.list.DeleteForever },
+			run: func(m Model) (Model, tea.Cmd) {
+				return m.confirmThreadAction(deleteActionPermanent)
+			},
+		},
+		{
+			name:    "undo",
+			desc:    "Undo the last archive or trash",
+			binding: func(k keyMap) key.Binding { return k.list.Undo },
+			run:     Model.undoLastAction,
+		},
+		{
+			name:    "page-up",
+			desc:    "Scroll up a page",
+			binding: func(k keyMap) key.Binding { return k.list.PageUp },
+			run:     Model.pageUp,
+			counted: repeated(Model.pageUp),
+		},
+		{
+			name:    "page-down",
+			desc:    "Scroll down a page",
+			binding: func(k keyMap) key.Binding { return k.list.PageDown },
+			run:     Model.pageDown,
+			counted: repeated(Model.pageDown),
+		},
+		{
+			name:    "up",
+			desc:    "Move up",
+			binding: func(k keyMap) key.Binding { return k.list.Up },
+			run:     Model.cursorUp,
+			counted: func(m Model, n int) (Model, tea.Cmd) { return m.moveCursor(-n) },
+		},
+		{
+			name:    "down",
+			desc:    "Move down",
+			binding: func(k keyMap) key.Binding { return k.list.Down },
+			run:     Model.cursorDown,
+			counted: Model.moveCursor,
+		},
+		{
+			name:    "go-top",
+			desc:    "Go to the first thread",
+			binding: func(k keyMap) key.Binding { return k.list.GoTop },
+			run:     func(m Model) (Model, tea.Cmd) { return m.goToThread(1) },
+			counted: Model.goToThread,
+		},
+		{
+			name:    "go-bottom",
+			desc:    "Go to the last thread",
+			binding: func(k keyMap) key.Binding { return k.list.GoBottom },
+			run:     func(m Model) (Model, tea.Cmd) { return m.goToThread(m.displayCount()) },
+			counted: Model.goToThread,
+		},
+		{
+			name:    "go-inbox",
+			desc:    "Show the whole inbox",
+			binding: func(k keyMap) key.Binding { return k.list.GoInbox },
+			run:     Model.goToInbox,
+		},
+		{
+			name:    "go-archive",
+			desc:    "Show archived threads",
+			binding: func(k keyMap) key.Binding { return k.list.GoArchive },
+			run:     Model.goToArchive,
+		},
+		{
+			name:    "open",
+			desc:    "Open the thread",
+			binding: func(k keyMap) key.Binding { return k.list.Open },
+			run:     Model.openSelected,
+		},
+		{
+			name:    "focus-pane",
+			desc:    "Move focus to the preview pane",
+			binding: func(k keyMap) key.Binding { return k.list.FocusPane },
+			run:     Model.focusPreview,
+		},
+		{
+			name:    "search",
+			desc:    "Search threads",
+			binding: func(k keyMap) key.Binding { return k.list.Search },
+			run:     Model.startSearch,
+		},
+	}
+}
+
+func detailActions() []keyAction {
+	return []keyAction{
+		{
+			name:    "quit",
+			desc:    "Quit inbox",
+			binding: func(k keyMap) key.Binding { return k.detail.Quit },
+			run:     func(m Model) (Model, tea.Cmd) { return m, tea.Quit },
+		},
+		{
+			name:    "help",
+			desc:    "Show keyboard shortcuts",
+			binding: func(k keyMap) key.Binding { return k.detail.Help },
+			run:     Model.showHelp,
+		},
+		{
+			name:    "palette",
+			desc:    "Open the command palette",
+			binding: func(k keyMap) key.Binding { return k.detail.Palette },
+			run:     Model.openPalette,
+		},
+		{
+			name:    "command",
+			desc:    "Open the command line",
+			binding: func(k keyMap) key.Binding { return k.detail.Command },
+			run:     func(m Model) (Model, tea.Cmd) { return m.openCommandLine("") },
+		},
+		{
+			name:    "attachments",
+			desc:    "Show attachments",
+			binding: func(k keyMap) key.Binding { return k.detail.Attachments },
+			run:     Model.showAttachments,
+		},
+		{
+			name:    "save-attachments",
+			desc:    "Save every attachment in the thread",
+			binding: func(k keyMap) key.Binding { return k.detail.SaveAll },
+			run:     Model.saveThreadAttachments,
+		},
+		{
+			name:    "inline-image",
+			desc:    "Open an inline image (count picks which)",
+			binding: func(k keyMap) key.Binding { return k.detail.InlineImage },
+			run:     func(m Model) (Model, tea.Cmd) { return m.openInlineImage(1) },
+			counted: Model.openInlineImage,
+		},
+		{
+			name:    "gallery",
+			desc:    "Browse every image in the thread",
+			binding: func(k keyMap) key.Binding { return k.detail.Gallery },
+			run:     Model.openGallery,
+		},
+		{
+			name:    "mime-structure",
+			desc:    "Inspect the MIME parts of the message",
+			binding: func(k keyMap) key.Binding { return k.detail.MimeTree },
+			run:     Model.showMimeTree,
+		},
+		{
+			name:    "focus-pane",
+			desc:    "Move focus to the thread list",
+			binding: func(k keyMap) key.Binding { return k.detail.FocusPane },
+			run:     Model.focusList,
+		},
+		{
+			name:    "back",
+			desc:    "Back to the thread list",
+			binding: func(k keyMap) key.Binding { return k.detail.Back },
+			run: func(m Model) (Model, tea.Cmd) {
+				return m, m.exitDetailView()
+			},
+		},
+		{
+			name:    "toggle-view",
+			desc:    "Cycle text, HTML and raw views",
+			binding: func(k keyMap) key.Binding { return k.detail.ToggleView },
+			run:     Model.toggleMessageView,
+		},
+		{
+			name:    "toggle-quotes",
+			desc:    "Show or hide quoted text and signatures",
+			binding: func(k keyMap) key.Binding { return k.detail.ToggleQuotes },
+			run:     Model.toggleQuotes,
+		},
+		{
+			name:    "unsubscribe",
+			desc:    "Unsubscribe from the sender's mailing list",
+			binding: func(k keyMap) key.Binding { return k.detail.Unsubscribe },
+			run:     Model.unsubscribe,
+		},
+		{
+			name:    "links",
+			desc:    "Pick a link in the message to open or copy",
+			binding: func(k keyMap) key.Binding { return k.detail.Links },
+			run:     Model.showLinkPicker,
+		},
+		{
+			name:    "all-headers",
+			desc:    "Show every header, with authentication and routing",
+			binding: func(k keyMap) key.Binding { return k.detail.AllHeaders },
+			run:     Model.toggleHeaders,
+		},
+		{
+			name:        "next-message",
+			desc:        "Select the next message",
+			binding:     func(k keyMap) key.Binding { return k.detail.Down },
+			run:         Model.nextMessage,
+			counted:     repeated(Model.nextMessage),
+			passthrough: true,
+		},
+		{
+			name:        "prev-message",
+			desc:        "Select the previous message",
+			binding:     func(k keyMap) key.Binding { return k.detail.Up },
+			run:         Model.prevMessage,
+			counted:     repeated(Model.prevMessage),
+			passthrough: true,
+		},
+		{
+			name:        "toggle-expand",
+			desc:        "Expand or collapse the message",
+			binding:     func(k keyMap) key.Binding { return k.detail.ToggleExpand },
+			run:         Model.toggleExpand,
+			passthrough: true,
+		},
+		{
+			name:    "go-top",
+			desc:    "Scroll to the top",
+			binding: func(k keyMap) key.Binding { return k.detail.GoTop },
+			run: func(m Model) (Model, tea.Cmd) {
+				m.detail.viewport.GotoTop()
+				return m, nil
+			},
+		},
+		{
+			name:    "go-bottom",
+			desc:    "Scroll to the bottom",
+			binding: func(k keyMap) key.Binding { return k.detail.GoBottom },
+			run: func(m Model) (Model, tea.Cmd) {
+				m.detail.viewport.GotoBottom()
+				return m, nil
+			},
+		},
+		{
+			name:    "go-inbox",
+			desc:    "Back to the whole inbox",
+			binding: func(k keyMap) key.Binding { return k.detail.GoInbox },
+			run:     Model.goToInbox,
+		},
+		{
+			name:    "go-archive",
+			desc:    "Show archived threads",
+			binding: func(k keyMap) key.Binding { return k.detail.GoArchive },
+			run:     Model.goToArchive,
+		},
+	}
+}
+
+// actionsForView returns the actions available in the given view.
+func actionsForView(view viewState) []keyAction {
+	switch view {
+	case viewList:
+		return listActions()
+	case viewDetail:
+		return detailActions()
+	case viewImage, viewAttachment:
+		return nil
+	default:
+		return nil
+	}
+}
+
+func findAction(view viewState, name string) (keyAction, bool) {
+	for _, a := range actionsForView(view) {
+		if a.name == name {
+			return a, true
+		}
+	}
+	return keyAction{}, false
+}
+
+func (m Model) showHelp() (Model, tea.Cmd) {
+	m.ui.showHelp = true
+	return m, nil
+}
+
+func (m Model) showFailedActions() (Model, tea.Cmd) {
+	m.outbox.showFailed = true
+	return m, nil
+}
+
+func (m Model) refreshInbox() (Model, tea.Cmd) {
+	// Refresh inbox (non-disruptive)
+	tea.Printf("USER: Pressed 'r' to refresh, current threads=%d", len(m.inbox.threads))
+	m.inbox.refreshing = true
+	return m, m.loadInboxCmd(inboxLoadManual)
+}
+
+func (m Model) toggleRead() (Model, tea.Cmd) {
+	idx := m.selectedThreadIndex()
+	if idx < 0 || idx >= len(m.inbox.threads) {
+		return m, nil
+	}
+	thread := &m.inbox.threads[idx]
+	// Calculate new state before optimistic update
+	newUnreadState := !thread.Unread
+	// Optimistically update UI immediately
+	thread.Unread = newUnreadState
+	// Send API request in background with the target state
+	return m, m.markThreadUnreadCmd(thread.ThreadID, newUnreadState, thread.AccountIndex)
+}
+
+func (m Model) toggleSelect() (Model, tea.Cmd) {
+	if idx := m.selectedThreadIndex(); idx >= 0 && idx < len(m.inbox.threads) {
+		m.toggleThreadSelection(idx)
+	}
+	return m, nil
+}
+
+func (m Model) confirmThreadAction(action deleteAction) (Model, tea.Cmd) {
+	refs := m.selectionOrCurrent()
+	if len(refs) == 0 {
+		return m, nil
+	}
+	m.inbox.delete.pending = true
+	m.inbox.delete.targets = refs
+	m.inbox.delete.action = action
+	m.inbox.delete.sender = ""
+	return m, nil
+}
+
+func (m Model) undoLastAction() (Model, tea.Cmd) {
+	if !m.undoAvailable() || m.inbox.undo.inProgress {
+		return m, nil
+	}
+	refs := m.undoRefs()
+	if len(refs) == 0 {
+		return m, nil
+	}
+	action := m.inbox.undo.action
+	m.inbox.undo.inProgress = true
+	m = m.clearAlerts()
+	return m, m.undoThreadsCmd(action, refs)
+}
+
+func (m Model) pageUp() (Model, tea.Cmd) {
+	// Jump up by visible page size
+	start, end := m.getVisibleThreadRange()
+	pageSize := end - start
+	if pageSize <= 0 {
+		return m, nil
+	}
+	m.inbox.cursor = max(0, m.inbox.cursor-pageSize)
+	m.ensureCursorVisible()
+	return m, m.loadVisibleThreadsCmd()
+}
+
+func (m Model) pageDown() (Model, tea.Cmd) {
+	// Jump down by visible page size
+	start, end := m.getVisibleThreadRange()
+	pageSize := end - start
+	count := m.displayCount()
+	if pageSize <= 0 {
+		return m, nil
+	}
+	if count > 0 {
+		m.inbox.cursor = min(count-1, m.inbox.cursor+pageSize)
+	}
+	m.ensureCursorVisible()
+	return m, m.loadAfterScrollCmd()
+}
+
+func (m Model) cursorUp() (Model, tea.Cmd) {
+	if m.inbox.cursor <= 0 {
+		return m, nil
+	}
+	m.inbox.cursor--
+	m.ensureCursorVisible()
+	// Load visible threads when scrolling
+	return m, m.loadVisibleThreadsCmd()
+}
+
+func (m Model) cursorDown() (Model, tea.Cmd) {
+	if m.inbox.cursor >= m.displayCount()-1 {
+		return m, nil
+	}
+	m.inbox.cursor++
+	m.ensureCursorVisible()
+	return m, m.loadAfterScrollCmd()
+}
+
+// moveCursor moves the cursor by delta threads, clamped to the list.
+func (m Model) moveCursor(delta int) (Model, tea.Cmd) {
+	cursor := max(0, min(m.displayCount()-1, m.inbox.cursor+delta))
+	if cursor == m.inbox.cursor {
+		return m, nil
+	}
+	down := cursor > m.inbox.cursor
+	m.inbox.cursor = cursor
+	m.ensureCursorVisible()
+	if down {
+		return m, m.loadAfterScrollCmd()
+	}
+	return m, m.loadVisibleThreadsCmd()
+}
+
+// goToThread moves the cursor to the 1-based line n.
+func (m Model) goToThread(n int) (Model, tea.Cmd) {
+	return m.moveCursor(n - 1 - m.inbox.cursor)
+}
+
+// selectDown toggles count threads starting at the cursor, leaving the
+// cursor on the last one.
+func (m Model) selectDown(count int) (Model, tea.Cmd) {
+	for i := range count {
+		if i > 0 {
+			if m.inbox.cursor >= m.displayCount()-1 {
+				break
+			}
+			m.inbox.cursor++
+		}
+		m.toggleThreadSelection(m.selectedThreadIndex())
+	}
+	m.ensureCursorVisible()
+	return m, m.loadAfterScrollCmd()
+}
+
+// archiveQuery finds threads that have left the inbox, without the ones
+// that were deleted or marked as spam
+const archiveQuery = "-in:inbox -in:trash -in:spam"
+
+// goToInbox drops any search or account filter and shows the whole inbox.
+func (m Model) goToInbox() (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if m.currentView == viewDetail {
+		cmd = m.exitDetailView()
+	}
+	m.search.accountFilter = allAccounts
+	m.search.input.SetValue("")
+	m.inbox.cursor = 0
+	return m, tea.Batch(cmd, m.submitSearch(""), m.loadVisibleThreadsCmd())
+}
+
+// goToArchive searches for archived threads.
+func (m Model) goToArchive() (Model, tea.Cmd) {
+	var cmd tea.Cmd
+	if m.currentView == viewDetail {
+		cmd = m.exitDetailView()
+	}
+	m.search.input.SetValue(archiveQuery)
+	return m, tea.Batch(cmd, m.submitSearch(archiveQuery))
+}
+
+// loadAfterScrollCmd loads visible metadata and, near the bottom, the next page.
+func (m *Model) loadAfterScrollCmd() tea.Cmd {
+	cmd := m.loadVisibleThreadsCmd()
+
+	// If near bottom (within 10 threads), trigger loading more
+	if count := m.displayCount(); count > 0 &&
+		m.inbox.cursor >= count-10 && m.inbox.nextPageToken != "" && !m.inbox.loadingMore {
+		m.inbox.loadingMore = true
+		return tea.Batch(cmd, m.loadMoreThreadsCmd())
+	}
+	return cmd
+}
+
+func (m Model) openSelected() (Model, tea.Cmd) {
+	idx := m.selectedThreadIndex()
+	if idx < 0 || idx >= len(m.inbox.threads) {
+		return m, nil
+	}
+	if m.splitActive() && m.previewShowing() {
+		return m.focusPreview()
+	}
+	return m, m.openThread(m.inbox.threads[idx])
+}
+
+func (m Model) startSearch() (Model, tea.Cmd) {
+	m.search.previousQuery = m.search.query
+	m.search.active = true
+	m.search.input.SetValue(m.search.query)
+	m.search.input.CursorEnd()
+	m.search.input.Focus()
+	m.search.input.Width = max(10, m.ui.width-4)
+	m.logf("Search open query=%q", m.search.query)
+	return m, textinput.Blink
+}
+
+func (m Model) showAttachments() (Model, tea.Cmd) {
+	// Show attachments modal if current message has attachments
+	if m.detail.selectedMessageIdx < 0 || m.detail.selectedMessageIdx >= len(m.detail.messages) {
+		return m, nil
+	}
+	msg := m.detail.messages[m.detail.selectedMessageIdx]
+	if len(msg.Attachments) == 0 {
+		return m, nil
+	}
+	m.showAttachmentsModal(msg)
+	return m, m.setWindowTitleCmd()
+}
+
+// openInlineImage opens the nth inline image of the selected message in
+// the image viewer.
+func (m Model) openInlineImage(n int) (Model, tea.Cmd) {
+	if m.detail.currentThread == nil ||
+		m.detail.selectedMessageIdx < 0 || m.detail.selectedMessageIdx >= len(m.detail.messages) {
+		return m, nil
+	}
+	msg := m.detail.messages[m.detail.selectedMessageIdx]
+	if n < 1 || n > len(msg.InlineImages) {
+		return m, nil
+	}
+	img := msg.InlineImages[n-1]
+	if !isImageMimeType(img.MimeType) {
+		m.ui.err = fmt.Errorf("unsupported image type: %s", img.MimeType)
+		m.ui.showError = true
+		return m, nil
+	}
+	m.logf("Inline image open message=%s cid=%s", msg.ID, img.ContentID)
+	return m, m.loadInlineImageCmd(m.detail.currentThread.AccountIndex, msg.ID, img)
+}
+
+func (m Model) toggleMessageView() (Model, tea.Cmd) {
+	// Toggle view mode for the selected message.
+	if m.detail.selectedMessageIdx >= 0 &&
+		m.detail.selectedMessageIdx < len(m.detail.messages) {
+		selected := m.detail.messages[m.detail.selectedMessageIdx]
+		m.detail.messageViewMode = nextMessageViewMode(m.detail.messageViewMode, selected)
+	}
+	var rawCmd tea.Cmd
+	if m.detail.messageViewMode == viewModeRaw {
+		rawCmd = m.loadRawForExpandedMessages()
+	}
+	// Re-render the message and reset viewport position
+	body := m.renderThreadBody()
+	m.detail.viewport.SetContent(body)
+	m.detail.viewport.GotoTop()
+	m.detail.viewport.YOffset = 0
+	// Force full redraw
+	return m, tea.Batch(tea.ClearScreen, rawCmd, m.prerenderBodiesCmd())
+}
+
+func (m Model) nextMessage() (Model, tea.Cmd) {
+	if m.detail.selectedMessageIdx < len(m.detail.messages)-1 {
+		m.detail.selectedMessageIdx++
+		// Re-render
+		body := m.renderThreadBody()
+		m.detail.viewport.SetContent(body)
+	}
+	return m, nil
+}
+
+func (m Model) prevMessage() (Model, tea.Cmd) {
+	if m.detail.selectedMessageIdx > 0 {
+		m.detail.selectedMessageIdx--
+		// Re-render
+		body := m.renderThreadBody()
+		m.detail.viewport.SetContent(body)
+	}
+	return m, nil
+}
+
+func (m Model) toggleExpand() (Model, tea.Cmd) {
+	// Toggle expand/collapse for selected message
+	if m.detail.selectedMessageIdx < 0 || m.detail.selectedMessageIdx >= len(m.detail.messages) {
+		return m, nil
+	}
+	msgID := m.detail.messages[m.detail.selectedMessageIdx].ID
+	m.detail.expandedMessages[msgID] = !m.detail.expandedMessages[msgID]
+	var cmds []tea.Cmd
+	if m.detail.messageViewMode == viewModeRaw {
+		cmds = append(cmds, m.loadRawForExpandedMessages())
+	}
+	if m.detail.expandedMessages[msgID] {
+		if scanCmd := m.scanMessageLinksCmd(
+			m.detail.messages[m.detail.selectedMessageIdx],
+		); scanCmd != nil {
+			cmds = append(cmds, scanCmd)
+		}
+	}
+	// Re-render
+	body := m.renderThreadBody()
+	m.detail.viewport.SetContent(body)
+	if len(cmds) > 0 {
+		return m, tea.Batch(cmds...)
+	}
+	return m, nil
+}
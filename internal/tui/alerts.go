@@ -66,3 +66,36 @@ func (m Model) clearAlerts() Model {
 	m.ui.alert = newAlertModel(m.theme, m.ui.width)
 	return m
 }
+
+func (m *Model) labelToastCmd(count int) tea.Cmd {
+	if count <= 0 {
+		return nil
+	}
+	message := fmt.Sprintf("Labeled %d %s", count, pluralize(count, "thread"))
+	return m.ui.alert.NewAlertCmd(bubbleup.InfoKey, message)
+}
+
+func (m *Model) outboxToastCmd(applied int, failed int) tea.Cmd {
+	var message string
+	switch {
+	case failed > 0:
+		message = fmt.Sprintf("%d queued %s failed - ! review", failed, pluralize(failed, "action"))
+	case applied > 0:
+		message = fmt.Sprintf("Synced %d queued %s", applied, pluralize(applied, "action"))
+	default:
+		return nil
+	}
+	return m.ui.alert.NewAlertCmd(bubbleup.InfoKey, message)
+}
+
+// toastCmd shows a plain informational toast.
+func (m *Model) toastCmd(message string) tea.Cmd {
+	return m.ui.alert.NewAlertCmd(bubbleup.InfoKey, message)
+}
+
+func pluralize(count int, noun string) string {
+	if count == 1 {
+		return noun
+	}
+	return noun + "s"
+}
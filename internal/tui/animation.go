@@ -0,0 +1,153 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.withmatt.com/inbox/internal/image"
+)
+
+// kittyAnimationID names the animated image so control commands can find it
+const kittyAnimationID = 1
+
+type animationTickMsg struct {
+	generation int
+}
+
+type framesDecodedMsg struct {
+	generation int
+	frames     []image.Frame
+	total      int
+	err        error
+}
+
+func (m *Model) animated() bool {
+	return len(m.image.frames) > 1
+}
+
+// startAnimation decodes the frames of a GIF in the background. Until they
+// arrive, and for other images and GIFs with a single frame, the image is
+// shown still.
+func (m *Model) startAnimation() tea.Cmd {
+	if !strings.EqualFold(m.image.mimeType, "image/gif") {
+		return nil
+	}
+	data := m.image.data
+	gen := m.image.generation
+	return func() tea.Msg {
+		frames, total, err := image.DecodeFrames(data)
+		return framesDecodedMsg{generation: gen, frames: frames, total: total, err: err}
+	}
+}
+
+// handleFramesDecoded starts playback once every frame is ready.
+func (m Model) handleFramesDecoded(msg framesDecodedMsg) (tea.Model, tea.Cmd) {
+	if msg.generation != m.image.generation || m.currentView != viewImage {
+		return m, nil
+	}
+	if msg.err != nil {
+		m.logf("GIF frames decode failed: %v", msg.err)
+		return m, nil
+	}
+	if msg.total > len(msg.frames) {
+		m.logf("GIF frames dropped file=%s kept=%d total=%d", m.image.filename, len(msg.frames), msg.total)
+	}
+	if len(msg.frames) < 2 {
+		return m, nil
+	}
+	m.image.frames = msg.frames
+	m.image.totalFrames = msg.total
+	m.image.frame = 0
+	m.image.paused = false
+	m.image.animationGeneration++
+	return m, m.animationTickCmd()
+}
+
+func (m *Model) animationTickCmd() tea.Cmd {
+	gen := m.image.animationGeneration
+	return tea.Tick(m.image.frames[m.image.frame].Delay, func(time.Time) tea.Msg {
+		return animationTickMsg{generation: gen}
+	})
+}
+
+// handleAnimationTick advances to the next frame. Kitty plays the animation
+// itself; the frame is still tracked so pausing stops where the user sees it.
+func (m Model) handleAnimationTick(msg animationTickMsg) (tea.Model, tea.Cmd) {
+	if msg.generation != m.image.animationGeneration ||
+		m.currentView != viewImage || m.image.paused || !m.animated() {
+		return m, nil
+	}
+	next := (m.image.frame + 1) % len(m.image.frames)
+	key := m.imageCacheKey(m.imageBox())
+	if key.frame >= 0 {
+		// Hold the frame until the next one has been encoded
+		key.frame = next
+		if _, ok := m.image.encoded[key]; !ok {
+			return m, m.animationTickCmd()
+		}
+	}
+	m.image.frame = next
+	return m, m.animationTickCmd()
+}
+
+func (m Model) toggleAnimation() (Model, tea.Cmd) {
+	if !m.animated() {
+		return m, nil
+	}
+	m.image.paused = !m.image.paused
+	m.image.animationGeneration++
+	if m.image.paused {
+		return m, nil
+	}
+	return m, m.animationTickCmd()
+}
+
+// stepAnimation pauses and moves delta frames, wrapping around.
+func (m Model) stepAnimation(delta int) (Model, tea.Cmd) {
+	if !m.animated() {
+		return m, nil
+	}
+	m.image.paused = true
+	m.image.animationGeneration++
+	count := len(m.image.frames)
+	m.image.frame = ((m.image.frame+delta)%count + count) % count
+	return m, nil
+}
+
+// encodeKittyAnimation transmits every frame with its delay so the terminal
+// can play the animation without re-renders.
+func encodeKittyAnimation(frames []image.Frame, box imageBox) (string, error) {
+	var b strings.Builder
+	pixelWidth := box.cols * box.pixelsPerColumn
+	pixelHeight := box.rows * box.pixelsPerRow
+	for i, frame := range frames {
+		data, err := image.EncodePNG(frame.Image, pixelWidth, pixelHeight)
+		if err != nil {
+			return "", err
+		}
+		gap := frame.Delay.Milliseconds()
+		if i == 0 {
+			fmt.Fprintf(
+				&b,
+				"\x1b_Gf=100,a=T,t=d,i=%d,q=2,c=%d,r=%d;%s\x1b\\",
+				kittyAnimationID, box.cols, box.rows, data,
+			)
+			// The first frame's delay is set on the root frame after the fact
+			fmt.Fprintf(&b, "\x1b_Ga=a,i=%d,r=1,z=%d,q=2\x1b\\", kittyAnimationID, gap)
+			continue
+		}
+		fmt.Fprintf(&b, "\x1b_Ga=f,i=%d,f=100,t=d,z=%d,q=2;%s\x1b\\", kittyAnimationID, gap, data)
+	}
+	return b.String(), nil
+}
+
+// kittyAnimationControl loops the animation forever, or stops it on frame.
+func kittyAnimationControl(paused bool, frame int) string {
+	if paused {
+		return fmt.Sprintf("\x1b_Ga=a,i=%d,s=1,c=%d,q=2\x1b\\", kittyAnimationID, frame+1)
+	}
+	return fmt.Sprintf("\x1b_Ga=a,i=%d,s=3,v=1,q=2\x1b\\", kittyAnimationID)
+}
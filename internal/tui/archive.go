@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.withmatt.com/inbox/internal/archive"
+)
+
+const (
+	// maxArchivePreviewBytes keeps a previewed entry a reasonable size to
+	// hold in memory and render
+	maxArchivePreviewBytes = 20 << 20
+	// maxArchiveExtractBytes stops a compressed bomb from filling the disk
+	maxArchiveExtractBytes = 4 << 30
+)
+
+type archiveOpenedMsg struct {
+	filename string
+	format   archive.Format
+	path     string
+	// dir is the temp directory holding path
+	dir     string
+	entries []archive.Entry
+	err     error
+}
+
+type archiveEntryExtractedMsg struct {
+	name string
+	path string
+	err  error
+}
+
+// openArchiveCmd downloads an archive attachment to a temp file and lists
+// its entries.
+func (m *Model) openArchiveCmd(
+	ctx context.Context,
+	written *atomic.Int64,
+	attachmentIdx int,
+	format archive.Format,
+) tea.Cmd {
+	return func() tea.Msg {
+		if attachmentIdx < 0 || attachmentIdx >= len(m.attachments.modal.attachments) {
+			return archiveOpenedMsg{err: errors.New("invalid attachment index")}
+		}
+		att := m.attachments.modal.attachments[attachmentIdx]
+
+		dir, err := os.MkdirTemp("", "inbox-archive-*")
+		if err != nil {
+			return archiveOpenedMsg{filename: att.Filename, err: err}
+		}
+		archivePath := filepath.Join(dir, sanitizeFilename(att.Filename))
+		file, err := os.OpenFile(archivePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			os.RemoveAll(dir)
+			return archiveOpenedMsg{filename: att.Filename, err: err}
+		}
+		err = writeAttachment(
+			ctx,
+			m.clients[m.attachments.modal.accountIndex],
+			m.attachments.modal.messageID,
+			att,
+			file,
+			written,
+		)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		var entries []archive.Entry
+		if err == nil {
+			entries, err = archive.List(archivePath, format)
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return archiveOpenedMsg{filename: att.Filename, err: err}
+		}
+		return archiveOpenedMsg{
+			filename: att.Filename,
+			format:   format,
+			path:     archivePath,
+			dir:      dir,
+			entries:  entries,
+		}
+	}
+}
+
+func (m Model) handleArchiveOpened(msg archiveOpenedMsg) (tea.Model, tea.Cmd) {
+	m.endDownload()
+	if errors.Is(msg.err, context.Canceled) {
+		return m, m.toastCmd("Download cancelled")
+	}
+	if msg.err != nil {
+		m.ui.err = fmt.Errorf("failed to open %s: %w", msg.filename, msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	if !m.attachments.modal.show {
+		// The modal went away while the archive downloaded
+		os.RemoveAll(msg.dir)
+		return m, nil
+	}
+	m.logf("Archive open file=%s entries=%d", msg.filename, len(msg.entries))
+	m.attachments.modal.archive = archiveState{
+		active:   true,
+		filename: msg.filename,
+		format:   msg.format,
+		path:     msg.path,
+		dir:      msg.dir,
+		entries:  msg.entries,
+	}
+	return m, nil
+}
+
+// closeArchive removes the temp copy and returns to the attachment list.
+func (m *Model) closeArchive() {
+	if dir := m.attachments.modal.archive.dir; dir != "" {
+		os.RemoveAll(dir)
+	}
+	m.attachments.modal.archive = archiveState{}
+}
+
+func (m *Model) selectedArchiveEntry() (archive.Entry, bool) {
+	state := m.attachments.modal.archive
+	if state.selectedIdx < 0 || state.selectedIdx >= len(state.entries) {
+		return archive.Entry{}, false
+	}
+	return state.entries[state.selectedIdx], true
+}
+
+// archiveEntryMimeType guesses an entry's type from its extension, since
+// archives don't record one.
+func archiveEntryMimeType(name string) string {
+	mimeType, _, _ := mime.ParseMediaType(mime.TypeByExtension(path.Ext(name)))
+	return mimeType
+}
+
+// previewArchiveEntry opens the selected entry in the text or image viewer.
+func (m Model) previewArchiveEntry() (tea.Model, tea.Cmd) {
+	entry, ok := m.selectedArchiveEntry()
+	if !ok {
+		return m, nil
+	}
+	mimeType := archiveEntryMimeType(entry.Name)
+	if !isImageMimeType(mimeType) && !isTextAttachment(mimeType, entry.Name) {
+		m.ui.err = fmt.Errorf("unsupported attachment type: %s", cmp.Or(mimeType, path.Ext(entry.Name)))
+		m.ui.showError = true
+		return m, nil
+	}
+	if entry.Size > maxArchivePreviewBytes {
+		m.ui.err = fmt.Errorf("%s is too large to preview", path.Base(entry.Name))
+		m.ui.showError = true
+		return m, nil
+	}
+	m.attachments.modal.loadingPreview = true
+	state := m.attachments.modal.archive
+	return m, tea.Batch(
+		loadArchiveEntryCmd(state.path, state.format, entry, mimeType),
+		m.ui.spinner.Tick,
+	)
+}
+
+// loadArchiveEntryCmd reads an entry into the same message a downloaded
+// attachment produces, so previews go through the usual viewers.
+func loadArchiveEntryCmd(archivePath string, format archive.Format, entry archive.Entry, mimeType string) tea.Cmd {
+	return func() tea.Msg {
+		filename := path.Base(entry.Name)
+		var buf bytes.Buffer
+		if err := archive.Extract(archivePath, format, entry, &buf, maxArchivePreviewBytes); err != nil {
+			return attachmentLoadedMsg{filename: filename, err: err}
+		}
+		return attachmentLoadedMsg{
+			data:     base64.URLEncoding.EncodeToString(buf.Bytes()),
+			mimeType: mimeType,
+			filename: filename,
+			size:     int64(buf.Len()),
+		}
+	}
+}
+
+// extractArchiveEntry saves the selected entry to the download directory.
+// Only the base name is used, so entries can't escape it.
+func (m Model) extractArchiveEntry() (tea.Model, tea.Cmd) {
+	entry, ok := m.selectedArchiveEntry()
+	if !ok {
+		return m, nil
+	}
+	state := m.attachments.modal.archive
+	m.logf("Archive extract file=%s entry=%s", state.filename, entry.Name)
+	return m, m.extractArchiveEntryCmd(state.path, state.format, entry)
+}
+
+func (m *Model) extractArchiveEntryCmd(archivePath string, format archive.Format, entry archive.Entry) tea.Cmd {
+	name := entry.Name
+	return func() tea.Msg {
+		dir, err := m.downloadsDir()
+		if err != nil {
+			return archiveEntryExtractedMsg{name: name, err: err}
+		}
+		file, dest, err := createUniqueFile(dir, sanitizeFilename(path.Base(name)))
+		if err != nil {
+			return archiveEntryExtractedMsg{name: name, err: err}
+		}
+		err = archive.Extract(archivePath, format, entry, file, maxArchiveExtractBytes)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(dest)
+			return archiveEntryExtractedMsg{name: name, err: err}
+		}
+		return archiveEntryExtractedMsg{name: name, path: dest}
+	}
+}
+
+func (m Model) handleArchiveEntryExtracted(msg archiveEntryExtractedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.ui.err = fmt.Errorf("failed to extract %s: %w", msg.name, msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	return m, m.toastCmd("Saved " + tildePath(msg.path))
+}
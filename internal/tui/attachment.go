@@ -8,7 +8,8 @@ import (
 
 func isImageMimeType(mime string) bool {
 	switch strings.ToLower(mime) {
-	case "image/png", "image/jpeg", "image/jpg", "image/gif", "image/webp":
+	case "image/png", "image/jpeg", "image/jpg", "image/gif", "image/webp",
+		"image/bmp", "image/x-ms-bmp", "image/tiff":
 		return true
 	default:
 		return false
@@ -0,0 +1,263 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.withmatt.com/inbox/internal/config"
+)
+
+// commandDef is a `:` command that takes arguments. Commands without
+// arguments are the actions from actions.go, invoked by name.
+type commandDef struct {
+	name string
+	desc string
+	run  func(m Model, args string) (Model, tea.Cmd, error)
+}
+
+func commandDefs() []commandDef {
+	return []commandDef{
+		{name: "label", desc: "Add or remove labels: +name -name", run: Model.runLabelCommand},
+		{name: "search", desc: "Filter threads with a Gmail query", run: Model.runSearchCommand},
+		{name: "account", desc: "Show one account: number, name or all", run: Model.runAccountCommand},
+		{name: "theme", desc: "Switch to a named color theme", run: Model.runThemeCommand},
+		{name: "set", desc: "Change a UI option: name=value", run: Model.runSetCommand},
+	}
+}
+
+func (m Model) openCommandLine(value string) (Model, tea.Cmd) {
+	m.command.active = true
+	m.command.input.SetSuggestions(m.commandSuggestions())
+	m.command.input.SetValue(value)
+	m.command.input.CursorEnd()
+	m.command.input.Focus()
+	m.command.input.Width = max(10, m.ui.width-4)
+	return m, textinput.Blink
+}
+
+func (m Model) closeCommandLine() Model {
+	m.command.active = false
+	m.command.input.Blur()
+	m.command.input.SetValue("")
+	return m
+}
+
+func (m Model) handleCommandKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		return m.closeCommandLine(), nil
+	case "enter":
+		line := m.command.input.Value()
+		m = m.closeCommandLine()
+		m.logf("Command run line=%q", line)
+		return m.runCommandLine(line)
+	}
+
+	var cmd tea.Cmd
+	m.command.input, cmd = m.command.input.Update(msg)
+	return m, cmd
+}
+
+// runCommandLine runs one or more `;` separated commands, stopping at the
+// first error.
+func (m Model) runCommandLine(line string) (Model, tea.Cmd) {
+	var cmds []tea.Cmd
+	for part := range strings.SplitSeq(line, ";") {
+		part = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(part), ":"))
+		if part == "" {
+			continue
+		}
+		next, cmd, err := m.execCommand(part)
+		m = next
+		cmds = append(cmds, cmd)
+		if err != nil {
+			m.ui.err = fmt.Errorf(":%s: %w", part, err)
+			m.ui.showError = true
+			break
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) execCommand(line string) (Model, tea.Cmd, error) {
+	name, args, _ := strings.Cut(line, " ")
+	args = strings.TrimSpace(args)
+
+	action, isAction := findAction(m.currentView, name)
+	// A bare name prefers the action, so `:search` opens the search prompt
+	if isAction && args == "" {
+		next, cmd := action.run(m)
+		return next, cmd, nil
+	}
+	for _, c := range commandDefs() {
+		if c.name == name {
+			return c.run(m, args)
+		}
+	}
+	if isAction {
+		return m, nil, fmt.Errorf("%s takes no arguments", name)
+	}
+	return m, nil, fmt.Errorf("unknown command %q", name)
+}
+
+func (m *Model) commandSuggestions() []string {
+	var suggestions []string
+	for _, a := range actionsForView(m.currentView) {
+		suggestions = append(suggestions, a.name)
+	}
+	for _, c := range commandDefs() {
+		suggestions = append(suggestions, c.name+" ")
+	}
+	suggestions = append(suggestions, "account all")
+	for _, name := range m.accountNames {
+		suggestions = append(suggestions, "account "+name)
+	}
+	for _, option := range setOptions {
+		suggestions = append(suggestions, "set "+option+"=")
+	}
+	return suggestions
+}
+
+// commandTargets returns the threads a command acts on.
+func (m *Model) commandTargets() []threadRef {
+	if m.currentView == viewDetail && m.detail.currentThread != nil {
+		thread := m.detail.currentThread
+		return []threadRef{{threadID: thread.ThreadID, accountIndex: thread.AccountIndex}}
+	}
+	return m.selectionOrCurrent()
+}
+
+func (m Model) runLabelCommand(args string) (Model, tea.Cmd, error) {
+	add, remove, err := parseLabelChanges(strings.Fields(args))
+	if err != nil {
+		return m, nil, err
+	}
+	refs := m.commandTargets()
+	if len(refs) == 0 {
+		return m, nil, errors.New("no thread selected")
+	}
+	return m, m.labelThreadsCmd(refs, add, remove), nil
+}
+
+func (m Model) runSearchCommand(args string) (Model, tea.Cmd, error) {
+	var cmds []tea.Cmd
+	if m.currentView == viewDetail {
+		cmds = append(cmds, m.exitDetailView())
+	}
+	m.search.input.SetValue(args)
+	cmds = append(cmds, m.submitSearch(args))
+	return m, tea.Batch(cmds...), nil
+}
+
+func (m Model) runAccountCommand(args string) (Model, tea.Cmd, error) {
+	accountIndex, err := m.parseAccountArg(args)
+	if err != nil {
+		return m, nil, err
+	}
+	var cmd tea.Cmd
+	if m.currentView == viewDetail {
+		cmd = m.exitDetailView()
+	}
+	m.search.accountFilter = accountIndex
+	m.reapplyFilterPreserveCursor()
+	m.logf("Account filter set account=%d", accountIndex)
+	return m, tea.Batch(cmd, m.loadVisibleThreadsCmd()), nil
+}
+
+// parseAccountArg accepts a 1-based account number, an account name, or "all".
+func (m *Model) parseAccountArg(arg string) (int, error) {
+	switch strings.ToLower(arg) {
+	case "", "all", "*":
+		return allAccounts, nil
+	}
+	if n, err := strconv.Atoi(arg); err == nil {
+		if n < 1 || n > len(m.accountNames) {
+			return 0, fmt.Errorf("account %d out of range (1-%d)", n, len(m.accountNames))
+		}
+		return n - 1, nil
+	}
+	for i, name := range m.accountNames {
+		if strings.EqualFold(name, arg) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown account %q", arg)
+}
+
+func (m *Model) accountFilterName() string {
+	idx := m.search.accountFilter
+	if idx < 0 || idx >= len(m.accountNames) {
+		return "all accounts"
+	}
+	return m.accountNames[idx]
+}
+
+func (m Model) runThemeCommand(args string) (Model, tea.Cmd, error) {
+	if args == "" {
+		return m, nil, errors.New("usage: theme <name>")
+	}
+	theme, err := config.ResolveTheme(config.Theme{Name: args})
+	if err != nil {
+		return m, nil, err
+	}
+	m.applyTheme(theme)
+	return m, tea.ClearScreen, nil
+}
+
+// setOptions are the UI options `:set` understands.
+var setOptions = []string{"snippet_lines", "refresh_interval"}
+
+func (m Model) runSetCommand(args string) (Model, tea.Cmd, error) {
+	name, value, ok := strings.Cut(args, "=")
+	if !ok {
+		name, value, ok = strings.Cut(args, " ")
+	}
+	name = strings.TrimSpace(name)
+	value = strings.TrimSpace(value)
+	if !ok || name == "" || value == "" {
+		return m, nil, errors.New("usage: set name=value")
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return m, nil, fmt.Errorf("%s must be a number", name)
+	}
+
+	switch name {
+	case "snippet_lines", "list_snippet_lines":
+		if n < 1 {
+			return m, nil, errors.New("snippet_lines must be at least 1")
+		}
+		m.uiConfig.ListSnippetLines = n
+		m.ensureCursorVisible()
+		return m, m.loadVisibleThreadsCmd(), nil
+	case "refresh_interval", "refresh_interval_seconds":
+		if n == 0 {
+			return m, nil, errors.New("refresh_interval must be positive, or -1 to disable")
+		}
+		m.uiConfig.RefreshIntervalSeconds = n
+		m.inbox.refreshGeneration++
+		return m, m.autoRefreshCmd(), nil
+	default:
+		return m, nil, fmt.Errorf("unknown option %q", name)
+	}
+}
+
+func (m *Model) renderCommandStatusline() string {
+	left := []statusSegment{
+		statusModeSegment(m.theme, "COMMAND"),
+		statusPaddedRaw(m.theme, m.command.input.View()),
+	}
+	right := []statusSegment{
+		statusDimSegment(m.theme, "tab complete"),
+		statusDimSegment(m.theme, "enter run"),
+		statusDimSegment(m.theme, "esc cancel"),
+	}
+	return renderStatusline(m.theme, m.ui.width, left, right)
+}
@@ -1,18 +1,21 @@
 package tui
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"os"
-	"path/filepath"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"golang.org/x/sync/errgroup"
 
 	"go.withmatt.com/inbox/internal/gmail"
+	"go.withmatt.com/inbox/internal/outbox"
 )
 
 const (
@@ -21,8 +24,10 @@ const (
 )
 
 type threadLoadedMsg struct {
-	messages []gmail.Message
-	err      error
+	threadID     string
+	accountIndex int
+	messages     []gmail.Message
+	err          error
 }
 
 type messageRawLoadedMsg struct {
@@ -72,6 +77,7 @@ type batchLoadStartMsg struct {
 type threadMarkedMsg struct {
 	threadID string
 	unread   bool
+	queued   bool
 	err      error
 }
 
@@ -79,6 +85,7 @@ type threadsActionMsg struct {
 	action deleteAction
 	refs   []threadRef
 	failed []threadRef
+	queued int
 	err    error
 }
 
@@ -86,6 +93,16 @@ type threadsUndoMsg struct {
 	action deleteAction
 	refs   []threadRef
 	failed []threadRef
+	queued int
+	err    error
+}
+
+type threadsLabeledMsg struct {
+	refs   []threadRef
+	add    []string
+	remove []string
+	failed []threadRef
+	queued int
 	err    error
 }
 
@@ -96,7 +113,9 @@ type searchDebounceMsg struct {
 	generation int
 }
 
-type autoRefreshMsg struct{}
+type autoRefreshMsg struct {
+	generation int
+}
 
 type searchRemoteLoadedMsg struct {
 	query      string
@@ -204,8 +223,9 @@ func (m *Model) autoRefreshCmd() tea.Cmd {
 		return nil
 	}
 	interval := time.Duration(m.uiConfig.RefreshIntervalSeconds) * time.Second
+	generation := m.inbox.refreshGeneration
 	return tea.Tick(interval, func(time.Time) tea.Msg {
-		return autoRefreshMsg{}
+		return autoRefreshMsg{generation: generation}
 	})
 }
 
@@ -234,12 +254,17 @@ func (m *Model) searchRemoteCmd(query string, generation int) tea.Cmd {
 		}
 
 		results := make([]accountResult, len(m.clients))
+		allMail := searchesAllMail(query)
 
 		for i := range m.clients {
 			accountIndex := i
 			g.Go(func() error {
-				m.logf("SearchInbox start account=%d query=%q", accountIndex, query)
-				inbox, err := m.clients[accountIndex].SearchInbox(ctx, query, 50, "")
+				m.logf("SearchInbox start account=%d query=%q all=%t", accountIndex, query, allMail)
+				search := m.clients[accountIndex].SearchInbox
+				if allMail {
+					search = m.clients[accountIndex].SearchAllMail
+				}
+				inbox, err := search(ctx, query, 50, "")
 				if err != nil {
 					m.logf("SearchInbox error account=%d query=%q err=%v", accountIndex, query, err)
 					results[accountIndex] = accountResult{accountIndex: accountIndex, err: err}
@@ -340,8 +365,10 @@ func (m *Model) loadThreadCmd(thread *gmail.Thread) tea.Cmd {
 			)
 		}
 		return threadLoadedMsg{
-			messages: messages,
-			err:      err,
+			threadID:     thread.ThreadID,
+			accountIndex: thread.AccountIndex,
+			messages:     messages,
+			err:          err,
 		}
 	}
 }
@@ -489,6 +516,8 @@ func (m *Model) loadVisibleThreadsCmdWithForce(force bool) tea.Cmd {
 
 // markThreadUnreadCmd marks a thread with the specified unread state
 func (m *Model) markThreadUnreadCmd(threadID string, unread bool, accountIndex int) tea.Cmd {
+	ref := threadRef{threadID: threadID, accountIndex: accountIndex}
+	subject := m.subjectForRef(ref)
 	return func() tea.Msg {
 		var err error
 
@@ -498,9 +527,25 @@ func (m *Model) markThreadUnreadCmd(threadID string, unread bool, accountIndex i
 			err = m.clients[accountIndex].MarkThreadRead(m.ctx, threadID)
 		}
 
+		// Keep the optimistic update and replay later if we're offline
+		queued := false
+		if gmail.IsTransientError(err) {
+			kind := outbox.KindMarkRead
+			if unread {
+				kind = outbox.KindMarkUnread
+			}
+			op := outbox.Op{Kind: kind, Subject: subject}
+			if qerr := m.enqueueThreadOp(ref, op); qerr == nil {
+				m.logf("Queued %s thread=%s err=%v", kind, threadID, err)
+				queued = true
+				err = nil
+			}
+		}
+
 		return threadMarkedMsg{
 			threadID: threadID,
 			unread:   unread,
+			queued:   queued,
 			err:      err,
 		}
 	}
@@ -508,12 +553,14 @@ func (m *Model) markThreadUnreadCmd(threadID string, unread bool, accountIndex i
 
 // threadActionCmd performs an action on the specified threads.
 func (m *Model) threadActionCmd(action deleteAction, refs []threadRef) tea.Cmd {
+	subjects := m.subjectsForRefs(refs)
 	return func() tea.Msg {
 		if len(refs) == 0 {
 			return threadsActionMsg{action: action}
 		}
 
 		failed := make([]threadRef, 0)
+		queued := 0
 		var firstErr error
 		for _, ref := range refs {
 			if ref.accountIndex < 0 || ref.accountIndex >= len(m.clients) {
@@ -532,6 +579,15 @@ func (m *Model) threadActionCmd(action deleteAction, refs []threadRef) tea.Cmd {
 			case deleteActionPermanent:
 				err = m.clients[ref.accountIndex].DeleteThread(m.ctx, ref.threadID)
 			}
+			if kind, ok := outboxKindForAction(action); ok && gmail.IsTransientError(err) {
+				key := threadKey(ref.threadID, ref.accountIndex)
+				op := outbox.Op{Kind: kind, Subject: subjects[key]}
+				if qerr := m.enqueueThreadOp(ref, op); qerr == nil {
+					m.logf("Queued %s thread=%s err=%v", kind, ref.threadID, err)
+					queued++
+					continue
+				}
+			}
 			if err != nil {
 				if firstErr == nil {
 					firstErr = err
@@ -549,6 +605,7 @@ func (m *Model) threadActionCmd(action deleteAction, refs []threadRef) tea.Cmd {
 			action: action,
 			refs:   refs,
 			failed: failed,
+			queued: queued,
 			err:    err,
 		}
 	}
@@ -556,12 +613,14 @@ func (m *Model) threadActionCmd(action deleteAction, refs []threadRef) tea.Cmd {
 
 // undoThreadsCmd reverses the last archive or trash action.
 func (m *Model) undoThreadsCmd(action deleteAction, refs []threadRef) tea.Cmd {
+	subjects := m.subjectsForRefs(refs)
 	return func() tea.Msg {
 		if len(refs) == 0 {
 			return threadsUndoMsg{action: action}
 		}
 
 		failed := make([]threadRef, 0)
+		queued := 0
 		var firstErr error
 		for _, ref := range refs {
 			if ref.accountIndex < 0 || ref.accountIndex >= len(m.clients) {
@@ -571,6 +630,14 @@ func (m *Model) undoThreadsCmd(action deleteAction, refs []threadRef) tea.Cmd {
 				failed = append(failed, ref)
 				continue
 			}
+			// An action that never reached Gmail only needs to leave the queue
+			kind, queueable := outboxKindForAction(action)
+			if queueable {
+				account := m.accountNames[ref.accountIndex]
+				if cancelled, _ := m.outbox.queue.Cancel(account, ref.threadID, kind); cancelled {
+					continue
+				}
+			}
 			var err error
 			switch action {
 			case deleteActionArchive:
@@ -580,6 +647,16 @@ func (m *Model) undoThreadsCmd(action deleteAction, refs []threadRef) tea.Cmd {
 			case deleteActionPermanent:
 				err = errors.New("cannot undo permanent delete")
 			}
+			if queueable && gmail.IsTransientError(err) {
+				inverse, _ := kind.Inverse()
+				key := threadKey(ref.threadID, ref.accountIndex)
+				op := outbox.Op{Kind: inverse, Subject: subjects[key]}
+				if qerr := m.enqueueThreadOp(ref, op); qerr == nil {
+					m.logf("Queued undo thread=%s err=%v", ref.threadID, err)
+					queued++
+					continue
+				}
+			}
 			if err != nil {
 				if firstErr == nil {
 					firstErr = err
@@ -597,13 +674,89 @@ func (m *Model) undoThreadsCmd(action deleteAction, refs []threadRef) tea.Cmd {
 			action: action,
 			refs:   refs,
 			failed: failed,
+			queued: queued,
+			err:    err,
+		}
+	}
+}
+
+// labelThreadsCmd adds and removes labels (by name or ID) on the specified threads.
+func (m *Model) labelThreadsCmd(refs []threadRef, add, remove []string) tea.Cmd {
+	subjects := m.subjectsForRefs(refs)
+	return func() tea.Msg {
+		failed := make([]threadRef, 0)
+		queued := 0
+		var firstErr error
+		labelIDs := make(map[int]map[string]string)
+		for _, ref := range refs {
+			if ref.accountIndex < 0 || ref.accountIndex >= len(m.clients) {
+				if firstErr == nil {
+					firstErr = fmt.Errorf("invalid account index %d", ref.accountIndex)
+				}
+				failed = append(failed, ref)
+				continue
+			}
+			err := m.modifyThreadLabels(ref, add, remove, labelIDs)
+			if gmail.IsTransientError(err) {
+				op := outbox.Op{
+					Kind:         outbox.KindModifyLabels,
+					Subject:      subjects[threadKey(ref.threadID, ref.accountIndex)],
+					AddLabels:    add,
+					RemoveLabels: remove,
+				}
+				if qerr := m.enqueueThreadOp(ref, op); qerr == nil {
+					m.logf("Queued label change thread=%s err=%v", ref.threadID, err)
+					queued++
+					continue
+				}
+			}
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				failed = append(failed, ref)
+			}
+		}
+
+		var err error
+		if len(failed) > 0 {
+			err = fmt.Errorf("failed to label %d thread(s): %w", len(failed), firstErr)
+		}
+
+		return threadsLabeledMsg{
+			refs:   refs,
+			add:    add,
+			remove: remove,
+			failed: failed,
+			queued: queued,
 			err:    err,
 		}
 	}
 }
 
+func (m *Model) modifyThreadLabels(
+	ref threadRef,
+	add, remove []string,
+	labelIDs map[int]map[string]string,
+) error {
+	ids, err := m.labelIDsFor(ref.accountIndex, labelIDs)
+	if err != nil {
+		return err
+	}
+	addIDs, err := resolveLabelIDs(add, ids)
+	if err != nil {
+		return err
+	}
+	removeIDs, err := resolveLabelIDs(remove, ids)
+	if err != nil {
+		return err
+	}
+	return m.clients[ref.accountIndex].ModifyThreadLabels(m.ctx, ref.threadID, addIDs, removeIDs)
+}
+
 type attachmentDownloadedMsg struct {
 	filename string
+	path     string
 	err      error
 }
 
@@ -615,8 +768,14 @@ type attachmentLoadedMsg struct {
 	err      error
 }
 
-// downloadAttachmentCmd downloads an attachment to ~/Downloads
-func (m *Model) downloadAttachmentCmd(attachmentIdx int) tea.Cmd {
+// downloadAttachmentCmd saves an attachment to dest, or to the download
+// directory when dest is empty
+func (m *Model) downloadAttachmentCmd(
+	ctx context.Context,
+	written *atomic.Int64,
+	attachmentIdx int,
+	dest string,
+) tea.Cmd {
 	return func() tea.Msg {
 		if attachmentIdx < 0 || attachmentIdx >= len(m.attachments.modal.attachments) {
 			return attachmentDownloadedMsg{err: errors.New("invalid attachment index")}
@@ -624,40 +783,30 @@ func (m *Model) downloadAttachmentCmd(attachmentIdx int) tea.Cmd {
 
 		att := m.attachments.modal.attachments[attachmentIdx]
 
-		// Get home directory
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return attachmentDownloadedMsg{filename: att.Filename, err: err}
-		}
-
-		// Create Downloads directory if it doesn't exist
-		downloadsDir := filepath.Join(homeDir, "Downloads")
-		if err := os.MkdirAll(downloadsDir, 0o750); err != nil {
-			return attachmentDownloadedMsg{filename: att.Filename, err: err}
-		}
-
-		// Create file
-		filePath := filepath.Join(downloadsDir, att.Filename)
-		file, err := os.Create(filePath)
+		file, filePath, err := m.createDownloadFile(dest, att.Filename)
 		if err != nil {
 			return attachmentDownloadedMsg{filename: att.Filename, err: err}
 		}
-		defer file.Close()
 
 		// Stream download and decode directly to file
-		err = m.clients[m.attachments.modal.accountIndex].DownloadAttachmentToWriter(
-			m.ctx,
+		err = writeAttachment(
+			ctx,
+			m.clients[m.attachments.modal.accountIndex],
 			m.attachments.modal.messageID,
-			att.AttachmentID,
+			att,
 			file,
+			written,
 		)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
 		if err != nil {
 			// Clean up partial file on error
 			os.Remove(filePath)
 			return attachmentDownloadedMsg{filename: att.Filename, err: err}
 		}
 
-		return attachmentDownloadedMsg{filename: att.Filename, err: nil}
+		return attachmentDownloadedMsg{filename: att.Filename, path: filePath}
 	}
 }
 
@@ -690,9 +839,38 @@ func (m *Model) loadAttachmentForViewCmd(attachmentIdx int) tea.Cmd {
 	}
 }
 
-func clearKittyImagesCmd() tea.Cmd {
+// loadInlineImageCmd loads a Content-ID image part for the image viewer.
+// Small parts arrive with the message, so only large ones are fetched.
+func (m *Model) loadInlineImageCmd(accountIndex int, messageID string, img gmail.Attachment) tea.Cmd {
+	filename := cmp.Or(img.Filename, img.ContentID)
+	return func() tea.Msg {
+		data := img.Data
+		if data == "" {
+			if accountIndex < 0 || accountIndex >= len(m.clients) {
+				return attachmentLoadedMsg{filename: filename, err: errors.New("invalid account")}
+			}
+			var err error
+			data, err = m.clients[accountIndex].GetAttachmentData(m.ctx, messageID, img.AttachmentID)
+			if err != nil {
+				return attachmentLoadedMsg{filename: filename, err: err}
+			}
+		}
+		return attachmentLoadedMsg{
+			data:     data,
+			mimeType: img.MimeType,
+			filename: filename,
+			size:     img.Size,
+		}
+	}
+}
+
+func clearImagesCmd(protocol graphicsProtocol) tea.Cmd {
+	commands := protocol.clearCommands()
+	if commands == "" {
+		return nil
+	}
 	return func() tea.Msg {
-		fmt.Print(kittyClearCommands)
+		fmt.Print(commands)
 		return nil
 	}
 }
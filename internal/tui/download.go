@@ -0,0 +1,361 @@
+package tui
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.withmatt.com/inbox/internal/config"
+	"go.withmatt.com/inbox/internal/gmail"
+)
+
+const (
+	// maxFilenameBytes is the name limit on most filesystems
+	maxFilenameBytes = 255
+	// maxCollisionSuffix bounds the search for a free "name (n).ext"
+	maxCollisionSuffix = 1000
+	// maxPathCompletions keeps huge directories from flooding the prompt
+	maxPathCompletions = 200
+)
+
+type threadAttachmentsSavedMsg struct {
+	count int
+	dir   string
+	err   error
+}
+
+// sanitizeFilename makes an attachment's name safe to create inside the
+// download directory: no path separators, no control characters, and never
+// empty, "." or "..".
+func sanitizeFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == '/' || r == '\\':
+			return '_'
+		case unicode.IsControl(r):
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." {
+		return "attachment"
+	}
+	if len(name) <= maxFilenameBytes {
+		return name
+	}
+	ext := filepath.Ext(name)
+	if len(ext) > 16 {
+		ext = ""
+	}
+	base := name[:maxFilenameBytes-len(ext)]
+	for !utf8.ValidString(base) {
+		base = base[:len(base)-1]
+	}
+	return base + ext
+}
+
+// createUniqueFile creates name in dir, adding " (1)", " (2)" and so on
+// before the extension if the name is taken.
+func createUniqueFile(dir, name string) (*os.File, string, error) {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if base == "" {
+		// Dotfiles like ".env" are all extension
+		base, ext = name, ""
+	}
+	for i := range maxCollisionSuffix {
+		candidate := name
+		if i > 0 {
+			candidate = fmt.Sprintf("%s (%d)%s", base, i, ext)
+		}
+		path := filepath.Join(dir, candidate)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return file, path, err
+	}
+	return nil, "", fmt.Errorf("too many files named %s in %s", name, dir)
+}
+
+// downloadsDir returns the configured download directory, creating it if
+// needed.
+func (m *Model) downloadsDir() (string, error) {
+	dir, err := m.attachmentConfig.ResolveDownloadDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// createDownloadFile opens the file an attachment is saved to. An empty dest
+// means the download directory; an existing directory gets a collision-free
+// name inside it; anything else is created as given and must not exist.
+func (m *Model) createDownloadFile(dest, filename string) (*os.File, string, error) {
+	if dest == "" {
+		dir, err := m.downloadsDir()
+		if err != nil {
+			return nil, "", err
+		}
+		return createUniqueFile(dir, sanitizeFilename(filename))
+	}
+	path, err := config.ExpandPath(dest)
+	if err != nil {
+		return nil, "", err
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return createUniqueFile(path, sanitizeFilename(filename))
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, "", err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	return file, path, err
+}
+
+// writeAttachment streams an attachment to w, using the data Gmail already
+// returned for small parts. written, if set, counts the bytes as they land.
+func writeAttachment(
+	ctx context.Context,
+	client *gmail.Client,
+	messageID string,
+	att gmail.Attachment,
+	w io.Writer,
+	written *atomic.Int64,
+) error {
+	if written != nil {
+		w = &countingWriter{w: w, n: written}
+	}
+	if att.Data != "" {
+		decoded, err := decodeAttachmentData(att.Data)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(decoded)
+		return err
+	}
+	return client.DownloadAttachmentToWriter(ctx, messageID, att.AttachmentID, w)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// beginDownload marks the modal busy with the selected attachment, returning
+// a context esc cancels and the counter the progress bar reads.
+func (m *Model) beginDownload() (context.Context, *atomic.Int64) {
+	modal := &m.attachments.modal
+	ctx, cancel := context.WithCancel(m.ctx)
+	written := new(atomic.Int64)
+	modal.downloading = true
+	modal.download = downloadState{written: written, cancel: cancel}
+	if modal.selectedIdx >= 0 && modal.selectedIdx < len(modal.attachments) {
+		modal.download.total = modal.attachments[modal.selectedIdx].Size
+	}
+	return ctx, written
+}
+
+func (m *Model) endDownload() {
+	if cancel := m.attachments.modal.download.cancel; cancel != nil {
+		cancel()
+	}
+	m.attachments.modal.downloading = false
+	m.attachments.modal.download = downloadState{}
+}
+
+// openSaveAs prompts for where to save the selected attachment, starting
+// from the download directory.
+func (m Model) openSaveAs() (Model, tea.Cmd) {
+	modal := &m.attachments.modal
+	if modal.selectedIdx < 0 || modal.selectedIdx >= len(modal.attachments) {
+		return m, nil
+	}
+	dir, err := m.attachmentConfig.ResolveDownloadDir()
+	if err != nil {
+		m.ui.err = err
+		m.ui.showError = true
+		return m, nil
+	}
+	att := modal.attachments[modal.selectedIdx]
+
+	input := textinput.New()
+	input.Prompt = "Save as: "
+	input.CharLimit = 4096
+	input.Width = attachmentsModalWidth - utf8.RuneCountInString(input.Prompt) - 1
+	input.ShowSuggestions = true
+	input.SetValue(tildePath(filepath.Join(dir, sanitizeFilename(att.Filename))))
+	input.SetSuggestions(pathCompletions(input.Value()))
+	input.CursorEnd()
+	input.Focus()
+	modal.saveAs = saveAsState{active: true, input: input}
+	return m, textinput.Blink
+}
+
+func (m Model) handleSaveAsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	modal := &m.attachments.modal
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		modal.saveAs = saveAsState{}
+		return m, nil
+	case "enter":
+		dest := strings.TrimSpace(modal.saveAs.input.Value())
+		modal.saveAs = saveAsState{}
+		if dest == "" {
+			return m, nil
+		}
+		ctx, written := m.beginDownload()
+		return m, tea.Batch(m.downloadAttachmentCmd(ctx, written, modal.selectedIdx, dest), m.ui.spinner.Tick)
+	}
+
+	var cmd tea.Cmd
+	modal.saveAs.input, cmd = modal.saveAs.input.Update(msg)
+	modal.saveAs.input.SetSuggestions(pathCompletions(modal.saveAs.input.Value()))
+	return m, cmd
+}
+
+// pathCompletions lists the entries in the directory being typed that start
+// with the partial name after the last separator. Directories end in a
+// separator so Tab can keep descending.
+func pathCompletions(value string) []string {
+	typedDir := value[:strings.LastIndex(value, string(filepath.Separator))+1]
+	prefix := value[len(typedDir):]
+	dir, err := config.ExpandPath(cmp.Or(typedDir, "."))
+	if err != nil {
+		return nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	completions := make([]string, 0, min(len(entries), maxPathCompletions))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, prefix) ||
+			(strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".")) {
+			continue
+		}
+		completion := typedDir + name
+		if entry.IsDir() {
+			completion += string(filepath.Separator)
+		}
+		completions = append(completions, completion)
+		if len(completions) == maxPathCompletions {
+			break
+		}
+	}
+	return completions
+}
+
+// tildePath shortens paths under the home directory to ~/...
+func tildePath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~" + string(filepath.Separator) + rel
+	}
+	return path
+}
+
+// saveThreadAttachments saves every attachment in the thread into a
+// subdirectory of the download directory named after the subject.
+func (m Model) saveThreadAttachments() (Model, tea.Cmd) {
+	if m.detail.currentThread == nil {
+		return m, nil
+	}
+	count := 0
+	for _, msg := range m.detail.messages {
+		count += len(msg.Attachments)
+	}
+	if count == 0 {
+		return m, nil
+	}
+	subject := m.detail.currentThread.Subject
+	if len(m.detail.messages) > 0 {
+		subject = cmp.Or(subject, m.detail.messages[0].Subject)
+	}
+	m.logf("Thread attachments save thread=%s count=%d", m.detail.currentThread.ThreadID, count)
+	return m, tea.Batch(
+		m.toastCmd(fmt.Sprintf(
+			"Saving %d %s…", count, pluralize(count, "attachment"),
+		)),
+		m.saveThreadAttachmentsCmd(m.detail.currentThread.AccountIndex, subject, m.detail.messages),
+	)
+}
+
+func (m *Model) saveThreadAttachmentsCmd(accountIndex int, subject string, messages []gmail.Message) tea.Cmd {
+	return func() tea.Msg {
+		if accountIndex < 0 || accountIndex >= len(m.clients) {
+			return threadAttachmentsSavedMsg{err: errors.New("invalid account")}
+		}
+		parent, err := m.downloadsDir()
+		if err != nil {
+			return threadAttachmentsSavedMsg{err: err}
+		}
+		dir := filepath.Join(parent, sanitizeFilename(cmp.Or(subject, "attachments")))
+		if err := os.MkdirAll(dir, 0o750); err != nil {
+			return threadAttachmentsSavedMsg{err: err}
+		}
+		saved := 0
+		for _, msg := range messages {
+			for _, att := range msg.Attachments {
+				file, path, err := createUniqueFile(dir, sanitizeFilename(att.Filename))
+				if err != nil {
+					return threadAttachmentsSavedMsg{count: saved, dir: dir, err: err}
+				}
+				err = writeAttachment(m.ctx, m.clients[accountIndex], msg.ID, att, file, nil)
+				if closeErr := file.Close(); err == nil {
+					err = closeErr
+				}
+				if err != nil {
+					os.Remove(path)
+					return threadAttachmentsSavedMsg{
+						count: saved,
+						dir:   dir,
+						err:   fmt.Errorf("%s: %w", att.Filename, err),
+					}
+				}
+				saved++
+			}
+		}
+		return threadAttachmentsSavedMsg{count: saved, dir: dir}
+	}
+}
+
+func (m Model) handleThreadAttachmentsSaved(msg threadAttachmentsSavedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.logf("Thread attachments save failed after %d: %v", msg.count, msg.err)
+		m.ui.err = fmt.Errorf("failed to save attachments: %w", msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	return m, m.toastCmd(fmt.Sprintf(
+		"Saved %d %s to %s", msg.count, pluralize(msg.count, "attachment"), tildePath(msg.dir),
+	))
+}
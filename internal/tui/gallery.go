@@ -0,0 +1,394 @@
+package tui
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go.withmatt.com/inbox/internal/gmail"
+	"go.withmatt.com/inbox/internal/image"
+)
+
+const (
+	// galleryThumbCols and galleryThumbRows size each thumbnail in the strip
+	galleryThumbCols = 10
+	galleryThumbRows = 3
+	// galleryStripHeight is the thumbnails plus their number row
+	galleryStripHeight = galleryThumbRows + 1
+	// galleryLoads caps the images downloading at once
+	galleryLoads = 3
+	// galleryKeep is how many images either side of the selected one keep
+	// their full data, so stepping to them is instant
+	galleryKeep = 2
+)
+
+type galleryImageLoadedMsg struct {
+	generation int
+	index      int
+	data       string
+	thumb      string
+	err        error
+}
+
+type gallerySavedMsg struct {
+	count int
+	dir   string
+	err   error
+}
+
+// galleryItems collects every image attachment and inline image in the
+// thread, in message order. Named inline images are attachments as well and
+// are only listed once.
+func galleryItems(messages []gmail.Message) []galleryItem {
+	var items []galleryItem
+	for _, msg := range messages {
+		listed := make(map[string]bool, len(msg.Attachments))
+		for _, att := range msg.Attachments {
+			if isImageMimeType(att.MimeType) {
+				items = append(items, galleryItem{messageID: msg.ID, image: att})
+				listed[att.AttachmentID] = true
+			}
+		}
+		for _, img := range msg.InlineImages {
+			if img.AttachmentID != "" && listed[img.AttachmentID] {
+				continue
+			}
+			if isImageMimeType(img.MimeType) {
+				items = append(items, galleryItem{messageID: msg.ID, image: img})
+			}
+		}
+	}
+	return items
+}
+
+// openGallery opens the image viewer on the first image in the thread and
+// loads the rest in the background, nearest first, for the thumbnail strip.
+func (m Model) openGallery() (Model, tea.Cmd) {
+	if m.detail.currentThread == nil {
+		return m, nil
+	}
+	items := galleryItems(m.detail.messages)
+	if len(items) == 0 {
+		return m, nil
+	}
+	m.gallery = galleryState{
+		active:       true,
+		items:        items,
+		accountIndex: m.detail.currentThread.AccountIndex,
+		generation:   m.gallery.generation + 1,
+		waiting:      true,
+		data:         make(map[int]string),
+		thumbs:       make(map[int]string),
+		loading:      make(map[int]bool),
+		failed:       make(map[int]bool),
+	}
+	m.logf("Gallery open thread=%s images=%d", m.detail.currentThread.ThreadID, len(items))
+	return m, m.loadGalleryCmd()
+}
+
+// galleryDistance is how many steps apart two images are, wrapping around.
+func (m *Model) galleryDistance(a, b int) int {
+	d := a - b
+	if d < 0 {
+		d = -d
+	}
+	return min(d, len(m.gallery.items)-d)
+}
+
+// loadGalleryCmd starts downloading the images still needed, nearest to the
+// selected one first, keeping at most galleryLoads in flight. Images near
+// the selection need their data; the rest only until they have a thumbnail.
+func (m *Model) loadGalleryCmd() tea.Cmd {
+	state := &m.gallery
+	n := len(state.items)
+	var cmds []tea.Cmd
+	for step := 0; step <= n/2 && len(state.loading) < galleryLoads; step++ {
+		for _, i := range []int{(state.index + step) % n, ((state.index-step)%n + n) % n} {
+			if len(state.loading) >= galleryLoads {
+				break
+			}
+			if state.loading[i] || state.failed[i] {
+				continue
+			}
+			_, hasThumb := state.thumbs[i]
+			_, hasData := state.data[i]
+			if hasData || (hasThumb && m.galleryDistance(i, state.index) > galleryKeep) {
+				continue
+			}
+			state.loading[i] = true
+			cmds = append(cmds, m.loadGalleryImageCmd(i))
+		}
+	}
+	return tea.Batch(cmds...)
+}
+
+// pruneGalleryData drops the data of images far from the selected one.
+func (m *Model) pruneGalleryData() {
+	for i := range m.gallery.data {
+		if m.galleryDistance(i, m.gallery.index) > galleryKeep {
+			delete(m.gallery.data, i)
+		}
+	}
+}
+
+func (m *Model) loadGalleryImageCmd(index int) tea.Cmd {
+	gen := m.gallery.generation
+	accountIndex := m.gallery.accountIndex
+	item := m.gallery.items[index]
+	return func() tea.Msg {
+		data := item.image.Data
+		if data == "" {
+			if accountIndex < 0 || accountIndex >= len(m.clients) {
+				return galleryImageLoadedMsg{generation: gen, index: index, err: errors.New("invalid account")}
+			}
+			var err error
+			data, err = m.clients[accountIndex].GetAttachmentData(m.ctx, item.messageID, item.image.AttachmentID)
+			if err != nil {
+				return galleryImageLoadedMsg{generation: gen, index: index, err: err}
+			}
+		}
+		// Rendering the thumbnail here keeps decoding off the UI loop
+		var thumb string
+		if img, err := image.Decode(data); err == nil {
+			thumb = image.RenderThumbnail(img, galleryThumbCols, galleryThumbRows)
+		}
+		return galleryImageLoadedMsg{generation: gen, index: index, data: data, thumb: thumb}
+	}
+}
+
+func (m Model) handleGalleryImageLoaded(msg galleryImageLoadedMsg) (tea.Model, tea.Cmd) {
+	if !m.gallery.active || msg.generation != m.gallery.generation {
+		return m, nil
+	}
+	item := m.gallery.items[msg.index]
+	delete(m.gallery.loading, msg.index)
+	if msg.err != nil {
+		m.logf("Gallery load failed message=%s file=%s: %v", item.messageID, galleryFilename(item, msg.index), msg.err)
+		// An empty thumbnail marks the image as unavailable in the strip
+		m.gallery.thumbs[msg.index] = ""
+		m.gallery.failed[msg.index] = true
+		if msg.index != m.gallery.index || !m.gallery.waiting {
+			return m, m.loadGalleryCmd()
+		}
+		m.gallery.waiting = false
+		if m.currentView != viewImage {
+			m.gallery = galleryState{generation: m.gallery.generation}
+			m.ui.err = fmt.Errorf("failed to load %s: %w", galleryFilename(item, msg.index), msg.err)
+			m.ui.showError = true
+			return m, nil
+		}
+		m.gallery.status = "failed to load"
+		return m, m.loadGalleryCmd()
+	}
+	m.gallery.thumbs[msg.index] = msg.thumb
+	if m.galleryDistance(msg.index, m.gallery.index) <= galleryKeep {
+		m.gallery.data[msg.index] = msg.data
+	}
+	loadCmd := m.loadGalleryCmd()
+	if msg.index != m.gallery.index || !m.gallery.waiting {
+		return m, loadCmd
+	}
+	if m.currentView != viewImage && m.currentView != viewDetail {
+		// The user moved on before the first image arrived
+		m.gallery = galleryState{generation: m.gallery.generation}
+		return m, nil
+	}
+	return m, tea.Batch(m.showGalleryImage(), loadCmd)
+}
+
+// showGalleryImage puts the selected image in the viewer, or waits for it
+// to finish loading.
+func (m *Model) showGalleryImage() tea.Cmd {
+	data, ok := m.gallery.data[m.gallery.index]
+	if !ok {
+		if m.gallery.failed[m.gallery.index] {
+			m.gallery.waiting = false
+			m.gallery.status = "failed to load"
+			return nil
+		}
+		m.gallery.waiting = true
+		return nil
+	}
+	m.gallery.waiting = false
+	item := m.gallery.items[m.gallery.index]
+	firstImage := m.currentView != viewImage
+	cmd := m.enterImageView(attachmentLoadedMsg{
+		data:     data,
+		mimeType: item.image.MimeType,
+		filename: galleryFilename(item, m.gallery.index),
+		size:     item.image.Size,
+	})
+	if firstImage {
+		return tea.Batch(cmd, m.setWindowTitleCmd())
+	}
+	// The previous image has to go before the next one is drawn
+	return tea.Batch(cmd, clearImagesCmd(m.image.protocol), tea.ClearScreen)
+}
+
+// stepGallery moves to the next or previous image, wrapping around.
+func (m Model) stepGallery(delta int) (Model, tea.Cmd) {
+	if !m.gallery.active || len(m.gallery.items) < 2 {
+		return m, nil
+	}
+	n := len(m.gallery.items)
+	m.gallery.index = ((m.gallery.index+delta)%n + n) % n
+	m.gallery.status = ""
+	m.pruneGalleryData()
+	return m, tea.Batch(m.showGalleryImage(), m.loadGalleryCmd())
+}
+
+func (m Model) saveGalleryImage() (Model, tea.Cmd) {
+	if !m.gallery.active {
+		return m, nil
+	}
+	m.gallery.status = "saving…"
+	return m, m.saveGalleryCmd([]int{m.gallery.index})
+}
+
+func (m Model) saveAllGalleryImages() (Model, tea.Cmd) {
+	if !m.gallery.active {
+		return m, nil
+	}
+	indices := make([]int, len(m.gallery.items))
+	for i := range indices {
+		indices[i] = i
+	}
+	m.gallery.status = "saving…"
+	return m, m.saveGalleryCmd(indices)
+}
+
+// saveGalleryCmd writes the given images to the downloads directory,
+// fetching any that haven't loaded yet.
+func (m *Model) saveGalleryCmd(indices []int) tea.Cmd {
+	type pending struct {
+		item     galleryItem
+		filename string
+		data     string
+	}
+	// Snapshot what has loaded; the maps keep changing on the UI loop
+	files := make([]pending, 0, len(indices))
+	for _, i := range indices {
+		item := m.gallery.items[i]
+		files = append(files, pending{
+			item:     item,
+			filename: galleryFilename(item, i),
+			data:     m.gallery.data[i],
+		})
+	}
+	accountIndex := m.gallery.accountIndex
+	return func() tea.Msg {
+		dir, err := m.downloadsDir()
+		if err != nil {
+			return gallerySavedMsg{err: err}
+		}
+		for i, f := range files {
+			data := f.data
+			if data == "" {
+				if accountIndex < 0 || accountIndex >= len(m.clients) {
+					return gallerySavedMsg{count: i, dir: dir, err: errors.New("invalid account")}
+				}
+				data, err = m.clients[accountIndex].GetAttachmentData(m.ctx, f.item.messageID, f.item.image.AttachmentID)
+				if err != nil {
+					return gallerySavedMsg{count: i, dir: dir, err: fmt.Errorf("%s: %w", f.filename, err)}
+				}
+			}
+			decoded, err := decodeAttachmentData(data)
+			if err != nil {
+				return gallerySavedMsg{count: i, dir: dir, err: fmt.Errorf("%s: %w", f.filename, err)}
+			}
+			file, path, err := createUniqueFile(dir, sanitizeFilename(f.filename))
+			if err != nil {
+				return gallerySavedMsg{count: i, dir: dir, err: err}
+			}
+			_, err = file.Write(decoded)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return gallerySavedMsg{count: i, dir: dir, err: err}
+			}
+		}
+		return gallerySavedMsg{count: len(files), dir: dir}
+	}
+}
+
+func (m Model) handleGallerySaved(msg gallerySavedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.logf("Gallery save failed after %d: %v", msg.count, msg.err)
+		m.gallery.status = "save failed"
+		m.ui.err = fmt.Errorf("failed to save images: %w", msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	m.gallery.status = fmt.Sprintf("saved %d to %s", msg.count, tildePath(msg.dir))
+	if msg.count == 1 {
+		m.gallery.status = "saved to " + tildePath(msg.dir)
+	}
+	return m, nil
+}
+
+// galleryFilename names an item for the statusline and for saving. Inline
+// images often have no filename, so fall back to the Content-ID or position.
+func galleryFilename(item galleryItem, index int) string {
+	name := cmp.Or(item.image.Filename, strings.Trim(item.image.ContentID, "<>"))
+	if name == "" {
+		name = fmt.Sprintf("image-%d", index+1)
+		if exts, _ := mime.ExtensionsByType(item.image.MimeType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return filepath.Base(name)
+}
+
+// renderGalleryStrip draws a row of thumbnails around the selected image,
+// with the image numbers underneath.
+func (m *Model) renderGalleryStrip() string {
+	n := len(m.gallery.items)
+	visible := max(1, min(n, (m.ui.width+1)/(galleryThumbCols+1)))
+	start := min(max(0, m.gallery.index-visible/2), n-visible)
+
+	cellStyle := lipgloss.NewStyle().
+		Width(galleryThumbCols).
+		Height(galleryThumbRows).
+		MaxHeight(galleryThumbRows).
+		Align(lipgloss.Center, lipgloss.Center)
+	placeholderStyle := cellStyle.Foreground(lipgloss.Color(m.theme.Image.MetaFg))
+	labelStyle := lipgloss.NewStyle().
+		Width(galleryThumbCols).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Image.MetaFg))
+	selectedStyle := labelStyle.
+		Foreground(lipgloss.Color(m.theme.Detail.BorderSelected)).
+		Bold(true)
+
+	cells := make([]string, 0, visible*2)
+	for i := start; i < start+visible; i++ {
+		if i > start {
+			cells = append(cells, " ")
+		}
+		thumb, ok := m.gallery.thumbs[i]
+		var top string
+		switch {
+		case ok && thumb != "":
+			top = cellStyle.Render(thumb)
+		case ok:
+			top = placeholderStyle.Render("?")
+		default:
+			top = placeholderStyle.Render("…")
+		}
+		label := labelStyle.Render(fmt.Sprintf("%d", i+1))
+		if i == m.gallery.index {
+			label = selectedStyle.Render(fmt.Sprintf("[%d]", i+1))
+		}
+		cells = append(cells, lipgloss.JoinVertical(lipgloss.Center, top, label))
+	}
+	return lipgloss.JoinHorizontal(lipgloss.Top, cells...)
+}
@@ -0,0 +1,61 @@
+package tui
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	"go.withmatt.com/inbox/internal/gmail"
+)
+
+func TestGalleryLoadsNearestFirst(t *testing.T) {
+	var m Model
+	m.currentView = viewImage
+	for i := range 10 {
+		m.gallery.items = append(m.gallery.items, galleryItem{
+			messageID: "m",
+			image:     gmail.Attachment{MimeType: "image/png", Data: string(rune('a' + i))},
+		})
+	}
+	m.gallery.active = true
+	m.gallery.data = make(map[int]string)
+	m.gallery.thumbs = make(map[int]string)
+	m.gallery.loading = make(map[int]bool)
+	m.gallery.failed = make(map[int]bool)
+
+	loading := func() []int {
+		return slices.Sorted(maps.Keys(m.gallery.loading))
+	}
+	m.loadGalleryCmd()
+	if got := loading(); !slices.Equal(got, []int{0, 1, 9}) {
+		t.Fatalf("first loads = %v, want the selected image and its neighbours", got)
+	}
+
+	// Finishing loads starts the next nearest, never more than galleryLoads
+	for range 20 {
+		if len(m.gallery.loading) == 0 {
+			break
+		}
+		i := loading()[0]
+		model, _ := m.handleGalleryImageLoaded(galleryImageLoadedMsg{index: i, data: "data"})
+		m = model.(Model)
+		if len(m.gallery.loading) > galleryLoads {
+			t.Fatalf("%d loads in flight", len(m.gallery.loading))
+		}
+	}
+	if len(m.gallery.thumbs) != 10 {
+		t.Errorf("loaded %d thumbnails, want 10", len(m.gallery.thumbs))
+	}
+	if got := slices.Sorted(maps.Keys(m.gallery.data)); !slices.Equal(got, []int{0, 1, 2, 8, 9}) {
+		t.Errorf("kept data for %v, want only images near the selection", got)
+	}
+
+	// Moving on drops data that's now far away and reloads what's near
+	m, _ = m.stepGallery(5)
+	if got := slices.Sorted(maps.Keys(m.gallery.data)); len(got) != 0 {
+		t.Errorf("kept data for %v after moving away", got)
+	}
+	if got := loading(); !slices.Equal(got, []int{4, 5, 6}) {
+		t.Errorf("loads after moving = %v, want 4, 5 and 6", got)
+	}
+}
@@ -0,0 +1,159 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+
+	"go.withmatt.com/inbox/internal/config"
+)
+
+// graphicsProtocol is how the image viewer draws pixels.
+type graphicsProtocol int
+
+const (
+	// graphicsHalfBlocks needs nothing but truecolor, so it is the fallback
+	graphicsHalfBlocks graphicsProtocol = iota
+	graphicsKitty
+	graphicsSixel
+	graphicsITerm2
+)
+
+// graphicsQueryTimeout bounds how long startup waits for the terminal to
+// answer; terminals that don't understand a query stay silent.
+const graphicsQueryTimeout = 200 * time.Millisecond
+
+const (
+	// kittyGraphicsQuery asks whether a 1x1 image would be accepted, without
+	// displaying anything.
+	kittyGraphicsQuery = "\x1b_Gi=31,s=1,v=1,a=q,t=d,f=24;AAAA\x1b\\"
+	// primaryDeviceAttributes is answered by nearly every terminal, so it
+	// marks the end of the replies.
+	primaryDeviceAttributes = "\x1b[c"
+)
+
+var deviceAttributesReply = regexp.MustCompile(`\x1b\[\?([0-9;]*)c`)
+
+func (p graphicsProtocol) String() string {
+	switch p {
+	case graphicsKitty:
+		return config.ImageProtocolKitty
+	case graphicsSixel:
+		return config.ImageProtocolSixel
+	case graphicsITerm2:
+		return config.ImageProtocolITerm2
+	case graphicsHalfBlocks:
+		return config.ImageProtocolHalfBlocks
+	default:
+		return "unknown"
+	}
+}
+
+// clearCommands removes any images the protocol leaves behind. Only Kitty
+// keeps images outside the text grid; the others are wiped with the screen.
+func (p graphicsProtocol) clearCommands() string {
+	if p == graphicsKitty {
+		return kittyClearCommands
+	}
+	return ""
+}
+
+// detectGraphicsProtocol resolves the configured image protocol, checking
+// the environment and then querying the terminal when set to auto. It must
+// run before the program takes over the terminal.
+func detectGraphicsProtocol(setting string) graphicsProtocol {
+	switch setting {
+	case config.ImageProtocolKitty:
+		return graphicsKitty
+	case config.ImageProtocolSixel:
+		return graphicsSixel
+	case config.ImageProtocolITerm2:
+		return graphicsITerm2
+	case config.ImageProtocolHalfBlocks:
+		return graphicsHalfBlocks
+	}
+	if p, ok := graphicsProtocolFromEnv(); ok {
+		return p
+	}
+	return queryGraphicsProtocol()
+}
+
+// graphicsProtocolFromEnv recognizes terminals that identify themselves.
+func graphicsProtocolFromEnv() (graphicsProtocol, bool) {
+	termName := os.Getenv("TERM")
+	termProgram := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "" ||
+		termName == "xterm-kitty" ||
+		termName == "xterm-ghostty" ||
+		termProgram == "ghostty":
+		return graphicsKitty, true
+	case termProgram == "iTerm.app" ||
+		os.Getenv("LC_TERMINAL") == "iTerm2" ||
+		termProgram == "WezTerm":
+		return graphicsITerm2, true
+	case strings.HasPrefix(termName, "foot") ||
+		strings.HasPrefix(termName, "mlterm") ||
+		strings.Contains(termName, "sixel"):
+		return graphicsSixel, true
+	}
+	return graphicsHalfBlocks, false
+}
+
+// queryGraphicsProtocol asks the terminal for Kitty graphics support and for
+// its device attributes, where a 4 means sixel.
+func queryGraphicsProtocol() graphicsProtocol {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return graphicsHalfBlocks
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return graphicsHalfBlocks
+	}
+	defer term.Restore(fd, state)
+
+	if _, err := os.Stdout.WriteString(kittyGraphicsQuery + primaryDeviceAttributes); err != nil {
+		return graphicsHalfBlocks
+	}
+
+	var reply []byte
+	buf := make([]byte, 256)
+	deadline := time.Now().Add(graphicsQueryTimeout)
+	for !deviceAttributesReply.Match(reply) {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(remaining.Milliseconds())+1)
+		if err != nil && !errors.Is(err, unix.EINTR) {
+			break
+		}
+		if n <= 0 {
+			continue
+		}
+		read, err := unix.Read(fd, buf)
+		if err != nil || read <= 0 {
+			break
+		}
+		reply = append(reply, buf[:read]...)
+	}
+
+	if strings.Contains(string(reply), "\x1b_Gi=31;OK") {
+		return graphicsKitty
+	}
+	if match := deviceAttributesReply.FindSubmatch(reply); match != nil {
+		for attr := range strings.SplitSeq(string(match[1]), ";") {
+			if attr == "4" {
+				return graphicsSixel
+			}
+		}
+	}
+	return graphicsHalfBlocks
+}
@@ -0,0 +1,182 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/wordwrap"
+	"github.com/muesli/reflow/wrap"
+
+	"go.withmatt.com/inbox/internal/gmail"
+	"go.withmatt.com/inbox/internal/headers"
+)
+
+// slowHopDelay is how long a relay can hold a message before the route
+// view points it out
+const slowHopDelay = time.Minute
+
+// highlightedHeaders are picked out in the full header view, since they
+// say who really sent a message and where replies go.
+var highlightedHeaders = map[string]bool{
+	"authentication-results": true,
+	"received":               true,
+	"list-id":                true,
+	"reply-to":               true,
+}
+
+func headerValues(msg gmail.Message, name string) []string {
+	var values []string
+	for _, header := range msg.Headers {
+		if strings.EqualFold(header.Name, name) {
+			values = append(values, header.Value)
+		}
+	}
+	return values
+}
+
+func headerValue(msg gmail.Message, name string) string {
+	if values := headerValues(msg, name); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// receiverAuthResults parses the topmost Authentication-Results header,
+// which Gmail adds on arrival. Ones further down could have been written by
+// anyone, including the sender.
+func receiverAuthResults(msg gmail.Message) []headers.AuthResult {
+	value := headerValue(msg, "Authentication-Results")
+	if value == "" {
+		return nil
+	}
+	return headers.ParseAuthResults(value)
+}
+
+func messageWarnings(msg gmail.Message) []string {
+	return headers.Warnings(msg.From, headerValue(msg, "Reply-To"), receiverAuthResults(msg))
+}
+
+// toggleHeaders switches between the short header block and every header.
+func (m Model) toggleHeaders() (Model, tea.Cmd) {
+	m.detail.allHeaders = !m.detail.allHeaders
+	m.detail.viewport.SetContent(m.renderThreadBody())
+	return m, nil
+}
+
+// renderWarnings draws a message's warnings, one per line.
+func (m *Model) renderWarnings(msg gmail.Message, width int) string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Detail.AuthFailFg)).Bold(true)
+	var b strings.Builder
+	for _, warning := range messageWarnings(msg) {
+		b.WriteString(style.Render(truncateToWidth("⚠ "+warning, width)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderAllHeaders summarizes authentication and the relay route, then
+// lists every header, wrapped to width.
+func (m *Model) renderAllHeaders(msg gmail.Message, width int) string {
+	labelStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.theme.Detail.HeaderLabelFg)).
+		Bold(true)
+	highlightStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.theme.Detail.HeaderHighlightFg)).
+		Bold(true)
+	valueStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.theme.Detail.HeaderValueFg))
+	passStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Detail.AuthPassFg))
+	failStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Detail.AuthFailFg))
+
+	var b strings.Builder
+	if results := receiverAuthResults(msg); len(results) > 0 {
+		b.WriteString(highlightStyle.Render("Authentication: "))
+		for i, result := range results {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			text := result.Method + " " + result.Result
+			switch {
+			case result.Passed():
+				b.WriteString(passStyle.Render(text))
+			case result.Failed():
+				b.WriteString(failStyle.Render(text))
+			default:
+				b.WriteString(valueStyle.Render(text))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if hops := headers.Route(headerValues(msg, "Received")); len(hops) > 0 {
+		summary := fmt.Sprintf("%d %s", len(hops), pluralize(len(hops), "hop"))
+		if first, last := hops[0].Time, hops[len(hops)-1].Time; !first.IsZero() && !last.IsZero() {
+			summary += " over " + formatHopDelay(last.Sub(first))
+		}
+		b.WriteString(highlightStyle.Render("Route: "))
+		b.WriteString(valueStyle.Render(summary))
+		b.WriteString("\n")
+		for i, hop := range hops {
+			line := fmt.Sprintf("  %d  %s → %s", i+1, orUnknown(hop.From), orUnknown(hop.By))
+			delay := ""
+			if i > 0 && !hop.Time.IsZero() {
+				delay = "  +" + formatHopDelay(hop.Delay)
+			}
+			line = truncateToWidth(line, max(width-lipgloss.Width(delay), 0))
+			b.WriteString(valueStyle.Render(line))
+			if hop.Delay >= slowHopDelay {
+				b.WriteString(failStyle.Render(delay))
+			} else {
+				b.WriteString(labelStyle.Render(delay))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+
+	for _, header := range msg.Headers {
+		nameStyle := labelStyle
+		if highlightedHeaders[strings.ToLower(header.Name)] {
+			nameStyle = highlightStyle
+		}
+		label := header.Name + ": "
+		value := strings.Join(strings.Fields(header.Value), " ")
+		if width <= lipgloss.Width(label)+2 {
+			b.WriteString(nameStyle.Render(truncateToWidth(label, width)))
+			b.WriteString("\n")
+			continue
+		}
+		// Long tokens like DKIM signatures need a hard wrap too
+		lines := strings.Split(wrap.String(wordwrap.String(label+value, width), width), "\n")
+		for i, line := range lines {
+			if i == 0 {
+				// The value may have wrapped straight after the name
+				name := strings.TrimSpace(label)
+				b.WriteString(nameStyle.Render(name))
+				line = strings.TrimPrefix(line, name)
+			}
+			b.WriteString(valueStyle.Render(line))
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
+
+func formatHopDelay(d time.Duration) string {
+	if d < time.Second {
+		return "0s"
+	}
+	return d.Round(time.Second).String()
+}
+
+func orUnknown(host string) string {
+	if host == "" {
+		return "?"
+	}
+	return host
+}
@@ -0,0 +1,284 @@
+package tui
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// cleanHTMLForConversion drops style and script elements, turns the quote
+// containers mail clients use into blockquotes, and unwraps layout tables,
+// leaving only data tables for the Markdown table renderer.
+func cleanHTMLForConversion(source string) string {
+	doc, err := html.Parse(strings.NewReader(source))
+	if err != nil {
+		return source
+	}
+	cleanHTMLNode(doc)
+	var b strings.Builder
+	if err := html.Render(&b, doc); err != nil {
+		return source
+	}
+	return b.String()
+}
+
+func cleanHTMLNode(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		switch {
+		case c.Type != html.ElementNode:
+		case c.DataAtom == atom.Style || c.DataAtom == atom.Script:
+			n.RemoveChild(c)
+		case c.DataAtom == atom.Div && htmlAttr(c, "id") == "divRplyFwdMsg":
+			// Outlook heads the previous message with this and leaves the rest
+			// unquoted, so everything from here on is history
+			renameElement(c, atom.Div)
+			quote := quoteRest(n, c)
+			next = quote.NextSibling
+			cleanHTMLNode(quote)
+		case isQuoteContainer(c):
+			renameElement(c, atom.Blockquote)
+			unquoteNested(c)
+			cleanHTMLNode(c)
+		case c.DataAtom == atom.Table && isDataTable(c):
+			cleanHTMLNode(c)
+			flattenTableCells(c)
+		case c.DataAtom == atom.Table:
+			unwrapLayoutTable(c)
+			cleanHTMLNode(c)
+		default:
+			cleanHTMLNode(c)
+		}
+		c = next
+	}
+}
+
+// isQuoteContainer spots the divs Gmail and Yahoo wrap replies in. Gmail
+// also uses gmail_quote for forwards, which have no blockquote and are left
+// alone.
+func isQuoteContainer(n *html.Node) bool {
+	if n.DataAtom != atom.Div {
+		return false
+	}
+	switch {
+	case hasClass(n, "yahoo_quoted"):
+		return true
+	case hasClass(n, "gmail_quote"):
+		return hasDescendant(n, atom.Blockquote)
+	}
+	return false
+}
+
+// unquoteNested turns the blockquote Gmail puts inside its quote container
+// back into a div, so the reply is quoted once rather than twice.
+func unquoteNested(quote *html.Node) {
+	for d := range quote.Descendants() {
+		if d.DataAtom == atom.Blockquote && hasClass(d, "gmail_quote") {
+			renameElement(d, atom.Div)
+			return
+		}
+	}
+}
+
+// quoteRest moves start, any rule just above it, and everything after it
+// into a new blockquote.
+func quoteRest(parent, start *html.Node) *html.Node {
+	prev := start.PrevSibling
+	for prev != nil && prev.Type == html.TextNode && strings.TrimSpace(prev.Data) == "" {
+		prev = prev.PrevSibling
+	}
+	if prev != nil && prev.DataAtom == atom.Hr {
+		start = prev
+	}
+	quote := &html.Node{Type: html.ElementNode, DataAtom: atom.Blockquote, Data: atom.Blockquote.String()}
+	parent.InsertBefore(quote, start)
+	for c := start; c != nil; {
+		next := c.NextSibling
+		parent.RemoveChild(c)
+		quote.AppendChild(c)
+		c = next
+	}
+	return quote
+}
+
+// isDataTable tells tables holding rows of data from the tables marketing
+// mail uses for layout. Layout tables nest, opt out with
+// role="presentation", or have no headers or borders to speak of.
+func isDataTable(table *html.Node) bool {
+	if strings.EqualFold(htmlAttr(table, "role"), "presentation") || hasDescendant(table, atom.Table) {
+		return false
+	}
+	rows := tableRows(table)
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(rowCells(row)))
+	}
+	if len(rows) < 2 || cols < 2 {
+		return false
+	}
+	if hasDescendant(table, atom.Th) || hasDescendant(table, atom.Thead) || hasDescendant(table, atom.Caption) {
+		return true
+	}
+	if border, err := strconv.Atoi(strings.TrimSpace(htmlAttr(table, "border"))); err == nil && border > 0 {
+		return true
+	}
+	// Bordered cells are styled one by one as often as the table is
+	if hasBorderStyle(table) {
+		return true
+	}
+	for _, cell := range rowCells(rows[0]) {
+		if hasBorderStyle(cell) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasBorderStyle reports whether an inline style draws a visible border.
+func hasBorderStyle(n *html.Node) bool {
+	for decl := range strings.SplitSeq(htmlAttr(n, "style"), ";") {
+		prop, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		prop = strings.ToLower(strings.TrimSpace(prop))
+		value = strings.ToLower(strings.TrimSpace(value))
+		if !strings.HasPrefix(prop, "border") ||
+			prop == "border-collapse" || prop == "border-spacing" || strings.HasSuffix(prop, "radius") {
+			continue
+		}
+		if value == "" || value == "0" || value == "none" || strings.HasPrefix(value, "0px") ||
+			strings.Contains(value, "none") || strings.Contains(value, "hidden") {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// unwrapLayoutTable turns a table's own structure into plain blocks: each row
+// becomes a line and its cells run together. Tables nested in cells are left
+// for cleanHTMLNode to judge on their own.
+func unwrapLayoutTable(table *html.Node) {
+	for c := table.FirstChild; c != nil; c = c.NextSibling {
+		switch c.DataAtom {
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			for row := c.FirstChild; row != nil; row = row.NextSibling {
+				unwrapLayoutRow(row)
+			}
+			renameElement(c, atom.Div)
+		case atom.Tr:
+			unwrapLayoutRow(c)
+		case atom.Caption, atom.Colgroup:
+			renameElement(c, atom.Div)
+		}
+	}
+	renameElement(table, atom.Div)
+}
+
+func unwrapLayoutRow(row *html.Node) {
+	if row.DataAtom != atom.Tr {
+		return
+	}
+	for _, cell := range rowCells(row) {
+		renameElement(cell, atom.Span)
+		cell.Parent.InsertBefore(&html.Node{Type: html.TextNode, Data: " "}, cell.NextSibling)
+	}
+	renameElement(row, atom.Div)
+}
+
+// flattenTableCells keeps cell content on one line, since a Markdown table
+// row can't hold line breaks or blocks.
+func flattenTableCells(table *html.Node) {
+	for _, row := range tableRows(table) {
+		for _, cell := range rowCells(row) {
+			flattenInline(cell)
+		}
+	}
+}
+
+func flattenInline(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode {
+			switch c.DataAtom {
+			case atom.Br:
+				n.InsertBefore(&html.Node{Type: html.TextNode, Data: " "}, c)
+				n.RemoveChild(c)
+				c = next
+				continue
+			case atom.Hr:
+				n.RemoveChild(c)
+				c = next
+				continue
+			case atom.P, atom.Div, atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6,
+				atom.Ul, atom.Ol, atom.Li, atom.Blockquote, atom.Pre, atom.Section, atom.Center:
+				renameElement(c, atom.Span)
+				n.InsertBefore(&html.Node{Type: html.TextNode, Data: " "}, next)
+			}
+			flattenInline(c)
+		}
+		c = next
+	}
+}
+
+// tableRows returns the table's own rows, not those of nested tables.
+func tableRows(table *html.Node) []*html.Node {
+	var rows []*html.Node
+	for c := table.FirstChild; c != nil; c = c.NextSibling {
+		switch c.DataAtom {
+		case atom.Tr:
+			rows = append(rows, c)
+		case atom.Thead, atom.Tbody, atom.Tfoot:
+			for row := c.FirstChild; row != nil; row = row.NextSibling {
+				if row.DataAtom == atom.Tr {
+					rows = append(rows, row)
+				}
+			}
+		}
+	}
+	return rows
+}
+
+func rowCells(row *html.Node) []*html.Node {
+	var cells []*html.Node
+	for c := row.FirstChild; c != nil; c = c.NextSibling {
+		if c.DataAtom == atom.Td || c.DataAtom == atom.Th {
+			cells = append(cells, c)
+		}
+	}
+	return cells
+}
+
+func hasDescendant(n *html.Node, a atom.Atom) bool {
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && d.DataAtom == a {
+			return true
+		}
+	}
+	return false
+}
+
+func hasClass(n *html.Node, class string) bool {
+	return slices.Contains(strings.Fields(htmlAttr(n, "class")), class)
+}
+
+func htmlAttr(n *html.Node, name string) string {
+	for _, attr := range n.Attr {
+		if attr.Key == name {
+			return attr.Val
+		}
+	}
+	return ""
+}
+
+// renameElement changes a tag in place, dropping attributes that only meant
+// something on the old one.
+func renameElement(n *html.Node, a atom.Atom) {
+	n.DataAtom = a
+	n.Data = a.String()
+	n.Attr = nil
+}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"cmp"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
@@ -13,7 +14,12 @@ type listKeyMap struct {
 	Down           key.Binding
 	PageUp         key.Binding
 	PageDown       key.Binding
+	GoTop          key.Binding
+	GoBottom       key.Binding
+	GoInbox        key.Binding
+	GoArchive      key.Binding
 	Open           key.Binding
+	FocusPane      key.Binding
 	ToggleRead     key.Binding
 	ToggleSelect   key.Binding
 	ClearSelection key.Binding
@@ -23,6 +29,9 @@ type listKeyMap struct {
 	Undo           key.Binding
 	Search         key.Binding
 	Refresh        key.Binding
+	FailedActions  key.Binding
+	Palette        key.Binding
+	Command        key.Binding
 	Help           key.Binding
 	Quit           key.Binding
 }
@@ -30,9 +39,24 @@ type listKeyMap struct {
 type detailKeyMap struct {
 	Up           key.Binding
 	Down         key.Binding
+	GoTop        key.Binding
+	GoBottom     key.Binding
+	GoInbox      key.Binding
+	GoArchive    key.Binding
 	ToggleExpand key.Binding
 	ToggleView   key.Binding
+	ToggleQuotes key.Binding
+	AllHeaders   key.Binding
 	Attachments  key.Binding
+	InlineImage  key.Binding
+	Gallery      key.Binding
+	SaveAll      key.Binding
+	MimeTree     key.Binding
+	Unsubscribe  key.Binding
+	Links        key.Binding
+	FocusPane    key.Binding
+	Palette      key.Binding
+	Command      key.Binding
 	Back         key.Binding
 	Help         key.Binding
 	Quit         key.Binding
@@ -50,19 +74,38 @@ type attachmentKeyMap struct {
 }
 
 type imageKeyMap struct {
-	Back key.Binding
-	Quit key.Binding
+	Back     key.Binding
+	Quit     key.Binding
+	Pause    key.Binding
+	Step     key.Binding
+	StepBack key.Binding
+	Next     key.Binding
+	Prev     key.Binding
+	Save     key.Binding
+	SaveAll  key.Binding
 }
 
 type attachmentsModalKeyMap struct {
 	Up       key.Binding
 	Down     key.Binding
 	Download key.Binding
+	SaveAs   key.Binding
+	OpenWith key.Binding
 	View     key.Binding
 	Close    key.Binding
 }
 
+type linkPickerKeyMap struct {
+	Up             key.Binding
+	Down           key.Binding
+	Open           key.Binding
+	Copy           key.Binding
+	ToggleOriginal key.Binding
+	Close          key.Binding
+}
+
 type keyMap struct {
+	leader                 string
 	view                   viewState
 	searchActive           bool
 	attachmentsModalActive bool
@@ -73,10 +116,12 @@ type keyMap struct {
 	attachment           attachmentKeyMap
 	image                imageKeyMap
 	attachmentsModalKeys attachmentsModalKeyMap
+	linkPicker           linkPickerKeyMap
 }
 
 func keyMapFromConfig(cfg config.KeyMap) keyMap {
 	return keyMap{
+		leader: cmp.Or(cfg.Leader, defaultLeader),
 		list: listKeyMap{
 			Up: makeBinding(bindingDef{keys: []string{"k", "up"}, desc: "up"}, cfg.List.Up),
 			Down: makeBinding(
@@ -91,10 +136,30 @@ func keyMapFromConfig(cfg config.KeyMap) keyMap {
 				bindingDef{keys: []string{"pgdown"}, desc: "page down"},
 				cfg.List.PageDown,
 			),
+			GoTop: makeBinding(
+				bindingDef{keys: []string{"g g", "home"}, desc: "top"},
+				cfg.List.GoTop,
+			),
+			GoBottom: makeBinding(
+				bindingDef{keys: []string{"G", "end"}, desc: "bottom"},
+				cfg.List.GoBottom,
+			),
+			GoInbox: makeBinding(
+				bindingDef{keys: []string{"g i"}, desc: "go to inbox"},
+				cfg.List.GoInbox,
+			),
+			GoArchive: makeBinding(
+				bindingDef{keys: []string{"g a"}, desc: "go to archive"},
+				cfg.List.GoArchive,
+			),
 			Open: makeBinding(
 				bindingDef{keys: []string{"enter"}, desc: "open"},
 				cfg.List.Open,
 			),
+			FocusPane: makeBinding(
+				bindingDef{keys: []string{"tab"}, desc: "focus preview"},
+				cfg.List.FocusPane,
+			),
 			ToggleRead: makeBinding(
 				bindingDef{keys: []string{" ", "space"}, desc: "read/unread"},
 				cfg.List.ToggleRead,
@@ -131,6 +196,18 @@ func keyMapFromConfig(cfg config.KeyMap) keyMap {
 				bindingDef{keys: []string{"r"}, desc: "refresh"},
 				cfg.List.Refresh,
 			),
+			FailedActions: makeBinding(
+				bindingDef{keys: []string{"!"}, desc: "failed actions"},
+				cfg.List.FailedActions,
+			),
+			Palette: makeBinding(
+				bindingDef{keys: []string{"ctrl+p"}, desc: "commands"},
+				cfg.List.Palette,
+			),
+			Command: makeBinding(
+				bindingDef{keys: []string{":"}, desc: "command line"},
+				cfg.List.Command,
+			),
 			Help: makeBinding(bindingDef{keys: []string{"?"}, desc: "help"}, cfg.List.Help),
 			Quit: makeBinding(
 				bindingDef{keys: []string{"q", "esc", "ctrl+c"}, desc: "quit"},
@@ -146,6 +223,22 @@ func keyMapFromConfig(cfg config.KeyMap) keyMap {
 				bindingDef{keys: []string{"j", "down"}, desc: "next"},
 				cfg.Detail.Down,
 			),
+			GoTop: makeBinding(
+				bindingDef{keys: []string{"g g", "home"}, desc: "top"},
+				cfg.Detail.GoTop,
+			),
+			GoBottom: makeBinding(
+				bindingDef{keys: []string{"G", "end"}, desc: "bottom"},
+				cfg.Detail.GoBottom,
+			),
+			GoInbox: makeBinding(
+				bindingDef{keys: []string{"g i"}, desc: "go to inbox"},
+				cfg.Detail.GoInbox,
+			),
+			GoArchive: makeBinding(
+				bindingDef{keys: []string{"g a"}, desc: "go to archive"},
+				cfg.Detail.GoArchive,
+			),
 			ToggleExpand: makeBinding(
 				bindingDef{keys: []string{"enter", " ", "space"}, desc: "expand"},
 				cfg.Detail.ToggleExpand,
@@ -154,10 +247,54 @@ func keyMapFromConfig(cfg config.KeyMap) keyMap {
 				bindingDef{keys: []string{"t"}, desc: "toggle view"},
 				cfg.Detail.ToggleView,
 			),
+			ToggleQuotes: makeBinding(
+				bindingDef{keys: []string{"z"}, desc: "toggle quotes"},
+				cfg.Detail.ToggleQuotes,
+			),
+			AllHeaders: makeBinding(
+				bindingDef{keys: []string{"H"}, desc: "all headers"},
+				cfg.Detail.AllHeaders,
+			),
 			Attachments: makeBinding(
 				bindingDef{keys: []string{"a"}, desc: "attachments"},
 				cfg.Detail.Attachments,
 			),
+			InlineImage: makeBinding(
+				bindingDef{keys: []string{"i"}, desc: "inline image"},
+				cfg.Detail.InlineImage,
+			),
+			Gallery: makeBinding(
+				bindingDef{keys: []string{"I"}, desc: "image gallery"},
+				cfg.Detail.Gallery,
+			),
+			SaveAll: makeBinding(
+				bindingDef{keys: []string{"A"}, desc: "save all attachments"},
+				cfg.Detail.SaveAll,
+			),
+			MimeTree: makeBinding(
+				bindingDef{keys: []string{"M"}, desc: "mime structure"},
+				cfg.Detail.MimeTree,
+			),
+			Unsubscribe: makeBinding(
+				bindingDef{keys: []string{"U"}, desc: "unsubscribe"},
+				cfg.Detail.Unsubscribe,
+			),
+			Links: makeBinding(
+				bindingDef{keys: []string{"L"}, desc: "links"},
+				cfg.Detail.Links,
+			),
+			FocusPane: makeBinding(
+				bindingDef{keys: []string{"tab"}, desc: "focus list"},
+				cfg.Detail.FocusPane,
+			),
+			Palette: makeBinding(
+				bindingDef{keys: []string{"ctrl+p"}, desc: "commands"},
+				cfg.Detail.Palette,
+			),
+			Command: makeBinding(
+				bindingDef{keys: []string{":"}, desc: "command line"},
+				cfg.Detail.Command,
+			),
 			Back: makeBinding(
 				bindingDef{keys: []string{"esc", "q"}, desc: "back"},
 				cfg.Detail.Back,
@@ -198,6 +335,25 @@ func keyMapFromConfig(cfg config.KeyMap) keyMap {
 		image: imageKeyMap{
 			Back: makeBinding(bindingDef{keys: []string{"esc", "q"}, desc: "back"}, cfg.Image.Back),
 			Quit: makeBinding(bindingDef{keys: []string{"ctrl+c"}, desc: "quit"}, cfg.Image.Quit),
+			Pause: makeBinding(
+				bindingDef{keys: []string{" ", "space"}, desc: "pause/play"},
+				cfg.Image.Pause,
+			),
+			Step: makeBinding(bindingDef{keys: []string{"."}, desc: "next frame"}, cfg.Image.Step),
+			StepBack: makeBinding(
+				bindingDef{keys: []string{","}, desc: "previous frame"},
+				cfg.Image.StepBack,
+			),
+			Next: makeBinding(bindingDef{keys: []string{"l", "right"}, desc: "next image"}, cfg.Image.Next),
+			Prev: makeBinding(
+				bindingDef{keys: []string{"h", "left"}, desc: "previous image"},
+				cfg.Image.Prev,
+			),
+			Save: makeBinding(bindingDef{keys: []string{"s"}, desc: "save image"}, cfg.Image.Save),
+			SaveAll: makeBinding(
+				bindingDef{keys: []string{"S"}, desc: "save all images"},
+				cfg.Image.SaveAll,
+			),
 		},
 		attachmentsModalKeys: attachmentsModalKeyMap{
 			Up: makeBinding(
@@ -212,6 +368,14 @@ func keyMapFromConfig(cfg config.KeyMap) keyMap {
 				bindingDef{keys: []string{"enter", "d"}, desc: "download"},
 				cfg.AttachmentsModal.Download,
 			),
+			SaveAs: makeBinding(
+				bindingDef{keys: []string{"s"}, desc: "save as"},
+				cfg.AttachmentsModal.SaveAs,
+			),
+			OpenWith: makeBinding(
+				bindingDef{keys: []string{"o"}, desc: "open with"},
+				cfg.AttachmentsModal.OpenWith,
+			),
 			View: makeBinding(
 				bindingDef{keys: []string{"v"}, desc: "view"},
 				cfg.AttachmentsModal.View,
@@ -221,6 +385,26 @@ func keyMapFromConfig(cfg config.KeyMap) keyMap {
 				cfg.AttachmentsModal.Close,
 			),
 		},
+		linkPicker: linkPickerKeyMap{
+			Up: makeBinding(bindingDef{keys: []string{"k", "up"}, desc: "up"}, cfg.LinkPicker.Up),
+			Down: makeBinding(
+				bindingDef{keys: []string{"j", "down"}, desc: "down"},
+				cfg.LinkPicker.Down,
+			),
+			Open: makeBinding(
+				bindingDef{keys: []string{"enter", "o"}, desc: "open"},
+				cfg.LinkPicker.Open,
+			),
+			Copy: makeBinding(bindingDef{keys: []string{"y"}, desc: "copy"}, cfg.LinkPicker.Copy),
+			ToggleOriginal: makeBinding(
+				bindingDef{keys: []string{"tab"}, desc: "resolved/original"},
+				cfg.LinkPicker.ToggleOriginal,
+			),
+			Close: makeBinding(
+				bindingDef{keys: []string{"esc", "q"}, desc: "close"},
+				cfg.LinkPicker.Close,
+			),
+		},
 	}
 }
 
@@ -232,6 +416,9 @@ func (m Model) keyMap() keyMap {
 	return km
 }
 
+// defaultLeader is the key <leader> stands for when none is configured
+const defaultLeader = "\\"
+
 type bindingDef struct {
 	keys []string
 	desc string
@@ -269,6 +456,13 @@ func formatHelpKeys(keys []string) string {
 }
 
 func formatKeyLabel(key string) string {
+	if seq := strings.Fields(key); len(seq) > 1 {
+		var b strings.Builder
+		for _, k := range seq {
+			b.WriteString(formatKeyLabel(k))
+		}
+		return b.String()
+	}
 	switch key {
 	case "up":
 		return "↑"
@@ -295,6 +489,8 @@ func (k keyMap) ShortHelp() []key.Binding {
 			k.attachmentsModalKeys.Up,
 			k.attachmentsModalKeys.Down,
 			k.attachmentsModalKeys.Download,
+			k.attachmentsModalKeys.SaveAs,
+			k.attachmentsModalKeys.OpenWith,
 			k.attachmentsModalKeys.View,
 			k.attachmentsModalKeys.Close,
 		}
@@ -345,7 +541,8 @@ func (k keyMap) FullHelp() [][]key.Binding {
 	if k.attachmentsModalActive {
 		return [][]key.Binding{
 			{k.attachmentsModalKeys.Up, k.attachmentsModalKeys.Down},
-			{k.attachmentsModalKeys.Download, k.attachmentsModalKeys.View},
+			{k.attachmentsModalKeys.Download, k.attachmentsModalKeys.SaveAs},
+			{k.attachmentsModalKeys.OpenWith, k.attachmentsModalKeys.View},
 			{k.attachmentsModalKeys.Close},
 		}
 	}
@@ -358,8 +555,11 @@ func (k keyMap) FullHelp() [][]key.Binding {
 	switch k.view {
 	case viewDetail:
 		return [][]key.Binding{
-			{k.detail.Up, k.detail.Down},
-			{k.detail.ToggleExpand, k.detail.ToggleView, k.detail.Attachments},
+			{k.detail.Up, k.detail.Down, k.detail.GoTop, k.detail.GoBottom},
+			{k.detail.GoInbox, k.detail.GoArchive, k.detail.Unsubscribe},
+			{k.detail.ToggleExpand, k.detail.ToggleView, k.detail.ToggleQuotes, k.detail.AllHeaders, k.detail.Links, k.detail.FocusPane},
+			{k.detail.Attachments, k.detail.SaveAll, k.detail.InlineImage, k.detail.Gallery, k.detail.MimeTree},
+			{k.detail.Palette, k.detail.Command},
 			{k.detail.Back, k.detail.Help, k.detail.Quit},
 		}
 	case viewAttachment:
@@ -368,22 +568,28 @@ func (k keyMap) FullHelp() [][]key.Binding {
 		}
 	case viewImage:
 		return [][]key.Binding{
+			{k.image.Pause, k.image.Step, k.image.StepBack},
+			{k.image.Next, k.image.Prev, k.image.Save, k.image.SaveAll},
 			{k.image.Back, k.image.Quit},
 		}
 	case viewList:
 		return [][]key.Binding{
 			{k.list.Up, k.list.Down, k.list.PageUp, k.list.PageDown},
+			{k.list.GoTop, k.list.GoBottom, k.list.GoInbox, k.list.GoArchive},
 			{k.list.Open, k.list.ToggleRead, k.list.ToggleSelect, k.list.ClearSelection},
 			{k.list.Archive, k.list.Delete, k.list.DeleteForever, k.list.Undo},
-			{k.list.Search, k.list.Refresh},
+			{k.list.Search, k.list.Refresh, k.list.FailedActions},
+			{k.list.FocusPane, k.list.Palette, k.list.Command},
 			{k.list.Help, k.list.Quit},
 		}
 	default:
 		return [][]key.Binding{
 			{k.list.Up, k.list.Down, k.list.PageUp, k.list.PageDown},
+			{k.list.GoTop, k.list.GoBottom, k.list.GoInbox, k.list.GoArchive},
 			{k.list.Open, k.list.ToggleRead, k.list.ToggleSelect, k.list.ClearSelection},
 			{k.list.Archive, k.list.Delete, k.list.DeleteForever, k.list.Undo},
-			{k.list.Search, k.list.Refresh},
+			{k.list.Search, k.list.Refresh, k.list.FailedActions},
+			{k.list.FocusPane, k.list.Palette, k.list.Command},
 			{k.list.Help, k.list.Quit},
 		}
 	}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.withmatt.com/inbox/internal/config"
+	"go.withmatt.com/inbox/internal/gmail"
+)
+
+type labelNamesLoadedMsg struct {
+	accountIndex int
+	names        map[string]string // label ID -> name, user labels only
+	err          error
+}
+
+// loadLabelNamesCmd fetches user label names for the list's labels column.
+func (m *Model) loadLabelNamesCmd() tea.Cmd {
+	if !m.hasListColumn(config.ColumnLabels) {
+		return nil
+	}
+	cmds := make([]tea.Cmd, 0, len(m.clients))
+	for i, client := range m.clients {
+		ctx := m.ctx
+		cmds = append(cmds, func() tea.Msg {
+			labels, err := client.GetLabels(ctx)
+			if err != nil {
+				return labelNamesLoadedMsg{accountIndex: i, err: err}
+			}
+			names := make(map[string]string, len(labels))
+			for _, label := range labels {
+				if label.Type == "user" {
+					names[label.ID] = label.Name
+				}
+			}
+			return labelNamesLoadedMsg{accountIndex: i, names: names}
+		})
+	}
+	return tea.Batch(cmds...)
+}
+
+func (m Model) handleLabelNamesLoaded(msg labelNamesLoadedMsg) Model {
+	if msg.err != nil {
+		m.logf("Label names load failed account=%d: %v", msg.accountIndex, msg.err)
+		return m
+	}
+	m.labelNames[msg.accountIndex] = msg.names
+	return m
+}
+
+// labelIDIndex maps label IDs and lowercased names to label IDs.
+func labelIDIndex(labels []gmail.Label) map[string]string {
+	ids := make(map[string]string, len(labels)*2)
+	for _, label := range labels {
+		ids[label.ID] = label.ID
+		ids[strings.ToLower(label.Name)] = label.ID
+	}
+	return ids
+}
+
+// labelIDsFor loads the label index for an account, caching it in cache.
+func (m *Model) labelIDsFor(
+	accountIndex int,
+	cache map[int]map[string]string,
+) (map[string]string, error) {
+	if ids, ok := cache[accountIndex]; ok {
+		return ids, nil
+	}
+	labels, err := m.clients[accountIndex].GetLabels(m.ctx)
+	if err != nil {
+		return nil, err
+	}
+	ids := labelIDIndex(labels)
+	cache[accountIndex] = ids
+	return ids, nil
+}
+
+func resolveLabelIDs(names []string, ids map[string]string) ([]string, error) {
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		id, ok := ids[name]
+		if !ok {
+			id, ok = ids[strings.ToLower(name)]
+		}
+		if !ok {
+			return nil, fmt.Errorf("label %q not found", name)
+		}
+		out = append(out, id)
+	}
+	return out, nil
+}
+
+// parseLabelChanges splits `+work -old todo` into labels to add and remove.
+func parseLabelChanges(args []string) ([]string, []string, error) {
+	var add, remove []string
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "+"):
+			add = append(add, strings.TrimPrefix(arg, "+"))
+		case strings.HasPrefix(arg, "-"):
+			remove = append(remove, strings.TrimPrefix(arg, "-"))
+		default:
+			add = append(add, arg)
+		}
+	}
+	for _, label := range append(append([]string(nil), add...), remove...) {
+		if label == "" {
+			return nil, nil, fmt.Errorf("empty label name")
+		}
+	}
+	if len(add) == 0 && len(remove) == 0 {
+		return nil, nil, fmt.Errorf("usage: label +name -name")
+	}
+	return add, remove, nil
+}
+
+func containsLabel(labels []string, want string) bool {
+	for _, label := range labels {
+		if strings.EqualFold(label, want) {
+			return true
+		}
+	}
+	return false
+}
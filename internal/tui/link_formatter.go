@@ -6,8 +6,13 @@ import (
 	"unicode"
 
 	"github.com/charmbracelet/glamour/ansi"
+
+	"go.withmatt.com/inbox/internal/links"
 )
 
+// suspiciousLinkGlyph marks links that may not go where they appear to.
+const suspiciousLinkGlyph = "⚠ "
+
 const (
 	linkSpaceSentinel     = '\ue000'
 	linkCommaSentinel     = '\ue001'
@@ -21,6 +26,9 @@ const (
 func smartLinkFormatter() ansi.LinkFormatter {
 	return ansi.LinkFormatterFunc(func(data ansi.LinkData, ctx ansi.RenderContext) (string, error) {
 		data.URL = sanitizeLinkURL(data.URL)
+		if links.Suspicious(data.Text, data.URL) != "" {
+			data.Text = suspiciousLinkGlyph + data.Text
+		}
 		if supportsOSC8() {
 			data.Text = linkTextSentinelize(data.Text)
 			return ansi.HyperlinkFormatter.FormatLink(data, ctx)
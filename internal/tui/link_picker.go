@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/browser"
+
+	"go.withmatt.com/inbox/internal/links"
+)
+
+const linkPickerWidth = 80
+
+// markdownLinkRe matches [text](url) links in converted HTML bodies
+var markdownLinkRe = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)`)
+
+// markdownEscapeRe matches the backslash escapes the converter adds to text
+var markdownEscapeRe = regexp.MustCompile(`\\(.)`)
+
+// pickerLink is a link in the picker, with why it looks suspicious if it
+// does.
+type pickerLink struct {
+	links.Link
+	warning string
+}
+
+// clipboardHold is how long a clipboard sequence stays in the view. The
+// renderer draws at most every 1/60s, so this is enough for one frame to
+// carry it, and short enough that later redraws don't copy it again.
+const clipboardHold = 50 * time.Millisecond
+
+type clipboardSentMsg struct {
+	seq string
+}
+
+type linkOpenedMsg struct {
+	url string
+	err error
+}
+
+// showLinkPicker lists every link in the selected message, unwrapped where
+// the resolver knows where they lead.
+func (m Model) showLinkPicker() (Model, tea.Cmd) {
+	if m.detail.currentThread == nil ||
+		m.detail.selectedMessageIdx < 0 || m.detail.selectedMessageIdx >= len(m.detail.messages) {
+		return m, nil
+	}
+	msg := m.detail.messages[m.detail.selectedMessageIdx]
+	body := m.messageBodyForScan(msg)
+	found := m.linkResolver.Links(body)
+	if len(found) == 0 {
+		return m, m.toastCmd("No links in this message")
+	}
+	texts := markdownLinkTexts(body)
+	picked := make([]pickerLink, 0, len(found))
+	for _, link := range found {
+		// Check where the link really ends up, against the text it was shown as
+		picked = append(picked, pickerLink{
+			Link:    link,
+			warning: links.Suspicious(texts[link.Original], link.Resolved),
+		})
+	}
+	m.logf("Link picker message=%s links=%d", msg.ID, len(found))
+	m.linkPicker = linkPickerState{show: true, links: picked}
+	return m, m.setWindowTitleCmd()
+}
+
+// markdownLinkTexts maps each linked URL in a markdown body to the text it
+// is first shown as.
+func markdownLinkTexts(body string) map[string]string {
+	texts := make(map[string]string)
+	for _, match := range markdownLinkRe.FindAllStringSubmatch(body, -1) {
+		if _, ok := texts[match[2]]; !ok {
+			texts[match[2]] = markdownEscapeRe.ReplaceAllString(match[1], "$1")
+		}
+	}
+	return texts
+}
+
+func (m *Model) closeLinkPicker() {
+	m.linkPicker = linkPickerState{}
+}
+
+// selectedLink returns the selected link as it's currently listed.
+func (m *Model) selectedLink() (string, bool) {
+	state := m.linkPicker
+	if state.selectedIdx < 0 || state.selectedIdx >= len(state.links) {
+		return "", false
+	}
+	link := state.links[state.selectedIdx]
+	if state.showOriginal {
+		return link.Original, true
+	}
+	return link.Resolved, true
+}
+
+func (m Model) handleLinkPickerKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	km := m.keyMap()
+	state := &m.linkPicker
+	// Digits pick a link by number, and a second digit can make it 12
+	if r := msg.Runes; msg.Type == tea.KeyRunes && len(r) == 1 && r[0] >= '0' && r[0] <= '9' {
+		number := state.number + string(r)
+		n, _ := strconv.Atoi(number)
+		if n < 1 || n > len(state.links) {
+			number = string(r)
+			n, _ = strconv.Atoi(number)
+		}
+		state.number = number
+		if n >= 1 && n <= len(state.links) {
+			state.selectedIdx = n - 1
+		}
+		return m, nil
+	}
+	state.number = ""
+
+	switch {
+	case key.Matches(msg, km.linkPicker.Close):
+		m.closeLinkPicker()
+		return m, m.setWindowTitleCmd()
+	case key.Matches(msg, km.linkPicker.Down):
+		if state.selectedIdx < len(state.links)-1 {
+			state.selectedIdx++
+		}
+		return m, nil
+	case key.Matches(msg, km.linkPicker.Up):
+		if state.selectedIdx > 0 {
+			state.selectedIdx--
+		}
+		return m, nil
+	case key.Matches(msg, km.linkPicker.Open):
+		link, ok := m.selectedLink()
+		if !ok {
+			return m, nil
+		}
+		m.closeLinkPicker()
+		return m, tea.Batch(openLinkCmd(link), m.setWindowTitleCmd())
+	case key.Matches(msg, km.linkPicker.Copy):
+		link, ok := m.selectedLink()
+		if !ok {
+			return m, nil
+		}
+		return m, tea.Batch(m.copyToClipboard(link), m.toastCmd("Copied link"))
+	case key.Matches(msg, km.linkPicker.ToggleOriginal):
+		state.showOriginal = !state.showOriginal
+		return m, nil
+	}
+	return m, nil
+}
+
+func openLinkCmd(link string) tea.Cmd {
+	return func() tea.Msg {
+		return linkOpenedMsg{url: link, err: browser.OpenURL(link)}
+	}
+}
+
+// copyToClipboard has the next frame carry the sequence that sets the
+// clipboard, so it reaches the terminal through the renderer rather than
+// racing it.
+func (m *Model) copyToClipboard(text string) tea.Cmd {
+	seq := clipboardSequence(text)
+	m.ui.clipboard = seq
+	return tea.Tick(clipboardHold, func(time.Time) tea.Msg {
+		return clipboardSentMsg{seq: seq}
+	})
+}
+
+func (m Model) handleClipboardSent(msg clipboardSentMsg) (tea.Model, tea.Cmd) {
+	// A newer copy replaced it and has its own tick
+	if m.ui.clipboard == msg.seq {
+		m.ui.clipboard = ""
+	}
+	return m, nil
+}
+
+// clipboardSequence asks the terminal to set the clipboard with an OSC 52
+// sequence, which works over SSH too. Inside tmux the sequence has to be
+// passed through to the outer terminal.
+func clipboardSequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+func (m Model) handleLinkOpened(msg linkOpenedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.ui.err = fmt.Errorf("failed to open link: %w", msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	return m, m.toastCmd("Opened " + linkHost(msg.url))
+}
+
+func linkHost(link string) string {
+	if u, err := url.Parse(link); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return link
+}
+
+// splitLinkHost splits a URL around its host, so the part that says where
+// a link really goes can be picked out.
+func splitLinkHost(link string) (string, string, string) {
+	host := linkHost(link)
+	i := strings.Index(link, host)
+	if host == link || i < 0 {
+		return "", link, ""
+	}
+	return link[:i], host, link[i+len(host):]
+}
+
+func (m *Model) renderLinkPickerModal() string {
+	var b strings.Builder
+	state := m.linkPicker
+
+	titleStyle := lipgloss.NewStyle().
+		Width(linkPickerWidth).
+		Align(lipgloss.Center).
+		Bold(true)
+	title := fmt.Sprintf("Links (%d)", len(state.links))
+	if state.showOriginal {
+		title = fmt.Sprintf("Links as written (%d)", len(state.links))
+	}
+	b.WriteString(titleStyle.Render(title))
+	b.WriteString("\n\n")
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Detail.HeaderLabelFg))
+	hostStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Detail.LinkFg)).Bold(true)
+	warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Detail.AuthFailFg)).Bold(true)
+	numberWidth := len(strconv.Itoa(len(state.links)))
+
+	// Border, padding, title, details and footer take the rest of the screen
+	rows := max(3, m.ui.height-12)
+	start := 0
+	if state.selectedIdx >= rows {
+		start = state.selectedIdx - rows + 1
+	}
+	end := min(len(state.links), start+rows)
+	for i := start; i < end; i++ {
+		link := state.links[i].Resolved
+		if state.showOriginal {
+			link = state.links[i].Original
+		}
+		prefix := "    "
+		if i == state.selectedIdx {
+			prefix = "  > "
+		}
+		prefix += fmt.Sprintf("%*d ", numberWidth, i+1)
+		warning := " "
+		if state.links[i].warning != "" {
+			warning = warningStyle.Render("⚠")
+		}
+		prefix += warning + " "
+		before, host, after := splitLinkHost(link)
+		avail := linkPickerWidth - lipgloss.Width(prefix)
+		before = truncateToWidth(before, avail)
+		host = truncateToWidth(host, max(avail-lipgloss.Width(before), 0))
+		after = truncateToWidth(after, max(avail-lipgloss.Width(before)-lipgloss.Width(host), 0))
+		b.WriteString(prefix + dimStyle.Render(before) + hostStyle.Render(host) + dimStyle.Render(after))
+		b.WriteString("\n")
+	}
+
+	// Show why the selected link is suspicious, and the other side of it
+	// when it was unwrapped
+	if state.selectedIdx < len(state.links) {
+		link := state.links[state.selectedIdx]
+		var details []string
+		if link.warning != "" {
+			details = append(details, warningStyle.Render(truncateToWidth("⚠ "+link.warning, linkPickerWidth)))
+		}
+		if link.Original != link.Resolved {
+			other := "from " + link.Original
+			if state.showOriginal {
+				other = "to " + link.Resolved
+			}
+			details = append(details, dimStyle.Render(truncateToWidth(other, linkPickerWidth)))
+		}
+		if len(details) > 0 {
+			b.WriteString("\n")
+			b.WriteString(strings.Join(details, "\n"))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().
+		Width(linkPickerWidth).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+	km := m.keyMap().linkPicker
+	footer := fmt.Sprintf(
+		"%s %s or number move • %s open • %s copy • %s resolved/original • %s close",
+		km.Down.Help().Key, km.Up.Help().Key, km.Open.Help().Key,
+		km.Copy.Help().Key, km.ToggleOriginal.Help().Key, km.Close.Help().Key,
+	)
+	b.WriteString(footerStyle.Render(truncateToWidth(footer, linkPickerWidth)))
+
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.withmatt.com/inbox/internal/config"
+)
+
+func TestClipboardSentOnce(t *testing.T) {
+	theme, err := config.ResolveTheme(config.Theme{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(
+		context.Background(), nil, nil, nil, theme, config.UIConfig{}.WithDefaults(),
+		config.KeyMap{}, nil, false, config.AttachmentConfig{}, nil,
+	)
+	first := m.copyToClipboard("https://a.example/")
+	firstSeq := m.ui.clipboard
+	if !strings.Contains(m.View(), firstSeq) {
+		t.Fatal("view doesn't carry the clipboard sequence")
+	}
+	m.copyToClipboard("https://b.example/")
+
+	// The first copy's tick mustn't drop the second copy
+	model, _ := m.handleClipboardSent(first().(clipboardSentMsg))
+	m = model.(Model)
+	if m.ui.clipboard == "" || m.ui.clipboard == firstSeq {
+		t.Fatalf("clipboard = %q after the first copy's tick", m.ui.clipboard)
+	}
+
+	model, _ = m.handleClipboardSent(clipboardSentMsg{seq: m.ui.clipboard})
+	m = model.(Model)
+	if m.ui.clipboard != "" {
+		t.Errorf("clipboard = %q after its frame was sent", m.ui.clipboard)
+	}
+}
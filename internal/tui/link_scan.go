@@ -10,7 +10,13 @@ import (
 )
 
 func (m *Model) scanMessageLinksCmd(msg gmail.Message) tea.Cmd {
-	if m.detail.linkScanAttempted[msg.ID] {
+	mode := links.ScanModeKnown
+	if m.linkAutoScan {
+		mode = links.ScanModeLearn
+	}
+	// Without unwrap domains or auto-scan there's nothing to resolve, so
+	// don't convert the body just to look
+	if !m.linkResolver.CanScan(mode) || m.detail.linkScanAttempted[msg.ID] {
 		return nil
 	}
 	m.detail.linkScanAttempted[msg.ID] = true
@@ -20,10 +26,6 @@ func (m *Model) scanMessageLinksCmd(msg gmail.Message) tea.Cmd {
 		if body == "" {
 			return nil
 		}
-		mode := links.ScanModeKnown
-		if m.linkAutoScan {
-			mode = links.ScanModeLearn
-		}
 		m.linkResolver.ScanText(m.ctx, body, mode)
 		return linkScanFinishedMsg{messageID: msg.ID}
 	}
@@ -0,0 +1,254 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"go.withmatt.com/inbox/internal/config"
+	"go.withmatt.com/inbox/internal/gmail"
+)
+
+const (
+	// compactSenderWidth is the sender column width in compact rows
+	compactSenderWidth = 20
+	// compactCountWidth fits "(99)"
+	compactCountWidth = 4
+	// labelsMaxWidth caps the labels column
+	labelsMaxWidth = 24
+	// attachmentIndicator marks threads with attachments
+	attachmentIndicator = "📎"
+)
+
+// resolveListConfig drops unknown columns and loads the time zone, returning
+// anything worth telling the user about.
+func resolveListConfig(cfg *config.ListConfig) (*time.Location, []string) {
+	var problems []string
+	columns := make([]string, 0, len(cfg.Columns))
+	for _, column := range cfg.Columns {
+		if !config.IsListColumn(column) {
+			problems = append(problems, fmt.Sprintf("ui.list: unknown column %q", column))
+			continue
+		}
+		columns = append(columns, column)
+	}
+	if len(columns) == 0 {
+		columns = config.DefaultListColumns
+	}
+	cfg.Columns = columns
+
+	loc := time.Local
+	if cfg.TimeZone != "" {
+		zone, err := time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("ui.list: time_zone: %v", err))
+		} else {
+			loc = zone
+		}
+	}
+	return loc, problems
+}
+
+func (m *Model) hasListColumn(name string) bool {
+	return slices.Contains(m.uiConfig.List.Columns, name)
+}
+
+func (m *Model) listCompact() bool {
+	return m.uiConfig.List.Density == config.DensityCompact
+}
+
+func (m *Model) listSnippetLines() int {
+	if !m.hasListColumn(config.ColumnSnippet) {
+		return 0
+	}
+	return max(1, m.uiConfig.ListSnippetLines)
+}
+
+// listCardLines is the number of content lines in one row.
+func (m *Model) listCardLines() int {
+	if m.listCompact() {
+		return 1
+	}
+	lines := 1
+	if m.hasListColumn(config.ColumnSubject) {
+		lines++
+	}
+	return lines + m.listSnippetLines()
+}
+
+// listRowHeight includes the blank line that separates cards.
+func (m *Model) listRowHeight() int {
+	if m.listCompact() {
+		return 1
+	}
+	return m.listCardLines() + 1
+}
+
+// formatListDate renders a thread date using the configured format and zone.
+func (m *Model) formatListDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	t = t.In(m.location)
+	switch m.uiConfig.List.DateFormat {
+	case config.DateRelative:
+		return formatRelativeTime(t)
+	case config.DateAbsolute:
+		now := time.Now().In(m.location)
+		switch {
+		case sameDay(t, now):
+			return t.Format("15:04")
+		case t.Year() == now.Year():
+			return t.Format("Jan 2 15:04")
+		default:
+			return t.Format("2006-01-02")
+		}
+	default:
+		return t.Format(m.uiConfig.List.DateFormat)
+	}
+}
+
+// listDateWidth is the widest date the current format produces, so compact
+// rows line up.
+func (m *Model) listDateWidth() int {
+	switch m.uiConfig.List.DateFormat {
+	case config.DateRelative:
+		return lipgloss.Width("just now")
+	case config.DateAbsolute:
+		// "Jan 2 15:04" is the widest of the absolute layouts
+		return lipgloss.Width("Sep 28 23:59")
+	default:
+		sample := time.Date(2006, time.September, 28, 23, 59, 59, 0, m.location)
+		return lipgloss.Width(sample.Format(m.uiConfig.List.DateFormat))
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// dateGroup buckets a date for the group headers.
+func (m *Model) dateGroup(t time.Time) string {
+	now := time.Now().In(m.location)
+	t = t.In(m.location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, m.location)
+	switch {
+	case !t.Before(today):
+		return "Today"
+	case !t.Before(today.AddDate(0, 0, -1)):
+		return "Yesterday"
+	case !t.Before(today.AddDate(0, 0, -6)):
+		return "This week"
+	default:
+		return "Older"
+	}
+}
+
+// groupHeaderAt returns the header to draw above the row at displayIdx, if
+// any. The first visible row always gets one so the group stays in view.
+func (m *Model) groupHeaderAt(displayIdx, start int) string {
+	if !m.uiConfig.List.GroupByDate {
+		return ""
+	}
+	idx := m.threadIndexAt(displayIdx)
+	if idx < 0 || idx >= len(m.inbox.threads) || !m.inbox.threads[idx].Loaded {
+		return ""
+	}
+	group := m.dateGroup(m.inbox.threads[idx].Date)
+	if displayIdx == start {
+		return group
+	}
+	prev := m.threadIndexAt(displayIdx - 1)
+	if prev < 0 || prev >= len(m.inbox.threads) || !m.inbox.threads[prev].Loaded {
+		return group
+	}
+	if m.dateGroup(m.inbox.threads[prev].Date) == group {
+		return ""
+	}
+	return group
+}
+
+// rowSpan is the height of a row plus its group header.
+func (m *Model) rowSpan(displayIdx, start int) int {
+	height := m.listRowHeight()
+	if m.groupHeaderAt(displayIdx, start) != "" {
+		height++
+	}
+	return height
+}
+
+// visibleEnd returns the end of the rows that fit when drawing from start.
+// At least one row is always shown.
+func (m *Model) visibleEnd(start int) int {
+	_, available := m.listPaneSize()
+	available -= listHeaderHeight
+	total := m.displayCount()
+	used := 0
+	end := start
+	for end < total {
+		span := m.rowSpan(end, start)
+		if used+span > available && end > start {
+			break
+		}
+		used += span
+		end++
+	}
+	return end
+}
+
+// maxScrollOffset is the first row that still fills the screen to the end
+// of the list.
+func (m *Model) maxScrollOffset() int {
+	total := m.displayCount()
+	offset := total - 1
+	for offset > 0 && m.visibleEnd(offset-1) >= total {
+		offset--
+	}
+	return max(0, offset)
+}
+
+// displayIndexAtLine maps a screen line in the list to a row, or -1.
+func (m *Model) displayIndexAtLine(line int) int {
+	start, end := m.getVisibleThreadRange()
+	y := listHeaderHeight
+	for i := start; i < end; i++ {
+		if m.groupHeaderAt(i, start) != "" {
+			y++
+		}
+		if line >= y && line < y+m.listRowHeight() {
+			return i
+		}
+		y += m.listRowHeight()
+	}
+	return -1
+}
+
+func (m *Model) renderGroupHeader(label string, width int) string {
+	style := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.theme.Status.Dim)).
+		Bold(true)
+	text := " " + label + " "
+	rule := strings.Repeat("─", max(0, width-lipgloss.Width(text)-1))
+	return style.Render("─" + text + rule)
+}
+
+// threadLabelNames returns the user label names on a thread.
+func (m *Model) threadLabelNames(thread gmail.Thread) string {
+	names := m.labelNames[thread.AccountIndex]
+	if len(names) == 0 {
+		return ""
+	}
+	var out []string
+	for _, id := range thread.Labels {
+		if name, ok := names[id]; ok {
+			out = append(out, name)
+		}
+	}
+	slices.Sort(out)
+	return strings.Join(out, ", ")
+}
@@ -0,0 +1,207 @@
+package tui
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.withmatt.com/inbox/internal/mimetree"
+)
+
+type mimeParsedMsg struct {
+	messageID string
+	root      *mimetree.Part
+	err       error
+}
+
+type mimePartSavedMsg struct {
+	name string
+	path string
+	err  error
+}
+
+// showMimeTree opens the MIME structure of the selected message, fetching
+// its source first unless the raw view already has.
+func (m Model) showMimeTree() (Model, tea.Cmd) {
+	if m.detail.currentThread == nil ||
+		m.detail.selectedMessageIdx < 0 || m.detail.selectedMessageIdx >= len(m.detail.messages) {
+		return m, nil
+	}
+	msg := m.detail.messages[m.detail.selectedMessageIdx]
+	if msg.Raw != "" {
+		return m, parseMimeCmd(msg.ID, msg.Raw)
+	}
+	m.detail.mimePending = msg.ID
+	if m.detail.rawLoading[msg.ID] {
+		return m, nil
+	}
+	if m.detail.rawLoading == nil {
+		m.detail.rawLoading = make(map[string]bool)
+	}
+	m.detail.rawLoading[msg.ID] = true
+	return m, m.loadMessageRawCmd(
+		m.detail.currentThread.ThreadID,
+		msg.ID,
+		m.detail.currentThread.AccountIndex,
+	)
+}
+
+func parseMimeCmd(messageID, raw string) tea.Cmd {
+	return func() tea.Msg {
+		root, err := mimetree.Parse(raw)
+		return mimeParsedMsg{messageID: messageID, root: root, err: err}
+	}
+}
+
+func (m Model) handleMimeParsed(msg mimeParsedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.ui.err = fmt.Errorf("failed to parse message: %w", msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	if !m.detailVisible() || m.detail.currentThread == nil || m.attachments.modal.show {
+		return m, nil
+	}
+	parts := mimetree.Flatten(msg.root)
+	m.logf("MIME tree message=%s parts=%d", msg.messageID, len(parts))
+	m.attachments.modal.show = true
+	m.attachments.modal.attachments = nil
+	m.attachments.modal.messageID = msg.messageID
+	m.attachments.modal.accountIndex = m.detail.currentThread.AccountIndex
+	m.attachments.modal.selectedIdx = 0
+	m.attachments.modal.mime = mimeState{active: true, parts: parts}
+	return m, m.setWindowTitleCmd()
+}
+
+// closeMimeTree closes the modal, which the MIME tree has to itself.
+func (m *Model) closeMimeTree() {
+	m.attachments.modal.mime = mimeState{}
+	m.attachments.modal.show = false
+}
+
+func (m *Model) selectedMimePart() (*mimetree.Part, bool) {
+	state := m.attachments.modal.mime
+	if state.selectedIdx < 0 || state.selectedIdx >= len(state.parts) {
+		return nil, false
+	}
+	return state.parts[state.selectedIdx], true
+}
+
+// mimePartFilename names a part for saving: its own filename, or else its
+// section number and an extension for its type.
+func mimePartFilename(part *mimetree.Part) string {
+	if part.Filename != "" {
+		return part.Filename
+	}
+	name := "part-" + cmp.Or(part.Section, "0")
+	// The system tables often list a rarer extension first for these
+	switch part.ContentType {
+	case "text/plain":
+		return name + ".txt"
+	case "text/html":
+		return name + ".html"
+	case "message/rfc822":
+		return name + ".eml"
+	}
+	if exts, _ := mime.ExtensionsByType(part.ContentType); len(exts) > 0 {
+		return name + exts[0]
+	}
+	return name
+}
+
+// previewMimePart decodes the selected part and opens it in the text or
+// image viewer. Embedded messages are shown as their source.
+func (m Model) previewMimePart() (tea.Model, tea.Cmd) {
+	part, ok := m.selectedMimePart()
+	if !ok || part.Body == nil {
+		return m, nil
+	}
+	filename := mimePartFilename(part)
+	mimeType := part.ContentType
+	switch {
+	case isImageMimeType(mimeType):
+	case strings.HasPrefix(mimeType, "message/"):
+		mimeType = "text/plain"
+	case !isTextAttachment(mimeType, filename):
+		m.ui.err = fmt.Errorf("unsupported attachment type: %s", mimeType)
+		m.ui.showError = true
+		return m, nil
+	}
+	m.attachments.modal.loadingPreview = true
+	return m, tea.Batch(loadMimePartCmd(part, filename, mimeType), m.ui.spinner.Tick)
+}
+
+// loadMimePartCmd decodes a part into the same message a downloaded
+// attachment produces, so previews go through the usual viewers.
+func loadMimePartCmd(part *mimetree.Part, filename, mimeType string) tea.Cmd {
+	return func() tea.Msg {
+		var data []byte
+		var err error
+		if isImageMimeType(mimeType) {
+			data, err = part.Decode()
+		} else {
+			var text string
+			text, err = part.Text()
+			data = []byte(text)
+		}
+		if err != nil {
+			return attachmentLoadedMsg{filename: filename, err: err}
+		}
+		return attachmentLoadedMsg{
+			data:     base64.URLEncoding.EncodeToString(data),
+			mimeType: mimeType,
+			filename: filename,
+			size:     int64(len(data)),
+		}
+	}
+}
+
+// saveMimePart writes the selected part, decoded, to the download directory.
+func (m Model) saveMimePart() (tea.Model, tea.Cmd) {
+	part, ok := m.selectedMimePart()
+	if !ok || part.Body == nil {
+		return m, nil
+	}
+	m.logf("MIME save message=%s section=%s", m.attachments.modal.messageID, part.Section)
+	return m, m.saveMimePartCmd(part, mimePartFilename(part))
+}
+
+func (m *Model) saveMimePartCmd(part *mimetree.Part, name string) tea.Cmd {
+	return func() tea.Msg {
+		data, err := part.Decode()
+		if err != nil {
+			return mimePartSavedMsg{name: name, err: err}
+		}
+		dir, err := m.downloadsDir()
+		if err != nil {
+			return mimePartSavedMsg{name: name, err: err}
+		}
+		file, dest, err := createUniqueFile(dir, sanitizeFilename(name))
+		if err != nil {
+			return mimePartSavedMsg{name: name, err: err}
+		}
+		_, err = file.Write(data)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(dest)
+			return mimePartSavedMsg{name: name, err: err}
+		}
+		return mimePartSavedMsg{name: name, path: dest}
+	}
+}
+
+func (m Model) handleMimePartSaved(msg mimePartSavedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.ui.err = fmt.Errorf("failed to save %s: %w", msg.name, msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	return m, m.toastCmd("Saved " + tildePath(msg.path))
+}
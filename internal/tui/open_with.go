@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"go.withmatt.com/inbox/internal/mailcap"
+)
+
+type attachmentOpenReadyMsg struct {
+	filename string
+	mimeType string
+	entry    mailcap.Entry
+	path     string
+	// dir is the temp directory holding path
+	dir string
+	err error
+}
+
+type externalHandlerExitedMsg struct {
+	filename string
+	err      error
+}
+
+// openAttachmentWithCmd finds a mailcap handler for the attachment and
+// writes it to a temp file for the handler to open.
+func (m *Model) openAttachmentWithCmd(ctx context.Context, written *atomic.Int64, attachmentIdx int) tea.Cmd {
+	return func() tea.Msg {
+		if attachmentIdx < 0 || attachmentIdx >= len(m.attachments.modal.attachments) {
+			return attachmentOpenReadyMsg{err: errors.New("invalid attachment index")}
+		}
+		att := m.attachments.modal.attachments[attachmentIdx]
+
+		mimeType := att.MimeType
+		entry, err := m.mailcap.Lookup(ctx, mimeType)
+		if errors.Is(err, mailcap.ErrNoHandler) {
+			// Senders often label everything application/octet-stream
+			if guessed := mime.TypeByExtension(filepath.Ext(att.Filename)); guessed != "" {
+				mimeType, _, _ = mime.ParseMediaType(guessed)
+				entry, err = m.mailcap.Lookup(ctx, mimeType)
+			}
+		}
+		if err != nil {
+			return attachmentOpenReadyMsg{filename: att.Filename, err: err}
+		}
+
+		dir, err := os.MkdirTemp("", "inbox-open-*")
+		if err != nil {
+			return attachmentOpenReadyMsg{filename: att.Filename, err: err}
+		}
+		path := filepath.Join(dir, entry.TempName(sanitizeFilename(att.Filename)))
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			os.RemoveAll(dir)
+			return attachmentOpenReadyMsg{filename: att.Filename, err: err}
+		}
+		err = writeAttachment(
+			ctx,
+			m.clients[m.attachments.modal.accountIndex],
+			m.attachments.modal.messageID,
+			att,
+			file,
+			written,
+		)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.RemoveAll(dir)
+			return attachmentOpenReadyMsg{filename: att.Filename, err: err}
+		}
+		return attachmentOpenReadyMsg{
+			filename: att.Filename,
+			mimeType: mimeType,
+			entry:    entry,
+			path:     path,
+			dir:      dir,
+		}
+	}
+}
+
+// handleAttachmentOpenReady runs the handler. Terminal handlers get the
+// screen until they exit; others are started in the background.
+func (m Model) handleAttachmentOpenReady(msg attachmentOpenReadyMsg) (tea.Model, tea.Cmd) {
+	m.endDownload()
+	if errors.Is(msg.err, context.Canceled) {
+		return m, m.toastCmd("Download cancelled")
+	}
+	if msg.err != nil {
+		m.ui.err = fmt.Errorf("failed to open %s: %w", msg.filename, msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+
+	cmd := msg.entry.Cmd(msg.path, msg.mimeType)
+	m.logf("Attachment open file=%s type=%s handler=%q", msg.filename, msg.mimeType, msg.entry.Command)
+	if msg.entry.Terminal() {
+		return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+			os.RemoveAll(msg.dir)
+			return externalHandlerExitedMsg{filename: msg.filename, err: err}
+		})
+	}
+
+	// Viewers often hand the file to an existing window and exit right
+	// away, so the temp file is left for the system to clean up.
+	return m, func() tea.Msg {
+		if err := cmd.Start(); err != nil {
+			os.RemoveAll(msg.dir)
+			return externalHandlerExitedMsg{filename: msg.filename, err: err}
+		}
+		go cmd.Wait()
+		return nil
+	}
+}
+
+func (m Model) handleExternalHandlerExited(msg externalHandlerExitedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.logf("Attachment handler failed file=%s: %v", msg.filename, msg.err)
+		m.ui.err = fmt.Errorf("handler for %s failed: %w", msg.filename, msg.err)
+		m.ui.showError = true
+	}
+	return m, nil
+}
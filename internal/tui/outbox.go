@@ -0,0 +1,409 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go.withmatt.com/inbox/internal/gmail"
+	"go.withmatt.com/inbox/internal/outbox"
+)
+
+// outboxRetryInterval controls how often queued actions are retried while offline
+const outboxRetryInterval = 30 * time.Second
+
+type outboxLoadedMsg struct {
+	pending []outbox.Op
+	failed  []outbox.Op
+	replay  bool
+	err     error
+}
+
+type outboxReplayedMsg struct {
+	pending     []outbox.Op
+	failed      []outbox.Op
+	applied     int
+	newlyFailed int
+	offline     bool
+	err         error
+}
+
+type outboxRetryMsg struct{}
+
+func outboxKindForAction(action deleteAction) (outbox.Kind, bool) {
+	switch action {
+	case deleteActionArchive:
+		return outbox.KindArchive, true
+	case deleteActionTrash:
+		return outbox.KindTrash, true
+	case deleteActionPermanent:
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+// enqueueThreadOp queues op against the thread ref points at.
+func (m *Model) enqueueThreadOp(ref threadRef, op outbox.Op) error {
+	if ref.accountIndex < 0 || ref.accountIndex >= len(m.accountNames) {
+		return fmt.Errorf("invalid account index %d", ref.accountIndex)
+	}
+	op.Account = m.accountNames[ref.accountIndex]
+	op.ThreadID = ref.threadID
+	_, err := m.outbox.queue.Enqueue(op)
+	return err
+}
+
+func (m *Model) accountIndexByName(name string) int {
+	for i, accountName := range m.accountNames {
+		if accountName == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *Model) subjectForRef(ref threadRef) string {
+	return m.subjectsForRefs([]threadRef{ref})[threadKey(ref.threadID, ref.accountIndex)]
+}
+
+// subjectsForRefs snapshots subjects so commands don't read threads off the UI goroutine.
+func (m *Model) subjectsForRefs(refs []threadRef) map[string]string {
+	subjects := make(map[string]string, len(refs))
+	for _, ref := range refs {
+		subjects[threadKey(ref.threadID, ref.accountIndex)] = ""
+	}
+	for _, threads := range [][]gmail.Thread{m.inbox.threads, m.inbox.undo.threads} {
+		for _, thread := range threads {
+			key := threadKey(thread.ThreadID, thread.AccountIndex)
+			if _, ok := subjects[key]; ok {
+				subjects[key] = thread.Subject
+			}
+		}
+	}
+	return subjects
+}
+
+func listOutbox(queue *outbox.Queue) ([]outbox.Op, []outbox.Op, error) {
+	pending, err := queue.List(outbox.StatePending)
+	if err != nil {
+		return nil, nil, err
+	}
+	failed, err := queue.List(outbox.StateFailed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pending, failed, nil
+}
+
+// loadOutboxCmd refreshes the pending and failed counts from the queue.
+func (m *Model) loadOutboxCmd(replay bool) tea.Cmd {
+	queue := m.outbox.queue
+	if queue == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		pending, failed, err := listOutbox(queue)
+		return outboxLoadedMsg{pending: pending, failed: failed, replay: replay, err: err}
+	}
+}
+
+// outboxSyncCmd starts a replay if there is anything waiting and none is running.
+func (m *Model) outboxSyncCmd() tea.Cmd {
+	if m.outbox.queue == nil || m.outbox.replaying || len(m.outbox.pending) == 0 {
+		return nil
+	}
+	m.outbox.replaying = true
+	return m.replayOutboxCmd()
+}
+
+func (m *Model) scheduleOutboxRetryCmd() tea.Cmd {
+	if m.outbox.retryScheduled || len(m.outbox.pending) == 0 {
+		return nil
+	}
+	m.outbox.retryScheduled = true
+	return tea.Tick(outboxRetryInterval, func(time.Time) tea.Msg {
+		return outboxRetryMsg{}
+	})
+}
+
+// replayOutboxCmd applies pending operations in order, stopping at the first
+// connectivity failure so later operations never overtake earlier ones.
+func (m *Model) replayOutboxCmd() tea.Cmd {
+	queue := m.outbox.queue
+	return func() tea.Msg {
+		ops, err := queue.List(outbox.StatePending)
+		if err != nil {
+			return outboxReplayedMsg{err: err}
+		}
+
+		var applied, newlyFailed int
+		offline := false
+		labelIDs := make(map[int]map[string]string)
+		for _, op := range ops {
+			err := m.replayOutboxOp(op, labelIDs)
+			switch {
+			case err == nil:
+				applied++
+				if err := queue.Delete(op.ID); err != nil {
+					m.logf("Outbox delete error op=%d err=%v", op.ID, err)
+				}
+			case gmail.IsTransientError(err):
+				m.logf("Outbox replay deferred op=%d err=%v", op.ID, err)
+				if err := queue.RecordAttempt(op.ID, err.Error()); err != nil {
+					m.logf("Outbox update error op=%d err=%v", op.ID, err)
+				}
+				offline = true
+			default:
+				m.logf("Outbox replay failed op=%d err=%v", op.ID, err)
+				newlyFailed++
+				if err := queue.MarkFailed(op.ID, err.Error()); err != nil {
+					m.logf("Outbox update error op=%d err=%v", op.ID, err)
+				}
+			}
+			if offline {
+				break
+			}
+		}
+
+		pending, failed, err := listOutbox(queue)
+		return outboxReplayedMsg{
+			pending:     pending,
+			failed:      failed,
+			applied:     applied,
+			newlyFailed: newlyFailed,
+			offline:     offline,
+			err:         err,
+		}
+	}
+}
+
+func (m *Model) replayOutboxOp(op outbox.Op, labelIDs map[int]map[string]string) error {
+	accountIndex := m.accountIndexByName(op.Account)
+	if accountIndex < 0 || accountIndex >= len(m.clients) {
+		return fmt.Errorf("account %q is no longer configured", op.Account)
+	}
+	client := m.clients[accountIndex]
+
+	if op.Kind == outbox.KindModifyLabels {
+		ids, err := m.labelIDsFor(accountIndex, labelIDs)
+		if err != nil {
+			return err
+		}
+		if op.AddLabels, err = resolveLabelIDs(op.AddLabels, ids); err != nil {
+			return err
+		}
+		if op.RemoveLabels, err = resolveLabelIDs(op.RemoveLabels, ids); err != nil {
+			return err
+		}
+	}
+
+	state, err := client.GetThreadState(m.ctx, op.ThreadID)
+	if err != nil {
+		if gmail.IsNotFound(err) {
+			return errors.New("thread no longer exists")
+		}
+		return err
+	}
+	applied, err := op.Check(state.Labels, state.LatestDate)
+	if err != nil || applied {
+		return err
+	}
+
+	switch op.Kind {
+	case outbox.KindArchive:
+		return client.ArchiveThread(m.ctx, op.ThreadID)
+	case outbox.KindUnarchive:
+		return client.UnarchiveThread(m.ctx, op.ThreadID)
+	case outbox.KindTrash:
+		return client.TrashThread(m.ctx, op.ThreadID)
+	case outbox.KindUntrash:
+		return client.UntrashThread(m.ctx, op.ThreadID)
+	case outbox.KindMarkRead:
+		return client.MarkThreadRead(m.ctx, op.ThreadID)
+	case outbox.KindMarkUnread:
+		return client.MarkThreadUnread(m.ctx, op.ThreadID)
+	case outbox.KindModifyLabels:
+		return client.ModifyThreadLabels(m.ctx, op.ThreadID, op.AddLabels, op.RemoveLabels)
+	default:
+		return fmt.Errorf("unknown queued action %q", op.Kind)
+	}
+}
+
+// applyPendingActions re-applies queued operations on top of freshly loaded
+// threads so optimistic changes survive a refresh.
+func (m *Model) applyPendingActions() {
+	if len(m.outbox.pending) == 0 {
+		return
+	}
+
+	hidden := make(map[string]bool)
+	unread := make(map[string]bool)
+	for _, op := range m.outbox.pending {
+		accountIndex := m.accountIndexByName(op.Account)
+		if accountIndex < 0 {
+			continue
+		}
+		key := threadKey(op.ThreadID, accountIndex)
+		switch op.Kind {
+		case outbox.KindArchive, outbox.KindTrash:
+			hidden[key] = true
+		case outbox.KindUnarchive, outbox.KindUntrash:
+			delete(hidden, key)
+		case outbox.KindMarkRead:
+			unread[key] = false
+		case outbox.KindMarkUnread:
+			unread[key] = true
+		case outbox.KindModifyLabels:
+			if containsLabel(op.RemoveLabels, "INBOX") {
+				hidden[key] = true
+			}
+			if containsLabel(op.AddLabels, "INBOX") {
+				delete(hidden, key)
+			}
+		}
+	}
+
+	removed := false
+	kept := make([]gmail.Thread, 0, len(m.inbox.threads))
+	for _, thread := range m.inbox.threads {
+		key := threadKey(thread.ThreadID, thread.AccountIndex)
+		if hidden[key] {
+			removed = true
+			continue
+		}
+		if value, ok := unread[key]; ok && thread.Loaded {
+			thread.Unread = value
+		}
+		kept = append(kept, thread)
+	}
+	m.inbox.threads = kept
+	if removed {
+		m.pruneSelection()
+		if m.filterActive() {
+			m.reapplyFilterPreserveCursor()
+		} else {
+			m.clampCursor()
+		}
+	}
+}
+
+func (m Model) handleOutboxLoaded(msg outboxLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.logf("Outbox load error: %v", msg.err)
+		return m, nil
+	}
+	m.outbox.pending = msg.pending
+	m.outbox.failed = msg.failed
+	m.applyPendingActions()
+	if msg.replay {
+		return m, m.outboxSyncCmd()
+	}
+	return m, m.scheduleOutboxRetryCmd()
+}
+
+func (m Model) handleOutboxRetry() (tea.Model, tea.Cmd) {
+	m.outbox.retryScheduled = false
+	return m, m.outboxSyncCmd()
+}
+
+func (m Model) handleOutboxReplayed(msg outboxReplayedMsg) (tea.Model, tea.Cmd) {
+	m.outbox.replaying = false
+	if msg.err != nil {
+		m.logf("Outbox replay error: %v", msg.err)
+		return m, m.scheduleOutboxRetryCmd()
+	}
+	m.outbox.pending = msg.pending
+	m.outbox.failed = msg.failed
+
+	var cmds []tea.Cmd
+	if msg.offline {
+		cmds = append(cmds, m.scheduleOutboxRetryCmd())
+	}
+	if msg.newlyFailed > 0 {
+		// Threads hidden by failed actions come back with a fresh listing
+		m.inbox.refreshing = true
+		cmds = append(cmds, m.loadInboxCmd(inboxLoadManual))
+	}
+	cmds = append(cmds, m.outboxToastCmd(msg.applied, msg.newlyFailed))
+	return m, tea.Batch(cmds...)
+}
+
+func (m Model) handleFailedActionsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "r":
+		m.outbox.showFailed = false
+		if len(m.outbox.failed) == 0 {
+			return m, nil
+		}
+		if err := m.outbox.queue.RequeueFailed(); err != nil {
+			m.ui.err = err
+			m.ui.showError = true
+			return m, nil
+		}
+		return m, m.loadOutboxCmd(true)
+	case "c":
+		m.outbox.showFailed = false
+		if len(m.outbox.failed) == 0 {
+			return m, nil
+		}
+		if err := m.outbox.queue.ClearFailed(); err != nil {
+			m.ui.err = err
+			m.ui.showError = true
+			return m, nil
+		}
+		m.outbox.failed = nil
+		return m, nil
+	default:
+		m.outbox.showFailed = false
+		return m, nil
+	}
+}
+
+func (m *Model) renderFailedActionsModal() string {
+	var b strings.Builder
+
+	modalWidth := 70
+	titleStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center).
+		Bold(true)
+	b.WriteString(titleStyle.Render("Failed Actions"))
+	b.WriteString("\n\n")
+
+	if len(m.outbox.failed) == 0 {
+		b.WriteString("No failed actions.")
+		b.WriteString("\n")
+	}
+	maxWidth := modalWidth - 4
+	for _, op := range m.outbox.failed {
+		subject := strings.TrimSpace(stripZeroWidth(op.Subject))
+		if subject == "" {
+			subject = op.ThreadID
+		}
+		line := fmt.Sprintf("%s · %s", op.Describe(), subject)
+		if len(m.accountNames) > 1 {
+			line = op.Account + " · " + line
+		}
+		b.WriteString(truncateToWidth(line, maxWidth))
+		b.WriteString("\n")
+		if op.LastError != "" {
+			reasonStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+			b.WriteString(reasonStyle.Render(truncateToWidth("  "+op.LastError, maxWidth)))
+			b.WriteString("\n")
+		}
+	}
+
+	b.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+	b.WriteString(footerStyle.Render("r retry • c clear • esc close"))
+
+	return b.String()
+}
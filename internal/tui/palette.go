@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// paletteVisibleItems caps how many matches are shown at once
+const paletteVisibleItems = 12
+
+type paletteItem struct {
+	name string
+	desc string
+	keys string
+	// args marks commands that need arguments; they open the command line
+	args bool
+}
+
+// paletteItems lists every action in the current view followed by the
+// commands that take arguments.
+func (m *Model) paletteItems() []paletteItem {
+	km := m.keyMap()
+	var items []paletteItem
+	for _, a := range actionsForView(m.currentView) {
+		if a.name == "palette" {
+			continue
+		}
+		item := paletteItem{name: a.name, desc: a.desc}
+		if a.binding != nil {
+			item.keys = a.binding(km).Help().Key
+		}
+		items = append(items, item)
+	}
+	for _, c := range commandDefs() {
+		items = append(items, paletteItem{name: c.name, desc: c.desc, args: true})
+	}
+	return items
+}
+
+func (m Model) openPalette() (Model, tea.Cmd) {
+	m.palette.show = true
+	m.palette.cursor = 0
+	m.palette.input.SetValue("")
+	m.palette.input.Focus()
+	m.palette.items = filterPaletteItems(m.paletteItems(), "")
+	return m, textinput.Blink
+}
+
+func (m Model) closePalette() Model {
+	m.palette.show = false
+	m.palette.input.Blur()
+	m.palette.items = nil
+	return m
+}
+
+func (m Model) handlePaletteKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+	case "esc":
+		return m.closePalette(), nil
+	case "up", "ctrl+p", "ctrl+k":
+		if m.palette.cursor > 0 {
+			m.palette.cursor--
+		}
+		return m, nil
+	case "down", "ctrl+n", "ctrl+j":
+		if m.palette.cursor < len(m.palette.items)-1 {
+			m.palette.cursor++
+		}
+		return m, nil
+	case "enter":
+		if m.palette.cursor < 0 || m.palette.cursor >= len(m.palette.items) {
+			return m, nil
+		}
+		item := m.palette.items[m.palette.cursor]
+		m = m.closePalette()
+		if item.args {
+			return m.openCommandLine(item.name + " ")
+		}
+		return m.runCommandLine(item.name)
+	}
+
+	var cmd tea.Cmd
+	m.palette.input, cmd = m.palette.input.Update(msg)
+	m.palette.items = filterPaletteItems(m.paletteItems(), m.palette.input.Value())
+	m.palette.cursor = 0
+	return m, cmd
+}
+
+// filterPaletteItems keeps items that fuzzy match query, best matches first.
+func filterPaletteItems(items []paletteItem, query string) []paletteItem {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return items
+	}
+	type scored struct {
+		item  paletteItem
+		score int
+	}
+	matches := make([]scored, 0, len(items))
+	for _, item := range items {
+		score, ok := fuzzyScore(query, item.name)
+		if descScore, descOK := fuzzyScore(query, item.desc); descOK {
+			// Description matches count, but less than name matches
+			descScore /= 2
+			if !ok || descScore > score {
+				score, ok = descScore, true
+			}
+		}
+		if ok {
+			matches = append(matches, scored{item: item, score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	out := make([]paletteItem, 0, len(matches))
+	for _, match := range matches {
+		out = append(out, match.item)
+	}
+	return out
+}
+
+// fuzzyScore reports whether every rune of pattern appears in text in order,
+// scoring consecutive runs and word starts higher.
+func fuzzyScore(pattern, text string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	t := []rune(strings.ToLower(text))
+	if len(p) == 0 {
+		return 0, true
+	}
+
+	score := 0
+	pi := 0
+	prevMatched := false
+	for ti, r := range t {
+		if pi >= len(p) {
+			break
+		}
+		if r != p[pi] {
+			prevMatched = false
+			continue
+		}
+		score++
+		if prevMatched {
+			score += 3
+		}
+		if ti == 0 || !unicode.IsLetter(t[ti-1]) {
+			score += 2
+		}
+		prevMatched = true
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	// Prefer shorter targets when scores tie
+	return score*100 - len(t), true
+}
+
+func (m *Model) renderPaletteModal() string {
+	var b strings.Builder
+
+	modalWidth := max(40, min(70, m.ui.width-10))
+	titleStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center).
+		Bold(true)
+	b.WriteString(titleStyle.Render("Commands"))
+	b.WriteString("\n\n")
+
+	m.palette.input.Width = modalWidth - 4
+	b.WriteString(m.palette.input.View())
+	b.WriteString("\n\n")
+
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.theme.List.SelectedFg)).
+		Bold(true)
+
+	items := m.palette.items
+	if len(items) == 0 {
+		b.WriteString(dimStyle.Render("No matching commands"))
+		b.WriteString("\n")
+	}
+	start := 0
+	if m.palette.cursor >= paletteVisibleItems {
+		start = m.palette.cursor - paletteVisibleItems + 1
+	}
+	end := min(len(items), start+paletteVisibleItems)
+	nameWidth := 0
+	for _, item := range items {
+		nameWidth = max(nameWidth, lipgloss.Width(item.name))
+	}
+	for i := start; i < end; i++ {
+		item := items[i]
+		marker := "  "
+		nameStyle := lipgloss.NewStyle()
+		if i == m.palette.cursor {
+			marker = "> "
+			nameStyle = selectedStyle
+		}
+		name := item.name
+		if item.args {
+			name += " …"
+		}
+		name += strings.Repeat(" ", max(0, nameWidth+2-lipgloss.Width(name)))
+		keys := item.keys
+		descWidth := modalWidth - lipgloss.Width(marker) - lipgloss.Width(name) -
+			lipgloss.Width(keys) - 2
+		desc := truncateToWidth(item.desc, max(0, descWidth))
+		gap := strings.Repeat(" ", max(1, descWidth-lipgloss.Width(desc)+1))
+		b.WriteString(marker + nameStyle.Render(name) + dimStyle.Render(desc) + gap + keys)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+	b.WriteString(footerStyle.Render("↑/↓ navigate • enter run • esc close"))
+
+	return b.String()
+}
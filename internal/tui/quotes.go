@@ -0,0 +1,201 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type foldKind int
+
+const (
+	foldNone foldKind = iota
+	foldQuote
+	foldSignature
+)
+
+// bodySegment is a run of body lines that is folded as a unit.
+type bodySegment struct {
+	kind  foldKind
+	lines []string
+}
+
+var (
+	// attributionRe matches reply headers like "On Mon, Jan 2, 2006 at 3:04
+	// PM Jane <jane@example.com> wrote:", which clients often wrap
+	attributionRe = regexp.MustCompile(`(?i)^on\s.+\bwrote:\s*$`)
+	// originalMessageRe matches the separators Outlook and others put above
+	// an unquoted copy of the previous message
+	originalMessageRe = regexp.MustCompile(`(?i)^(-{2,}\s*original message\s*-{2,}|_{10,})$`)
+	// headerLineRe matches a "Label:" line, ignoring Markdown emphasis
+	headerLineRe = regexp.MustCompile(`^[*_]*(from|sent|date|to|cc|subject)[*_]*:`)
+)
+
+// quotesFolded reports whether a message's quotes and signature are hidden.
+// The toggle flips the configured default.
+func (m *Model) quotesFolded(msgID string) bool {
+	return m.uiConfig.ExpandQuotes == m.detail.quotesToggled[msgID]
+}
+
+// foldQuotedText replaces quoted history and the signature with a one-line
+// placeholder naming the key that shows them.
+func foldQuotedText(text, toggleKey string) string {
+	segments := splitFoldable(text)
+	if len(segments) == 1 && segments[0].kind == foldNone {
+		return text
+	}
+	var b strings.Builder
+	for i, seg := range segments {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		switch seg.kind {
+		case foldQuote:
+			n := countNonBlank(seg.lines)
+			fmt.Fprintf(&b, "\n`[%d quoted %s hidden, %s to show]`\n", n, pluralize(n, "line"), toggleKey)
+		case foldSignature:
+			fmt.Fprintf(&b, "\n`[signature hidden, %s to show]`\n", toggleKey)
+		default:
+			b.WriteString(strings.Join(seg.lines, "\n"))
+		}
+	}
+	return b.String()
+}
+
+// splitFoldable breaks a text or Markdown body into plain text, quoted
+// blocks and a signature. Quoted blocks are ">" lines and the "On ...
+// wrote:" header introducing them; an Outlook style separator or header
+// block quotes everything after it. A signature runs from a "-- " line to the next quote.
+func splitFoldable(text string) []bodySegment {
+	lines := strings.Split(text, "\n")
+	var segments []bodySegment
+	current := bodySegment{kind: foldNone}
+	flush := func(next foldKind) {
+		if len(current.lines) > 0 {
+			segments = append(segments, current)
+		}
+		current = bodySegment{kind: next}
+	}
+
+	inFence := false
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		// Code blocks are never quotes, whatever their lines start with
+		fence := strings.HasPrefix(trimmed, "```")
+		if fence {
+			inFence = !inFence
+		}
+		if inFence || fence {
+			if current.kind == foldQuote {
+				flush(foldNone)
+			}
+			current.lines = append(current.lines, line)
+			continue
+		}
+
+		if current.kind == foldQuote {
+			if isQuotedLine(trimmed) || (trimmed == "" && nextNonBlankQuoted(lines, i+1)) {
+				current.lines = append(current.lines, line)
+				continue
+			}
+			flush(foldNone)
+		}
+
+		switch {
+		case isHistoryStart(lines, i):
+			// Everything after an unquoted copy of the last message is history
+			flush(foldQuote)
+			current.lines = lines[i:]
+			i = len(lines)
+		case attributionRe.MatchString(trimmed) && nextNonBlankQuoted(lines, i+1):
+			flush(foldQuote)
+			current.lines = append(current.lines, line)
+		case i+1 < len(lines) && strings.HasPrefix(strings.ToLower(trimmed), "on ") &&
+			attributionRe.MatchString(trimmed+" "+strings.TrimSpace(lines[i+1])) &&
+			nextNonBlankQuoted(lines, i+2):
+			flush(foldQuote)
+			current.lines = append(current.lines, line, lines[i+1])
+			i++
+		case isQuotedLine(trimmed):
+			flush(foldQuote)
+			current.lines = append(current.lines, line)
+		case current.kind == foldNone && (line == "-- " || trimmed == "--"):
+			flush(foldSignature)
+			current.lines = append(current.lines, line)
+		default:
+			current.lines = append(current.lines, line)
+		}
+	}
+	flush(foldNone)
+
+	// Blank lines that close a quote read better outside the fold
+	for i := range segments {
+		seg := &segments[i]
+		if seg.kind != foldQuote {
+			continue
+		}
+		for len(seg.lines) > 1 && strings.TrimSpace(seg.lines[len(seg.lines)-1]) == "" {
+			seg.lines = seg.lines[:len(seg.lines)-1]
+		}
+	}
+	return segments
+}
+
+func isQuotedLine(trimmed string) bool {
+	return strings.HasPrefix(trimmed, ">")
+}
+
+func nextNonBlankQuoted(lines []string, from int) bool {
+	for _, line := range lines[from:] {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return isQuotedLine(trimmed)
+		}
+	}
+	return false
+}
+
+// isHistoryStart spots an "Original Message" separator, or a From: header
+// followed closely by Sent:/Date: and To:/Subject: lines.
+func isHistoryStart(lines []string, i int) bool {
+	trimmed := strings.TrimSpace(lines[i])
+	if originalMessageRe.MatchString(trimmed) {
+		return true
+	}
+	match := headerLineRe.FindStringSubmatch(strings.ToLower(trimmed))
+	if match == nil || match[1] != "from" {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, next := range lines[i+1 : min(len(lines), i+6)] {
+		if m := headerLineRe.FindStringSubmatch(strings.ToLower(strings.TrimSpace(next))); m != nil {
+			seen[m[1]] = true
+		}
+	}
+	return (seen["sent"] || seen["date"]) && (seen["to"] || seen["subject"])
+}
+
+// countNonBlank counts lines with text, not counting bare ">" markers.
+func countNonBlank(lines []string) int {
+	n := 0
+	for _, line := range lines {
+		if strings.Trim(line, "> \t") != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// toggleQuotes shows or hides quoted text and the signature in the selected
+// message.
+func (m Model) toggleQuotes() (Model, tea.Cmd) {
+	if m.detail.selectedMessageIdx < 0 || m.detail.selectedMessageIdx >= len(m.detail.messages) {
+		return m, nil
+	}
+	msgID := m.detail.messages[m.detail.selectedMessageIdx].ID
+	m.detail.quotesToggled[msgID] = !m.detail.quotesToggled[msgID]
+	m.detail.viewport.SetContent(m.renderThreadBody())
+	return m, nil
+}
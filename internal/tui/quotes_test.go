@@ -0,0 +1,92 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitFoldable(t *testing.T) {
+	type seg struct {
+		kind  foldKind
+		lines string
+	}
+	tests := []struct {
+		name string
+		text string
+		want []seg
+	}{
+		{
+			name: "wrapped attribution",
+			text: "Sounds good.\n\nOn Mon, Jan 2, 2006 at 3:04 PM Jane Doe <jane@example.com>\nwrote:\n> Lunch?\n> Jane",
+			want: []seg{
+				{foldNone, "Sounds good.\n"},
+				{foldQuote, "On Mon, Jan 2, 2006 at 3:04 PM Jane Doe <jane@example.com>\nwrote:\n> Lunch?\n> Jane"},
+			},
+		},
+		{
+			name: "quote marker in code fence",
+			text: "Run this:\n```\n> make\n>> out.log\n```\nThanks",
+			want: []seg{
+				{foldNone, "Run this:\n```\n> make\n>> out.log\n```\nThanks"},
+			},
+		},
+		{
+			name: "outlook header block",
+			text: "Approved.\n\nFrom: Jane Doe <jane@example.com>\nSent: Monday, January 2, 2006 3:04 PM\nTo: Bob\nSubject: Budget\n\nPlease approve.",
+			want: []seg{
+				{foldNone, "Approved.\n"},
+				{foldQuote, "From: Jane Doe <jane@example.com>\nSent: Monday, January 2, 2006 3:04 PM\nTo: Bob\nSubject: Budget\n\nPlease approve."},
+			},
+		},
+		{
+			name: "outlook header block in markdown",
+			text: "Approved.\n\n**From:** Jane\n**Sent:** Monday\n**Subject:** Budget\n\nPlease approve.",
+			want: []seg{
+				{foldNone, "Approved.\n"},
+				{foldQuote, "**From:** Jane\n**Sent:** Monday\n**Subject:** Budget\n\nPlease approve."},
+			},
+		},
+		{
+			name: "from line without headers",
+			text: "From: the team, with thanks.\nSee you Monday.",
+			want: []seg{
+				{foldNone, "From: the team, with thanks.\nSee you Monday."},
+			},
+		},
+		{
+			name: "signature followed by quote",
+			text: "Thanks\n-- \nBob\n555-0100\n\nOn Mon, Jan 2, 2006, Jane wrote:\n> Call me",
+			want: []seg{
+				{foldNone, "Thanks"},
+				{foldSignature, "-- \nBob\n555-0100\n"},
+				{foldQuote, "On Mon, Jan 2, 2006, Jane wrote:\n> Call me"},
+			},
+		},
+		{
+			name: "wrote line mid-paragraph",
+			text: "We met last week.\nOn Tuesday I sent the plan and wrote:\nit needs another week.",
+			want: []seg{
+				{foldNone, "We met last week.\nOn Tuesday I sent the plan and wrote:\nit needs another week."},
+			},
+		},
+		{
+			name: "wrapped wrote line mid-paragraph",
+			text: "On the whole I agree with what Jane\nwrote: the plan needs a week.",
+			want: []seg{
+				{foldNone, "On the whole I agree with what Jane\nwrote: the plan needs a week."},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []seg
+			for _, s := range splitFoldable(tt.text) {
+				got = append(got, seg{s.kind, strings.Join(s.lines, "\n")})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitFoldable() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package tui
+
+import (
+	"container/list"
+	"sync"
+
+	md "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go.withmatt.com/inbox/internal/gmail"
+)
+
+const (
+	// maxBodyCacheBytes bounds the memory held by rendered bodies, which are
+	// mostly ANSI styling and several times the size of their text
+	maxBodyCacheBytes = 32 << 20
+	// maxPrerenderMessages caps how many of a thread's newest messages are
+	// rendered ahead of being expanded
+	maxPrerenderMessages = 20
+)
+
+// bodyCacheKey identifies one rendering of a message body. Everything that
+// changes the output is part of it, so entries made stale by a resize, theme
+// switch or newly resolved links stop matching and age out.
+type bodyCacheKey struct {
+	messageID string
+	mode      messageViewMode
+	width     int
+	// theme and links are generations bumped when either changes
+	theme  int
+	links  int
+	folded bool
+}
+
+type bodyCacheEntry struct {
+	key  bodyCacheKey
+	body string
+}
+
+// bodyCache holds rendered message bodies, evicting the least recently used
+// once they add up to more than limit bytes. It is shared between model
+// copies and filled by background renders, so it locks.
+type bodyCache struct {
+	mu      sync.Mutex
+	limit   int
+	size    int
+	order   *list.List
+	entries map[bodyCacheKey]*list.Element
+}
+
+func newBodyCache(limit int) *bodyCache {
+	return &bodyCache{
+		limit:   limit,
+		order:   list.New(),
+		entries: make(map[bodyCacheKey]*list.Element),
+	}
+}
+
+func (c *bodyCache) get(key bodyCacheKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*bodyCacheEntry).body, true
+}
+
+func (c *bodyCache) contains(key bodyCacheKey) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[key]
+	return ok
+}
+
+func (c *bodyCache) put(key bodyCacheKey, body string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(body) > c.limit {
+		return
+	}
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*bodyCacheEntry)
+		c.size += len(body) - len(entry.body)
+		entry.body = body
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[key] = c.order.PushFront(&bodyCacheEntry{key: key, body: body})
+		c.size += len(body)
+	}
+	for c.size > c.limit {
+		oldest := c.order.Back()
+		entry := oldest.Value.(*bodyCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, entry.key)
+		c.size -= len(entry.body)
+	}
+}
+
+// bodyRender is what rendering one message body needs, captured up front so
+// the work can happen off the UI goroutine.
+type bodyRender struct {
+	key       bodyCacheKey
+	msg       gmail.Message
+	toggleKey string
+}
+
+func (m *Model) newBodyRender(msg gmail.Message, mode messageViewMode, width int) bodyRender {
+	return bodyRender{
+		key: bodyCacheKey{
+			messageID: msg.ID,
+			mode:      mode,
+			width:     width,
+			theme:     m.renderers.themeGeneration,
+			links:     m.renderers.linkGeneration,
+			folded:    m.quotesFolded(msg.ID),
+		},
+		msg:       msg,
+		toggleKey: m.keyMap().detail.ToggleQuotes.Help().Key,
+	}
+}
+
+// threadBodyWidth is the width message bodies are rendered at, inside the
+// bar that marks the selected message.
+func (m *Model) threadBodyWidth() int {
+	paneWidth, _ := m.detailPaneSize()
+	return max(paneWidth-lipgloss.Width("┃ "), 0)
+}
+
+// renderBody renders a message body in the text or HTML view, reusing an
+// earlier rendering when nothing it depends on has changed.
+func (m *Model) renderBody(msg gmail.Message, mode messageViewMode, width int) string {
+	job := m.newBodyRender(msg, mode, width)
+	if body, ok := m.renderers.bodies.get(job.key); ok {
+		return body
+	}
+	body := renderBodyMarkdown(m.renderers.htmlConverter, job, func(text string) string {
+		return m.renderMarkdown(text, width)
+	})
+	m.renderers.bodies.put(job.key, body)
+	return body
+}
+
+// renderBodyMarkdown converts a body to Markdown, folds its quotes and hands
+// it to render. HTML is used unless the text view has plain text to show.
+func renderBodyMarkdown(converter *md.Converter, job bodyRender, render func(string) string) string {
+	msg := job.msg
+	fold := func(text string) string {
+		if !job.key.folded {
+			return text
+		}
+		return foldQuotedText(text, job.toggleKey)
+	}
+	switch {
+	case job.key.mode == viewModeText && msg.BodyText != "":
+		// Show plain text through glamour
+		return render(fold(msg.BodyText))
+	case msg.BodyHTML != "":
+		markdown, err := converter.ConvertString(cleanHTMLForConversion(msg.BodyHTML))
+		if err != nil {
+			// Fall back to the raw HTML if conversion fails
+			return msg.BodyHTML
+		}
+		return render(fold(inlineImagePlaceholders(markdown, msg)))
+	default:
+		return lipgloss.NewStyle().Italic(true).Render("[No message body]")
+	}
+}
+
+// prerenderBodiesCmd renders the newest messages of the open thread that
+// aren't cached yet in the background, so expanding one is instant.
+// Expanded messages are already rendered by the time this runs.
+func (m *Model) prerenderBodiesCmd() tea.Cmd {
+	width := m.threadBodyWidth()
+	if width <= 0 {
+		return nil
+	}
+	var jobs []bodyRender
+	for _, msg := range m.detail.messages[:min(len(m.detail.messages), maxPrerenderMessages)] {
+		mode := normalizeMessageViewMode(m.detail.messageViewMode, msg)
+		if mode == viewModeRaw {
+			continue
+		}
+		job := m.newBodyRender(msg, mode, width)
+		if m.renderers.bodies.contains(job.key) {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+
+	ctx, theme := m.ctx, m.theme
+	cache, converter, resolver := m.renderers.bodies, m.renderers.htmlConverter, m.linkResolver
+	return func() tea.Msg {
+		// Glamour renderers aren't safe to share, so this gets its own
+		renderer, err := newGlamourRenderer(theme, width)
+		if err != nil {
+			return nil
+		}
+		for _, job := range jobs {
+			if ctx.Err() != nil {
+				return nil
+			}
+			body := renderBodyMarkdown(converter, job, func(text string) string {
+				return renderMarkdownWith(ctx, resolver, renderer, text)
+			})
+			cache.put(job.key, body)
+		}
+		return nil
+	}
+}
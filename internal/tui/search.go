@@ -7,8 +7,20 @@ import (
 	"go.withmatt.com/inbox/internal/gmail"
 )
 
+// allAccounts disables the account filter
+const allAccounts = -1
+
+// filterActive reports whether the list shows a filtered subset of threads.
+func (m *Model) filterActive() bool {
+	return m.search.query != "" || m.search.accountFilter != allAccounts
+}
+
+func (m *Model) matchesAccountFilter(thread gmail.Thread) bool {
+	return m.search.accountFilter == allAccounts || thread.AccountIndex == m.search.accountFilter
+}
+
 func (m *Model) displayCount() int {
-	if m.search.query == "" {
+	if !m.filterActive() {
 		return len(m.inbox.threads)
 	}
 	return len(m.filteredIndices())
@@ -18,7 +30,7 @@ func (m *Model) threadIndexAt(displayIndex int) int {
 	if displayIndex < 0 {
 		return -1
 	}
-	if m.search.query == "" {
+	if !m.filterActive() {
 		if displayIndex >= len(m.inbox.threads) {
 			return -1
 		}
@@ -56,7 +68,7 @@ func (m *Model) visibleThreadIndices() []int {
 }
 
 func (m *Model) filteredIndices() []int {
-	if m.search.query == "" {
+	if !m.filterActive() {
 		return nil
 	}
 	if len(m.inbox.filteredIdx) == 0 {
@@ -89,6 +101,9 @@ func (m *Model) applyFilter(query string) {
 		m.search.remoteGeneration++
 		m.search.remoteKeys = nil
 		m.pruneSearchOnly()
+		if m.search.accountFilter != allAccounts {
+			m.inbox.filteredIdx = m.filterByAccount()
+		}
 		m.clampCursor()
 		m.logf("Search cleared")
 		return
@@ -112,7 +127,7 @@ func (m *Model) applyFilter(query string) {
 	terms := strings.Fields(strings.ToLower(query))
 	filtered := make([]int, 0, len(m.inbox.threads))
 	for i, thread := range m.inbox.threads {
-		if !thread.Loaded {
+		if !thread.Loaded || !m.matchesAccountFilter(thread) {
 			continue
 		}
 		if threadMatches(thread, terms) {
@@ -141,6 +156,9 @@ func (m *Model) filterByRemoteKeys() []int {
 	}
 	filtered := make([]int, 0, len(m.search.remoteKeys))
 	for i, thread := range m.inbox.threads {
+		if !m.matchesAccountFilter(thread) {
+			continue
+		}
 		if _, ok := m.search.remoteKeys[threadKey(thread.ThreadID, thread.AccountIndex)]; ok {
 			filtered = append(filtered, i)
 		}
@@ -148,6 +166,16 @@ func (m *Model) filterByRemoteKeys() []int {
 	return filtered
 }
 
+func (m *Model) filterByAccount() []int {
+	filtered := make([]int, 0, len(m.inbox.threads))
+	for i, thread := range m.inbox.threads {
+		if m.matchesAccountFilter(thread) {
+			filtered = append(filtered, i)
+		}
+	}
+	return filtered
+}
+
 func (m *Model) clampCursor() {
 	count := m.displayCount()
 	if count == 0 {
@@ -222,6 +250,17 @@ func threadMatches(thread gmail.Thread, terms []string) bool {
 	return true
 }
 
+// searchesAllMail reports whether query picks its own mailbox with in:, so
+// searching only the inbox would contradict it.
+func searchesAllMail(query string) bool {
+	for term := range strings.FieldsSeq(strings.ToLower(query)) {
+		if strings.HasPrefix(strings.TrimPrefix(term, "-"), "in:") {
+			return true
+		}
+	}
+	return false
+}
+
 func looksStructuredQuery(query string) bool {
 	for term := range strings.FieldsSeq(query) {
 		if strings.Contains(term, ":") {
@@ -0,0 +1,99 @@
+package tui
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	gmailapi "google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+
+	"go.withmatt.com/inbox/internal/config"
+	"go.withmatt.com/inbox/internal/gmail"
+)
+
+func TestGoToArchiveSearch(t *testing.T) {
+	var requests []url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Query())
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"threads": [{"id": "t1"}]}`))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	srv, err := gmailapi.NewService(ctx,
+		option.WithEndpoint(server.URL+"/"),
+		option.WithHTTPClient(server.Client()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theme, err := config.ResolveTheme(config.Theme{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(
+		ctx, []*gmail.Client{gmail.NewClient(srv, server.Client())}, []string{"me"}, nil,
+		theme, config.UIConfig{}.WithDefaults(), config.KeyMap{}, nil, false,
+		config.AttachmentConfig{}, nil,
+	)
+
+	tests := []struct {
+		name   string
+		run    func(Model) (Model, tea.Cmd)
+		query  string
+		labels []string
+	}{
+		{name: "archive", run: Model.goToArchive, query: archiveQuery},
+		{
+			name: "inbox search",
+			run: func(m Model) (Model, tea.Cmd) {
+				return m, m.submitSearch("from:a@b.example")
+			},
+			query:  "from:a@b.example",
+			labels: []string{"INBOX"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = nil
+			next, _ := tt.run(m)
+			msg := next.searchRemoteCmd(next.search.query, next.search.remoteGeneration)()
+			if loaded := msg.(searchRemoteLoadedMsg); loaded.err != nil || len(loaded.threads) != 1 {
+				t.Fatalf("search = %+v", loaded)
+			}
+			if len(requests) != 1 {
+				t.Fatalf("sent %d requests, want 1", len(requests))
+			}
+			if got := requests[0].Get("q"); got != tt.query {
+				t.Errorf("q = %q, want %q", got, tt.query)
+			}
+			if got := requests[0]["labelIds"]; !slices.Equal(got, tt.labels) {
+				t.Errorf("labelIds = %q, want %q", got, tt.labels)
+			}
+		})
+	}
+}
+
+func TestSearchesAllMail(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{query: archiveQuery, want: true},
+		{query: "in:sent invoice", want: true},
+		{query: "IN:Trash", want: true},
+		{query: "from:a@b.example", want: false},
+		{query: "within:reach", want: false},
+	}
+	for _, tt := range tests {
+		if got := searchesAllMail(tt.query); got != tt.want {
+			t.Errorf("searchesAllMail(%q) = %t, want %t", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"go.withmatt.com/inbox/internal/config"
+)
+
+// maxCount caps count prefixes so a stuck key can't queue absurd repeats
+const maxCount = 9999
+
+// leaderToken stands for the configured leader key in a binding
+const leaderToken = "<leader>"
+
+// keyMatch is the outcome of feeding one key into the sequence matcher.
+type keyMatch int
+
+const (
+	keyNoMatch keyMatch = iota
+	keyPending
+	keyMatched
+)
+
+// sequenceBinding is one key sequence bound to an action.
+type sequenceBinding struct {
+	keys   []string
+	action keyAction
+}
+
+// parseKeySequence splits a binding such as "g g" or "<leader> a" into keys.
+// A lone " " is the space key rather than an empty sequence.
+func parseKeySequence(binding, leader string) []string {
+	fields := strings.Fields(binding)
+	if len(fields) == 0 {
+		return []string{normalizeKey(binding)}
+	}
+	for i, field := range fields {
+		if field == leaderToken {
+			field = leader
+		}
+		fields[i] = normalizeKey(field)
+	}
+	return fields
+}
+
+// normalizeKey maps key names that bubbletea reports differently onto one
+// spelling.
+func normalizeKey(k string) string {
+	if k == "space" {
+		return " "
+	}
+	return k
+}
+
+func sequenceBindings(view viewState, km keyMap) []sequenceBinding {
+	var out []sequenceBinding
+	for _, a := range actionsForView(view) {
+		if a.binding == nil {
+			continue
+		}
+		binding := a.binding(km)
+		if !binding.Enabled() {
+			continue
+		}
+		for _, k := range binding.Keys() {
+			out = append(out, sequenceBinding{keys: parseKeySequence(k, km.leader), action: a})
+		}
+	}
+	return out
+}
+
+func hasKeyPrefix(keys, prefix []string) bool {
+	return len(keys) >= len(prefix) && slices.Equal(keys[:len(prefix)], prefix)
+}
+
+// resolveKey feeds msg into the pending key sequence. It returns keyPending
+// while a count or a prefix of some binding is being typed, and keyMatched
+// with the count (0 if none was typed) once a full sequence matches.
+func (m Model) resolveKey(msg tea.KeyMsg) (Model, keyAction, int, keyMatch) {
+	k := normalizeKey(msg.String())
+	pending := m.sequence.keys
+	if (len(pending) > 0 || m.sequence.count > 0) && k == "esc" {
+		m.sequence = sequenceState{}
+		return m, keyAction{}, 0, keyPending
+	}
+
+	bindings := sequenceBindings(m.currentView, m.keyMap())
+	if len(pending) == 0 && isCountKey(k, m.sequence.count) && !startsBinding(bindings, k) {
+		m.sequence.count = min(maxCount, m.sequence.count*10+int(k[0]-'0'))
+		return m, keyAction{}, 0, keyPending
+	}
+
+	seq := append(slices.Clone(pending), k)
+	var exact *sequenceBinding
+	for i, b := range bindings {
+		if !hasKeyPrefix(b.keys, seq) {
+			continue
+		}
+		if len(b.keys) > len(seq) {
+			// Longer sequences win; a binding that is also a prefix is
+			// reported as ambiguous when the keymap loads.
+			m.sequence.keys = seq
+			return m, keyAction{}, 0, keyPending
+		}
+		if exact == nil {
+			exact = &bindings[i]
+		}
+	}
+
+	count := m.sequence.count
+	m.sequence = sequenceState{}
+	if exact != nil {
+		return m, exact.action, count, keyMatched
+	}
+	if len(pending) > 0 {
+		// Like vim, an unknown continuation drops the prefix and the key is
+		// tried on its own.
+		return m.resolveKey(msg)
+	}
+	return m, keyAction{}, 0, keyNoMatch
+}
+
+func isCountKey(k string, count int) bool {
+	if len(k) != 1 || k[0] < '0' || k[0] > '9' {
+		return false
+	}
+	// A leading zero is a key, not a count
+	return k != "0" || count > 0
+}
+
+func startsBinding(bindings []sequenceBinding, k string) bool {
+	for _, b := range bindings {
+		if b.keys[0] == k {
+			return true
+		}
+	}
+	return false
+}
+
+// runAction runs a matched action, passing the count to actions that use one.
+func (m Model) runAction(a keyAction, count int) (Model, tea.Cmd) {
+	if count > 0 && a.counted != nil {
+		return a.counted(m, count)
+	}
+	return a.run(m)
+}
+
+// repeated turns an action into one that runs count times.
+func repeated(run func(Model) (Model, tea.Cmd)) func(Model, int) (Model, tea.Cmd) {
+	return func(m Model, count int) (Model, tea.Cmd) {
+		cmds := make([]tea.Cmd, 0, count)
+		for range count {
+			var cmd tea.Cmd
+			m, cmd = run(m)
+			cmds = append(cmds, cmd)
+		}
+		return m, tea.Batch(cmds...)
+	}
+}
+
+func (m *Model) sequencePending() bool {
+	return len(m.sequence.keys) > 0 || m.sequence.count > 0
+}
+
+// sequenceLabel renders the typed count and keys for the statusline.
+func (m *Model) sequenceLabel() string {
+	var b strings.Builder
+	if m.sequence.count > 0 {
+		fmt.Fprintf(&b, "%d", m.sequence.count)
+	}
+	for _, k := range m.sequence.keys {
+		b.WriteString(formatKeyLabel(k))
+	}
+	return b.String()
+}
+
+// renderWhichKeyModal lists the keys that can follow the pending prefix.
+func (m *Model) renderWhichKeyModal() string {
+	var b strings.Builder
+
+	modalWidth := max(30, min(50, m.ui.width-10))
+	titleStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center).
+		Bold(true)
+	b.WriteString(titleStyle.Render(m.sequenceLabel() + " …"))
+	b.WriteString("\n\n")
+
+	keyStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(m.theme.List.SelectedFg)).
+		Bold(true)
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+
+	type continuation struct {
+		keys string
+		desc string
+	}
+	var rows []continuation
+	seen := make(map[string]struct{})
+	keyWidth := 0
+	for _, binding := range sequenceBindings(m.currentView, m.keyMap()) {
+		if len(binding.keys) <= len(m.sequence.keys) ||
+			!hasKeyPrefix(binding.keys, m.sequence.keys) {
+			continue
+		}
+		var rest strings.Builder
+		for _, k := range binding.keys[len(m.sequence.keys):] {
+			rest.WriteString(formatKeyLabel(k))
+		}
+		keys := rest.String()
+		if _, ok := seen[keys]; ok {
+			continue
+		}
+		seen[keys] = struct{}{}
+		rows = append(rows, continuation{keys: keys, desc: binding.action.desc})
+		keyWidth = max(keyWidth, lipgloss.Width(keys))
+	}
+	for _, row := range rows {
+		pad := strings.Repeat(" ", keyWidth-lipgloss.Width(row.keys)+2)
+		b.WriteString(keyStyle.Render(row.keys) + pad + row.desc)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center)
+	b.WriteString(footerStyle.Render(dimStyle.Render("esc cancel")))
+
+	return b.String()
+}
+
+// keyMapProblems reports bindings that conflict or can never be reached
+// because another binding in the same view extends them.
+func keyMapProblems(cfg config.KeyMap) []string {
+	km := keyMapFromConfig(cfg)
+	var problems []string
+	for _, view := range []viewState{viewList, viewDetail} {
+		bindings := sequenceBindings(view, km)
+		for i, a := range bindings {
+			for _, b := range bindings[i+1:] {
+				if a.action.name == b.action.name {
+					continue
+				}
+				switch {
+				case slices.Equal(a.keys, b.keys):
+					problems = append(problems, fmt.Sprintf(
+						"%s: %q is bound to both %s and %s",
+						viewName(view), formatKeySequence(a.keys), a.action.name, b.action.name,
+					))
+				case hasKeyPrefix(b.keys, a.keys):
+					problems = append(problems, ambiguousProblem(view, a, b))
+				case hasKeyPrefix(a.keys, b.keys):
+					problems = append(problems, ambiguousProblem(view, b, a))
+				}
+			}
+		}
+	}
+	return append(problems, linkPickerProblems(km.linkPicker)...)
+}
+
+// linkPickerProblems reports link picker keys bound to two actions, or to a
+// digit, which always picks a link by number.
+func linkPickerProblems(km linkPickerKeyMap) []string {
+	bindings := []struct {
+		name    string
+		binding key.Binding
+	}{
+		{"up", km.Up},
+		{"down", km.Down},
+		{"open", km.Open},
+		{"copy", km.Copy},
+		{"toggle_original", km.ToggleOriginal},
+		{"close", km.Close},
+	}
+	var problems []string
+	bound := make(map[string]string)
+	for _, b := range bindings {
+		for _, k := range b.binding.Keys() {
+			if len(k) == 1 && k[0] >= '0' && k[0] <= '9' {
+				problems = append(problems, fmt.Sprintf(
+					"link picker: %q (%s) will never run, digits pick links by number", k, b.name,
+				))
+				continue
+			}
+			if other, ok := bound[k]; ok && other != b.name {
+				problems = append(problems, fmt.Sprintf(
+					"link picker: %q is bound to both %s and %s", formatKeyLabel(k), other, b.name,
+				))
+				continue
+			}
+			bound[k] = b.name
+		}
+	}
+	return problems
+}
+
+func ambiguousProblem(view viewState, short, long sequenceBinding) string {
+	return fmt.Sprintf(
+		"%s: %q (%s) is a prefix of %q (%s) and will never run",
+		viewName(view),
+		formatKeySequence(short.keys), short.action.name,
+		formatKeySequence(long.keys), long.action.name,
+	)
+}
+
+func formatKeySequence(keys []string) string {
+	var b strings.Builder
+	for _, k := range keys {
+		b.WriteString(formatKeyLabel(k))
+	}
+	return b.String()
+}
+
+func viewName(view viewState) string {
+	switch view {
+	case viewList:
+		return "list"
+	case viewDetail:
+		return "detail"
+	case viewAttachment:
+		return "attachment"
+	case viewImage:
+		return "image"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,239 @@
+package tui
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+
+	"go.withmatt.com/inbox/internal/config"
+)
+
+const (
+	// previewDebounce waits for the cursor to settle before loading a preview
+	previewDebounce = 150 * time.Millisecond
+	// splitMinHeight is the shortest terminal a horizontal split is used on
+	splitMinHeight = 24
+	// splitMinPane keeps either pane from collapsing at extreme ratios
+	splitMinPane = 20
+)
+
+type previewTickMsg struct {
+	generation int
+}
+
+// splitActive reports whether the list and preview are shown side by side.
+func (m *Model) splitActive() bool {
+	if m.currentView != viewList && m.currentView != viewDetail {
+		return false
+	}
+	switch m.uiConfig.Split {
+	case config.SplitVertical:
+		return m.ui.width >= m.uiConfig.SplitMinWidth
+	case config.SplitHorizontal:
+		return m.ui.height >= splitMinHeight
+	default:
+		return false
+	}
+}
+
+// detailVisible reports whether the thread is on screen, either in the
+// detail view or the preview pane.
+func (m *Model) detailVisible() bool {
+	return m.currentView == viewDetail || m.splitActive()
+}
+
+// splitSize divides total between the list and the preview, leaving one cell
+// for the separator.
+func (m *Model) splitSize(total int) (list, preview int) {
+	list = int(float64(total) * m.uiConfig.SplitRatio)
+	list = max(min(list, total-splitMinPane-1), min(splitMinPane, total))
+	return list, max(0, total-list-1)
+}
+
+// listPaneSize is the space available for thread cards, excluding the
+// statusline.
+func (m *Model) listPaneSize() (width, height int) {
+	height = max(0, m.ui.height-listFooterHeight)
+	if !m.splitActive() {
+		return m.ui.width, height
+	}
+	if m.uiConfig.Split == config.SplitHorizontal {
+		height, _ = m.splitSize(height)
+		return m.ui.width, height
+	}
+	width, _ = m.splitSize(m.ui.width)
+	return width, height
+}
+
+// detailPaneSize is the space available for the thread viewport.
+func (m *Model) detailPaneSize() (width, height int) {
+	height = detailViewportHeight(m.ui.height)
+	if !m.splitActive() {
+		return m.ui.width, height
+	}
+	if m.uiConfig.Split == config.SplitHorizontal {
+		_, height = m.splitSize(height)
+		return m.ui.width, max(1, height)
+	}
+	_, width = m.splitSize(m.ui.width)
+	return width, height
+}
+
+// sizeDetailViewport fits the viewport to the detail pane, re-rendering the
+// thread if its width changed.
+func (m *Model) sizeDetailViewport() {
+	width, height := m.detailPaneSize()
+	widthChanged := m.detail.viewport.Width != width
+	m.detail.viewport.Width = width
+	m.detail.viewport.Height = height
+	if widthChanged && len(m.detail.messages) > 0 {
+		m.detail.viewport.SetContent(m.renderThreadBody())
+	}
+}
+
+// syncPreview schedules a preview load when the cursor lands on a different
+// thread.
+func (m Model) syncPreview() (Model, tea.Cmd) {
+	if m.currentView != viewList || !m.splitActive() {
+		return m, nil
+	}
+	key := ""
+	if idx := m.selectedThreadIndex(); idx >= 0 && idx < len(m.inbox.threads) {
+		thread := m.inbox.threads[idx]
+		key = threadKey(thread.ThreadID, thread.AccountIndex)
+	}
+	if key == m.split.previewKey {
+		return m, nil
+	}
+	m.split.previewKey = key
+	m.split.previewGeneration++
+	if key == "" {
+		m.resetDetail()
+		return m, nil
+	}
+	gen := m.split.previewGeneration
+	return m, tea.Tick(previewDebounce, func(time.Time) tea.Msg {
+		return previewTickMsg{generation: gen}
+	})
+}
+
+func (m Model) handlePreviewTick(msg previewTickMsg) (tea.Model, tea.Cmd) {
+	if msg.generation != m.split.previewGeneration ||
+		m.currentView != viewList || !m.splitActive() {
+		return m, nil
+	}
+	idx := m.selectedThreadIndex()
+	if idx < 0 || idx >= len(m.inbox.threads) {
+		return m, nil
+	}
+	thread := m.inbox.threads[idx]
+	if threadKey(thread.ThreadID, thread.AccountIndex) != m.split.previewKey {
+		return m, nil
+	}
+	m.logf("Preview load account=%d thread=%s", thread.AccountIndex, thread.ThreadID)
+	m.resetDetail()
+	m.detail.currentThread = &thread
+	m.detail.loading = true
+	m.sizeDetailViewport()
+	return m, m.loadThreadCmd(m.detail.currentThread)
+}
+
+// previewShowing reports whether the preview already holds the thread at the
+// cursor.
+func (m *Model) previewShowing() bool {
+	current := m.detail.currentThread
+	if current == nil {
+		return false
+	}
+	idx := m.selectedThreadIndex()
+	if idx < 0 || idx >= len(m.inbox.threads) {
+		return false
+	}
+	thread := m.inbox.threads[idx]
+	return current.ThreadID == thread.ThreadID && current.AccountIndex == thread.AccountIndex
+}
+
+// focusPreview moves focus to the preview pane, opening the thread there
+// if the preview hasn't caught up with the cursor yet.
+func (m Model) focusPreview() (Model, tea.Cmd) {
+	if !m.splitActive() {
+		return m, nil
+	}
+	if !m.previewShowing() {
+		return m.openSelected()
+	}
+	m.currentView = viewDetail
+	cmds := []tea.Cmd{m.setWindowTitleCmd()}
+	thread := m.detail.currentThread
+	if thread.Unread {
+		thread.Unread = false
+		if idx := m.selectedThreadIndex(); idx >= 0 && idx < len(m.inbox.threads) {
+			m.inbox.threads[idx].Unread = false
+		}
+		cmds = append(cmds, m.markThreadUnreadCmd(thread.ThreadID, false, thread.AccountIndex))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// focusList moves focus back to the list, keeping the preview.
+func (m Model) focusList() (Model, tea.Cmd) {
+	if !m.splitActive() || m.currentView != viewDetail {
+		return m, nil
+	}
+	return m, m.exitDetailView()
+}
+
+func (m *Model) renderSplitView() string {
+	listWidth, listHeight := m.listPaneSize()
+	detailWidth, detailHeight := m.detailPaneSize()
+
+	listBody := fitPane(m.renderListBody(), listWidth, listHeight)
+	detailBody, detailFooter := m.renderDetailParts()
+	dimStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Status.Dim))
+	switch {
+	case m.detail.currentThread == nil:
+		detailBody = dimStyle.Render("No thread selected")
+	case m.detail.loading:
+		detailBody = dimStyle.Render("Loading...")
+	}
+	detailBody = fitPane(detailBody, detailWidth, detailHeight)
+
+	footer := m.renderListStatusline()
+	if m.currentView == viewDetail {
+		footer = detailFooter
+	}
+
+	var body string
+	if m.uiConfig.Split == config.SplitHorizontal {
+		separator := dimStyle.Render(strings.Repeat("─", m.ui.width))
+		body = lipgloss.JoinVertical(lipgloss.Left, listBody, separator, detailBody)
+	} else {
+		separator := dimStyle.Render(
+			strings.TrimSuffix(strings.Repeat("│\n", listHeight), "\n"),
+		)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, listBody, separator, detailBody)
+	}
+	return lipgloss.JoinVertical(lipgloss.Left, body, footer)
+}
+
+// fitPane clips or pads content to exactly width x height cells.
+func fitPane(content string, width, height int) string {
+	lines := strings.Split(content, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	for i, line := range lines {
+		line = truncate.String(line, uint(max(0, width)))
+		if pad := width - lipgloss.Width(line); pad > 0 {
+			line += strings.Repeat(" ", pad)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
@@ -1,6 +1,9 @@
 package tui
 
 import (
+	"context"
+	"sync/atomic"
+
 	md "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/spinner"
@@ -10,8 +13,12 @@ import (
 	"github.com/charmbracelet/lipgloss"
 	"go.dalton.dog/bubbleup"
 
+	"go.withmatt.com/inbox/internal/archive"
 	"go.withmatt.com/inbox/internal/config"
 	"go.withmatt.com/inbox/internal/gmail"
+	"go.withmatt.com/inbox/internal/image"
+	"go.withmatt.com/inbox/internal/mimetree"
+	"go.withmatt.com/inbox/internal/outbox"
 )
 
 type uiState struct {
@@ -25,6 +32,10 @@ type uiState struct {
 	err       error
 	focused   bool
 
+	// clipboard is an OSC 52 sequence the view writes for one frame, so it
+	// reaches the terminal through the renderer
+	clipboard string
+
 	debugDumpHashes map[string][32]byte
 }
 
@@ -42,6 +53,8 @@ type inboxState struct {
 	selected       map[string]struct{}
 	delete         deleteState
 	undo           undoState
+
+	refreshGeneration int
 }
 
 type detailState struct {
@@ -55,6 +68,23 @@ type detailState struct {
 	savedViewportYOffset int
 	rawLoading           map[string]bool
 	linkScanAttempted    map[string]bool
+	// quotesToggled flips the default quote folding per message
+	quotesToggled map[string]bool
+	// mimePending is the message whose MIME tree opens once its source loads
+	mimePending string
+	// allHeaders shows every header instead of the short header block
+	allHeaders bool
+	// unsubscribe is an unsubscribe email waiting for the user to confirm it
+	unsubscribe unsubscribeConfirm
+}
+
+// unsubscribeConfirm is an unsubscribe email built from a mailto link. It's
+// only sent once the user has seen who it goes to.
+type unsubscribeConfirm struct {
+	pending      bool
+	email        unsubscribeEmail
+	sender       string
+	accountIndex int
 }
 
 type attachmentsModalState struct {
@@ -65,6 +95,46 @@ type attachmentsModalState struct {
 	accountIndex   int
 	downloading    bool
 	loadingPreview bool
+	saveAs         saveAsState
+	download       downloadState
+	archive        archiveState
+	mime           mimeState
+}
+
+// mimeState is a message's MIME tree being inspected in the modal, parents
+// listed before their children.
+type mimeState struct {
+	active      bool
+	parts       []*mimetree.Part
+	selectedIdx int
+}
+
+// archiveState is an archive attachment being browsed in the modal. The
+// archive is kept in a temp file so entries can be read on demand.
+type archiveState struct {
+	active      bool
+	filename    string
+	format      archive.Format
+	path        string
+	dir         string
+	entries     []archive.Entry
+	selectedIdx int
+}
+
+// downloadState tracks the attachment being written to disk so the modal can
+// show progress and esc can stop it.
+type downloadState struct {
+	// written is updated from the download goroutine
+	written *atomic.Int64
+	total   int64
+	cancel  context.CancelFunc
+}
+
+// saveAsState is the path prompt for saving an attachment somewhere other
+// than the download directory.
+type saveAsState struct {
+	active bool
+	input  textinput.Model
 }
 
 type attachmentPreviewState struct {
@@ -86,6 +156,70 @@ type imageState struct {
 	filename   string
 	size       int64
 	needsClear bool
+	// width and height are the image's size in pixels, read from its header
+	width  int
+	height int
+	// err is why the image can't be shown
+	err error
+	// protocol is detected once at startup
+	protocol graphicsProtocol
+	// encoded caches the rendered image for each display size and frame,
+	// for the sizes in encodedBoxes, oldest first
+	encoded      map[imageKey]string
+	encodedBoxes []imageBox
+	// encoding is set while a rendering is made in the background
+	encoding bool
+	// generation changes with every image shown, dropping late encodes
+	generation int
+
+	// Animated GIF playback. totalFrames is how many the GIF has, more than
+	// len(frames) when the rest didn't fit in memory
+	frames              []image.Frame
+	totalFrames         int
+	frame               int
+	paused              bool
+	animationGeneration int
+}
+
+// imageKey identifies one cached rendering. frame is -1 when every frame is
+// encoded together.
+type imageKey struct {
+	box   imageBox
+	frame int
+}
+
+// imageBox is the cell area an image is fitted to, along with the cell size
+// in pixels.
+type imageBox struct {
+	cols            int
+	rows            int
+	pixelsPerColumn int
+	pixelsPerRow    int
+}
+
+// galleryState steps the image viewer through every image in a thread.
+type galleryState struct {
+	active       bool
+	items        []galleryItem
+	index        int
+	accountIndex int
+	// generation drops loads that finish after the gallery was closed
+	generation int
+	// waiting is set while the selected image is still loading
+	waiting bool
+	status  string
+	// data, thumbs, loading and failed are keyed by item index and shared
+	// between model copies. Only images near the selected one keep their
+	// data; the rest keep just a thumbnail.
+	data    map[int]string
+	thumbs  map[int]string
+	loading map[int]bool
+	failed  map[int]bool
+}
+
+type galleryItem struct {
+	messageID string
+	image     gmail.Attachment
 }
 
 type renderersState struct {
@@ -93,6 +227,13 @@ type renderersState struct {
 	glamourWidth    int
 	loggedHyperlink bool
 	htmlConverter   *md.Converter
+
+	// bodies caches rendered message bodies across redraws
+	bodies *bodyCache
+	// themeGeneration and linkGeneration are bumped when the theme changes
+	// or link scans resolve new URLs, retiring cached bodies
+	themeGeneration int
+	linkGeneration  int
 }
 
 type searchState struct {
@@ -104,6 +245,54 @@ type searchState struct {
 	remoteLoading    bool
 	remoteGeneration int
 	remoteKeys       map[string]struct{}
+
+	// accountFilter limits the list to one account, or allAccounts
+	accountFilter int
+}
+
+type paletteState struct {
+	show   bool
+	input  textinput.Model
+	cursor int
+	items  []paletteItem
+}
+
+// linkPickerState is the list of links in a message, numbered so one can
+// be opened or copied on terminals without clickable links.
+type linkPickerState struct {
+	show        bool
+	links       []pickerLink
+	selectedIdx int
+	// number holds the digits typed so far to jump to a link
+	number string
+	// showOriginal lists links as written instead of unwrapped
+	showOriginal bool
+}
+
+type commandState struct {
+	active bool
+	input  textinput.Model
+}
+
+// splitState tracks which thread the preview pane should show.
+type splitState struct {
+	previewKey        string
+	previewGeneration int
+}
+
+// sequenceState holds a partially typed key sequence and its count prefix.
+type sequenceState struct {
+	keys  []string
+	count int
+}
+
+type outboxState struct {
+	queue          *outbox.Queue
+	pending        []outbox.Op
+	failed         []outbox.Op
+	replaying      bool
+	retryScheduled bool
+	showFailed     bool
 }
 
 type threadRef struct {
@@ -116,6 +305,8 @@ type deleteState struct {
 	inProgress bool
 	action     deleteAction
 	targets    []threadRef
+	// sender is set when the targets are every thread from one sender
+	sender string
 }
 
 type deleteAction int
@@ -149,12 +340,12 @@ func newDetailState() detailState {
 		messageViewMode:   viewModeHTML,
 		rawLoading:        make(map[string]bool),
 		linkScanAttempted: make(map[string]bool),
+		quotesToggled:     make(map[string]bool),
 	}
 }
 
 func newSearchState(theme config.Theme) searchState {
-	input := textinput.New()
-	input.Prompt = "/ "
+	input := newStatusInput(theme, "/ ")
 	input.ShowSuggestions = true
 	input.SetSuggestions([]string{
 		"has:attachment",
@@ -170,8 +361,34 @@ func newSearchState(theme config.Theme) searchState {
 		"in:snoozed",
 		"filename:",
 	})
+	return searchState{input: input, accountFilter: allAccounts}
+}
+
+func newPaletteState() paletteState {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "type to filter"
+	input.CharLimit = 100
+	return paletteState{input: input}
+}
+
+func newCommandState(theme config.Theme) commandState {
+	input := newStatusInput(theme, ": ")
+	input.ShowSuggestions = true
+	return commandState{input: input}
+}
+
+// newStatusInput builds a text input styled to sit inside the statusline.
+func newStatusInput(theme config.Theme, prompt string) textinput.Model {
+	input := textinput.New()
+	input.Prompt = prompt
 	input.CharLimit = 200
 	input.Blur()
+	styleStatusInput(&input, theme)
+	return input
+}
+
+func styleStatusInput(input *textinput.Model, theme config.Theme) {
 	statusStyle := lipgloss.NewStyle().
 		Background(lipgloss.Color(theme.Status.Bg)).
 		Foreground(lipgloss.Color(theme.Status.Fg)).
@@ -185,7 +402,6 @@ func newSearchState(theme config.Theme) searchState {
 	input.Cursor.Style = lipgloss.NewStyle().
 		Background(lipgloss.Color(theme.Status.Fg)).
 		Foreground(lipgloss.Color(theme.Status.Bg))
-	return searchState{input: input}
 }
 
 func (m *Model) resetDetail() {
@@ -198,6 +414,9 @@ func (m *Model) resetDetail() {
 	m.detail.savedViewportYOffset = 0
 	m.detail.rawLoading = make(map[string]bool)
 	m.detail.linkScanAttempted = make(map[string]bool)
+	m.detail.quotesToggled = make(map[string]bool)
+	m.detail.mimePending = ""
+	m.detail.unsubscribe = unsubscribeConfirm{}
 }
 
 func (m *Model) resetAttachmentPreview() {
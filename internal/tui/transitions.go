@@ -9,9 +9,12 @@ import (
 )
 
 func (m *Model) openThread(thread gmail.Thread) tea.Cmd {
+	m.resetDetail()
 	m.detail.currentThread = &thread
 	m.currentView = viewDetail
 	m.detail.loading = true
+	m.split.previewKey = threadKey(thread.ThreadID, thread.AccountIndex)
+	m.sizeDetailViewport()
 
 	var cmds []tea.Cmd
 	cmds = append(cmds, m.setWindowTitleCmd())
@@ -40,6 +43,10 @@ func (m *Model) openThread(thread gmail.Thread) tea.Cmd {
 
 func (m *Model) exitDetailView() tea.Cmd {
 	m.currentView = viewList
+	if m.splitActive() {
+		// The thread stays in the preview pane
+		return m.setWindowTitleCmd()
+	}
 	m.resetDetail()
 	return m.setWindowTitleCmd()
 }
@@ -89,33 +96,59 @@ func (m *Model) enterAttachmentView(msg attachmentLoadedMsg) error {
 
 func (m *Model) exitAttachmentView() tea.Cmd {
 	m.currentView = viewDetail
+	// Previews of archive entries and MIME parts go back to their listing
+	m.attachments.modal.show = m.attachments.modal.archive.active || m.attachments.modal.mime.active
 	m.resetAttachmentPreview()
-	m.detail.viewport.Width = m.ui.width
-	m.detail.viewport.Height = detailViewportHeight(m.ui.height)
+	m.sizeDetailViewport()
 	body := m.renderThreadBody()
 	m.detail.viewport.SetContent(body)
 	m.detail.viewport.SetYOffset(m.detail.savedViewportYOffset)
 	return tea.Batch(tea.ClearScreen, m.setWindowTitleCmd())
 }
 
-func (m *Model) enterImageView(msg attachmentLoadedMsg) {
+func (m *Model) enterImageView(msg attachmentLoadedMsg) tea.Cmd {
 	m.image.data = msg.data
 	m.image.mimeType = msg.mimeType
 	m.image.filename = msg.filename
 	m.image.size = msg.size
+	m.image.width, m.image.height, m.image.err = imageSize(msg.data, msg.mimeType)
+	m.image.encoded = make(map[imageKey]string)
+	m.image.encodedBoxes = nil
+	m.image.encoding = false
+	m.image.generation++
+	// The gallery replaces one image with the next, so drop any animation
+	m.image.frames = nil
+	m.image.totalFrames = 0
+	m.image.frame = 0
+	m.image.animationGeneration++
 	m.currentView = viewImage
+	return m.startAnimation()
 }
 
 func (m *Model) exitImageView() tea.Cmd {
 	m.currentView = viewDetail
+	// Previews of archive entries and MIME parts go back to their listing
+	m.attachments.modal.show = m.attachments.modal.archive.active || m.attachments.modal.mime.active
+	m.sizeDetailViewport()
 	m.image.data = ""
 	m.image.mimeType = ""
 	m.image.filename = ""
 	m.image.size = 0
+	m.image.encoded = nil
+	m.image.encodedBoxes = nil
+	m.image.encoding = false
+	m.image.err = nil
+	m.image.generation++
+	m.image.frames = nil
+	m.image.totalFrames = 0
+	m.image.frame = 0
+	m.image.paused = false
+	m.image.animationGeneration++
 	m.image.needsClear = true
+	m.gallery = galleryState{generation: m.gallery.generation}
 
 	clearFlagCmd := func() tea.Msg {
 		return clearImageFlagMsg{}
 	}
-	return tea.Batch(clearKittyImagesCmd(), clearFlagCmd, m.setWindowTitleCmd())
+	return tea.Batch(clearImagesCmd(m.image.protocol), clearFlagCmd, m.setWindowTitleCmd())
 }
@@ -2,16 +2,25 @@ package tui
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	md "github.com/JohannesKaufmann/html-to-markdown/v2/converter"
 	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/base"
 	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/commonmark"
+	"github.com/JohannesKaufmann/html-to-markdown/v2/plugin/table"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/glamour"
+	"github.com/pkg/browser"
 
 	"go.withmatt.com/inbox/internal/config"
 	"go.withmatt.com/inbox/internal/gmail"
 	"go.withmatt.com/inbox/internal/links"
+	"go.withmatt.com/inbox/internal/mailcap"
+	"go.withmatt.com/inbox/internal/outbox"
 )
 
 type viewState int
@@ -40,14 +49,31 @@ type Model struct {
 	detail       detailState
 	attachments  attachmentState
 	image        imageState
+	gallery      galleryState
 	renderers    renderersState
 	search       searchState
+	outbox       outboxState
+	palette      paletteState
+	linkPicker   linkPickerState
+	command      commandState
+	sequence     sequenceState
+	split        splitState
 	theme        config.Theme
 	uiConfig     config.UIConfig
 	keyMapCfg    config.KeyMap
 	linkResolver *links.Resolver
 	linkAutoScan bool
 
+	// attachmentConfig sets where attachments are saved
+	attachmentConfig config.AttachmentConfig
+	// mailcap finds external programs for opening attachments
+	mailcap *mailcap.Mailcap
+
+	// location is the time zone list dates are shown in
+	location *time.Location
+	// labelNames maps account index to user label IDs and names
+	labelNames map[int]map[string]string
+
 	// Gmail clients for fetching data (one per account)
 	clients       []*gmail.Client
 	accountNames  []string // Account names corresponding to clients
@@ -68,6 +94,8 @@ func New(
 	keyMapCfg config.KeyMap,
 	linkResolver *links.Resolver,
 	linkAutoScan bool,
+	attachmentConfig config.AttachmentConfig,
+	actionQueue *outbox.Queue,
 ) Model {
 	ui := newUIState()
 	ui.help = newHelpModel(theme)
@@ -85,6 +113,12 @@ func New(
 				commonmark.WithEmDelimiter("_"),
 				commonmark.WithCodeBlockFence("```"),
 			),
+			// Only data tables are left by cleanHTMLForConversion
+			table.NewTablePlugin(
+				table.WithHeaderPromotion(true),
+				table.WithSkipEmptyRows(true),
+				table.WithSpanCellBehavior(table.SpanBehaviorEmpty),
+			),
 		),
 		md.WithEscapeMode(md.EscapeModeDisabled),
 	)
@@ -101,6 +135,9 @@ func New(
 		},
 		detail:        newDetailState(),
 		search:        newSearchState(theme),
+		outbox:        outboxState{queue: actionQueue},
+		palette:       newPaletteState(),
+		command:       newCommandState(theme),
 		theme:         theme,
 		uiConfig:      uiConfig,
 		keyMapCfg:     keyMapCfg,
@@ -109,14 +146,33 @@ func New(
 		clients:       clients,
 		accountNames:  accountNames,
 		accountBadges: accountBadges,
+		labelNames:    make(map[int]map[string]string),
 		renderers: renderersState{
 			glamourRenderer: r,
 			glamourWidth:    80,
 			htmlConverter:   converter,
+			bodies:          newBodyCache(maxBodyCacheBytes),
 		},
-		ctx: ctx,
+		attachmentConfig: attachmentConfig,
+		ctx:              ctx,
 	}
 	model.logf("debug logging enabled")
+	location, listProblems := resolveListConfig(&model.uiConfig.List)
+	model.location = location
+	problems := append(keyMapProblems(keyMapCfg), listProblems...)
+	handlers, err := mailcap.Load(attachmentConfig.Handlers, mailcap.DefaultPaths())
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("mailcap: %v", err))
+		handlers = &mailcap.Mailcap{}
+	}
+	model.mailcap = handlers
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			model.logf("config: %s", problem)
+		}
+		model.ui.err = errors.New("config problems:\n\n" + strings.Join(problems, "\n"))
+		model.ui.showError = true
+	}
 	return model
 }
 
@@ -140,6 +196,8 @@ func (m Model) Init() tea.Cmd {
 		m.ui.alert.Init(),
 		m.autoRefreshCmd(),
 		m.setWindowTitleCmd(),
+		m.loadOutboxCmd(true),
+		m.loadLabelNamesCmd(),
 	)
 }
 
@@ -154,24 +212,53 @@ func Run(
 	keyMapCfg config.KeyMap,
 	linkResolver *links.Resolver,
 	linkAutoScan bool,
+	attachmentConfig config.AttachmentConfig,
+	actionQueue *outbox.Queue,
 ) error {
+	model := New(
+		ctx,
+		clients,
+		accountNames,
+		accountBadges,
+		theme,
+		uiConfig,
+		keyMapCfg,
+		linkResolver,
+		linkAutoScan,
+		attachmentConfig,
+		actionQueue,
+	)
+	model.image.protocol = detectGraphicsProtocol(uiConfig.ImageProtocol)
+	model.logf("image protocol: %s", model.image.protocol)
+	// The browser's own output would land on top of the UI. It's set once
+	// here because links are opened from commands running concurrently.
+	browser.Stdout, browser.Stderr = io.Discard, io.Discard
 	p := tea.NewProgram(
-		New(
-			ctx,
-			clients,
-			accountNames,
-			accountBadges,
-			theme,
-			uiConfig,
-			keyMapCfg,
-			linkResolver,
-			linkAutoScan,
-		),
+		model,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
 		tea.WithReportFocus(),
 		tea.WithContext(ctx),
 	)
-	_, err := p.Run()
+	final, err := p.Run()
+	// However the program quit, an open archive's temp copy goes with it
+	if final, ok := final.(Model); ok {
+		final.closeArchive()
+	}
 	return err
 }
+
+// applyTheme swaps the active theme and rebuilds everything styled from it.
+func (m *Model) applyTheme(theme config.Theme) {
+	m.theme = theme
+	m.ui.help = newHelpModel(theme)
+	m.ui.alert = newAlertModel(theme, m.ui.width)
+	styleStatusInput(&m.search.input, theme)
+	styleStatusInput(&m.command.input, theme)
+	// Force a new glamour renderer on the next render
+	m.renderers.glamourRenderer = nil
+	m.renderers.themeGeneration++
+	if m.detailVisible() && m.detail.currentThread != nil {
+		m.detail.viewport.SetContent(m.renderThreadBody())
+	}
+}
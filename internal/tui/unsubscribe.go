@@ -0,0 +1,319 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/pkg/browser"
+
+	"go.withmatt.com/inbox/internal/gmail"
+)
+
+// maxSenderThreads caps how many of a sender's inbox threads an
+// unsubscribe offers to archive.
+const maxSenderThreads = 500
+
+type unsubscribeMethod int
+
+const (
+	unsubscribeOneClick unsubscribeMethod = iota
+	unsubscribeMailto
+	unsubscribeBrowser
+)
+
+type unsubscribedMsg struct {
+	// sender is the bare From address, empty when it couldn't be parsed
+	sender       string
+	method       unsubscribeMethod
+	accountIndex int
+	err          error
+}
+
+type senderThreadsMsg struct {
+	sender string
+	refs   []threadRef
+	err    error
+}
+
+// unsubscribeEmail is the message a mailto unsubscribe link asks for.
+type unsubscribeEmail struct {
+	to      string
+	subject string
+	raw     []byte
+}
+
+// unsubscribe follows the selected message's List-Unsubscribe header,
+// preferring a one-click POST, then an email, then opening the page. An
+// email is only sent after the user confirms the recipient.
+func (m Model) unsubscribe() (Model, tea.Cmd) {
+	if m.detail.currentThread == nil ||
+		m.detail.selectedMessageIdx < 0 || m.detail.selectedMessageIdx >= len(m.detail.messages) {
+		return m, nil
+	}
+	msg := m.detail.messages[m.detail.selectedMessageIdx]
+	if len(msg.Unsubscribe) == 0 {
+		return m, m.toastCmd("No unsubscribe link in this message")
+	}
+	accountIndex := m.detail.currentThread.AccountIndex
+	if accountIndex < 0 || accountIndex >= len(m.clients) {
+		return m, nil
+	}
+	link, method := unsubscribeLink(msg)
+	m.logf("Unsubscribe message=%s method=%d", msg.ID, method)
+	if method == unsubscribeMailto {
+		email, err := mailtoMessage(link)
+		if err != nil {
+			m.ui.err = fmt.Errorf("failed to unsubscribe: %w", err)
+			m.ui.showError = true
+			return m, nil
+		}
+		m.detail.unsubscribe = unsubscribeConfirm{
+			pending:      true,
+			email:        email,
+			sender:       msg.FromAddress,
+			accountIndex: accountIndex,
+		}
+		return m, nil
+	}
+	return m, m.unsubscribeCmd(method, link, unsubscribeEmail{}, msg.FromAddress, accountIndex)
+}
+
+// unsubscribeLink picks the link to use. RFC 8058 only allows the POST
+// to https links.
+func unsubscribeLink(msg gmail.Message) (string, unsubscribeMethod) {
+	var web string
+	for _, link := range msg.Unsubscribe {
+		lower := strings.ToLower(link)
+		if msg.UnsubscribeOneClick && strings.HasPrefix(lower, "https://") {
+			return link, unsubscribeOneClick
+		}
+		if web == "" && !strings.HasPrefix(lower, "mailto:") {
+			web = link
+		}
+	}
+	for _, link := range msg.Unsubscribe {
+		if strings.HasPrefix(strings.ToLower(link), "mailto:") {
+			return link, unsubscribeMailto
+		}
+	}
+	return web, unsubscribeBrowser
+}
+
+func (m *Model) unsubscribeCmd(
+	method unsubscribeMethod,
+	link string,
+	email unsubscribeEmail,
+	sender string,
+	accountIndex int,
+) tea.Cmd {
+	client := m.clients[accountIndex]
+	httpClient := m.linkResolver.HTTPClient()
+	return func() tea.Msg {
+		var err error
+		switch method {
+		case unsubscribeOneClick:
+			err = postOneClick(m.ctx, httpClient, link)
+		case unsubscribeMailto:
+			err = client.SendMessage(m.ctx, email.raw)
+		case unsubscribeBrowser:
+			err = browser.OpenURL(link)
+		}
+		return unsubscribedMsg{
+			sender:       sender,
+			method:       method,
+			accountIndex: accountIndex,
+			err:          err,
+		}
+	}
+}
+
+// handleUnsubscribeConfirmKey sends the pending unsubscribe email on y and
+// drops it on n.
+func (m Model) handleUnsubscribeConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		confirm := m.detail.unsubscribe
+		m.detail.unsubscribe = unsubscribeConfirm{}
+		if confirm.accountIndex < 0 || confirm.accountIndex >= len(m.clients) {
+			return m, nil
+		}
+		m.logf("Unsubscribe email to=%s", confirm.email.to)
+		return m, m.unsubscribeCmd(
+			unsubscribeMailto, "", confirm.email, confirm.sender, confirm.accountIndex,
+		)
+	case "n", "N", "esc":
+		m.detail.unsubscribe = unsubscribeConfirm{}
+		return m, nil
+	default:
+		return m, nil
+	}
+}
+
+func postOneClick(ctx context.Context, client *http.Client, link string) error {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, link, strings.NewReader("List-Unsubscribe=One-Click"),
+	)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	// RFC 8058 endpoints must not redirect, and the resolver's client doesn't
+	// follow redirects, so only a 2xx means it worked
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unsubscribe request failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// mailtoMessage builds the email a mailto link asks for, using the subject
+// and body it names if any. The link comes from the sender, so it may only
+// name a single recipient, and to, cc and bcc in its query are ignored.
+func mailtoMessage(link string) (unsubscribeEmail, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return unsubscribeEmail{}, err
+	}
+	to := u.Opaque
+	if to == "" {
+		to = u.Path
+	}
+	if to, err = url.PathUnescape(to); err != nil {
+		return unsubscribeEmail{}, err
+	}
+	if strings.ContainsAny(to, "\r\n") {
+		return unsubscribeEmail{}, fmt.Errorf("invalid address in %s", link)
+	}
+	addrs, err := mail.ParseAddressList(to)
+	if err != nil {
+		return unsubscribeEmail{}, fmt.Errorf("invalid address in %s: %w", link, err)
+	}
+	if len(addrs) != 1 {
+		return unsubscribeEmail{}, fmt.Errorf("%s must name exactly one address", link)
+	}
+	query := u.Query()
+	subject := query.Get("subject")
+	if subject == "" {
+		subject = "unsubscribe"
+	}
+	if strings.ContainsAny(subject, "\r\n") {
+		return unsubscribeEmail{}, fmt.Errorf("invalid subject in %s", link)
+	}
+	body := query.Get("body")
+	if body == "" {
+		body = "unsubscribe"
+	}
+	email := unsubscribeEmail{to: addrs[0].Address, subject: subject}
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\r\n", (&mail.Address{Address: email.to}).String())
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	email.raw = []byte(b.String())
+	return email, nil
+}
+
+func (m Model) handleUnsubscribed(msg unsubscribedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.ui.err = fmt.Errorf("failed to unsubscribe: %w", msg.err)
+		m.ui.showError = true
+		return m, nil
+	}
+	var toast string
+	switch msg.method {
+	case unsubscribeOneClick:
+		toast = "Unsubscribed"
+	case unsubscribeMailto:
+		toast = "Sent unsubscribe request"
+	case unsubscribeBrowser:
+		toast = "Opened unsubscribe page"
+	}
+	// Without a clean address a search could match someone else's threads
+	if msg.sender == "" {
+		return m, m.toastCmd(toast)
+	}
+	return m, tea.Batch(m.toastCmd(toast), m.senderThreadsCmd(msg.sender, msg.accountIndex))
+}
+
+// senderThreadsCmd finds the sender's threads still in the inbox, so they
+// can be archived along with the subscription.
+func (m *Model) senderThreadsCmd(address string, accountIndex int) tea.Cmd {
+	client := m.clients[accountIndex]
+	return func() tea.Msg {
+		var refs []threadRef
+		pageToken := ""
+		for len(refs) < maxSenderThreads {
+			res, err := client.SearchInbox(m.ctx, "from:"+address, 100, pageToken)
+			if err != nil {
+				return senderThreadsMsg{sender: address, err: err}
+			}
+			for _, thread := range res.Threads {
+				refs = append(refs, threadRef{threadID: thread.ThreadID, accountIndex: accountIndex})
+			}
+			if res.NextPageToken == "" {
+				break
+			}
+			pageToken = res.NextPageToken
+		}
+		if len(refs) > maxSenderThreads {
+			refs = refs[:maxSenderThreads]
+		}
+		return senderThreadsMsg{sender: address, refs: refs}
+	}
+}
+
+func (m Model) handleSenderThreads(msg senderThreadsMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		m.logf("Sender search failed sender=%s err=%v", msg.sender, msg.err)
+		return m, nil
+	}
+	if len(msg.refs) == 0 || m.inbox.delete.pending || m.inbox.delete.inProgress {
+		return m, nil
+	}
+	m.inbox.delete.pending = true
+	m.inbox.delete.targets = msg.refs
+	m.inbox.delete.action = deleteActionArchive
+	m.inbox.delete.sender = msg.sender
+	return m, nil
+}
+
+func (m *Model) renderUnsubscribeModal() string {
+	var b strings.Builder
+	email := m.detail.unsubscribe.email
+
+	modalWidth := 60
+	titleStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center).
+		Bold(true)
+	b.WriteString(titleStyle.Render("Send Unsubscribe Email"))
+	b.WriteString("\n\n")
+
+	maxWidth := modalWidth - 4
+	b.WriteString("The sender asks for an email to unsubscribe. Send it?\n")
+	b.WriteString("\nTo: " + truncateToWidth(email.to, maxWidth))
+	b.WriteString("\nSubject: " + truncateToWidth(email.subject, maxWidth))
+
+	b.WriteString("\n\n")
+	footerStyle := lipgloss.NewStyle().
+		Width(modalWidth).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+	b.WriteString(footerStyle.Render("y send • n cancel"))
+
+	return b.String()
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMailtoMessage(t *testing.T) {
+	tests := []struct {
+		link    string
+		to      string
+		subject string
+		wantErr bool
+	}{
+		{link: "mailto:unsub@list.example", to: "unsub@list.example", subject: "unsubscribe"},
+		{link: "mailto:list%2Bu@b.example?subject=Remove%20me", to: "list+u@b.example", subject: "Remove me"},
+		{link: "mailto:a@b.example?bcc=x@y.example&to=z@y.example", to: "a@b.example", subject: "unsubscribe"},
+		{link: "mailto:a@b.example%0D%0ABcc:x@y.example", wantErr: true},
+		{link: "mailto:a@b.example,x@y.example", wantErr: true},
+		{link: "mailto:a@b.example?subject=hi%0D%0ABcc:x@y.example", wantErr: true},
+		{link: "mailto:?subject=hi", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.link, func(t *testing.T) {
+			email, err := mailtoMessage(tt.link)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("mailtoMessage accepted %q: %q", tt.link, email.raw)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if email.to != tt.to || email.subject != tt.subject {
+				t.Errorf("to, subject = %q, %q, want %q, %q", email.to, email.subject, tt.to, tt.subject)
+			}
+			header, _, _ := strings.Cut(string(email.raw), "\r\n\r\n")
+			for _, line := range strings.Split(header, "\r\n") {
+				name, _, _ := strings.Cut(line, ":")
+				switch name {
+				case "To", "Subject", "MIME-Version", "Content-Type":
+				default:
+					t.Errorf("unexpected header line %q", line)
+				}
+			}
+		})
+	}
+}
+
+func TestPostOneClick(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{status: http.StatusOK},
+		{status: http.StatusNoContent},
+		{status: http.StatusFound, wantErr: true},
+		{status: http.StatusSeeOther, wantErr: true},
+		{status: http.StatusNotFound, wantErr: true},
+		{status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost {
+					t.Errorf("method = %s, want POST", r.Method)
+				}
+				if body, _ := io.ReadAll(r.Body); string(body) != "List-Unsubscribe=One-Click" {
+					t.Errorf("body = %q", body)
+				}
+				if tt.status/100 == 3 {
+					w.Header().Set("Location", "/landing")
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			// Like the resolver's client, don't follow redirects
+			client := server.Client()
+			client.CheckRedirect = func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			}
+
+			err := postOneClick(context.Background(), client, server.URL+"/unsubscribe")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("postOneClick with %d: err = %v, wantErr %t", tt.status, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -34,28 +34,72 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case threadLoadedMsg:
 		model, cmd = m.handleThreadLoaded(msg)
 	case threadMarkedMsg:
-		model = m.handleThreadMarked(msg)
+		model, cmd = m.handleThreadMarked(msg)
 	case threadsActionMsg:
 		model, cmd = m.handleThreadsAction(msg)
+	case threadsLabeledMsg:
+		model, cmd = m.handleThreadsLabeled(msg)
 	case threadsUndoMsg:
-		model = m.handleThreadsUndo(msg)
+		model, cmd = m.handleThreadsUndo(msg)
 	case attachmentDownloadedMsg:
-		model = m.handleAttachmentDownloaded(msg)
+		model, cmd = m.handleAttachmentDownloaded(msg)
+	case threadAttachmentsSavedMsg:
+		model, cmd = m.handleThreadAttachmentsSaved(msg)
+	case attachmentOpenReadyMsg:
+		model, cmd = m.handleAttachmentOpenReady(msg)
+	case externalHandlerExitedMsg:
+		model, cmd = m.handleExternalHandlerExited(msg)
+	case archiveOpenedMsg:
+		model, cmd = m.handleArchiveOpened(msg)
+	case archiveEntryExtractedMsg:
+		model, cmd = m.handleArchiveEntryExtracted(msg)
+	case mimeParsedMsg:
+		model, cmd = m.handleMimeParsed(msg)
+	case mimePartSavedMsg:
+		model, cmd = m.handleMimePartSaved(msg)
+	case clipboardSentMsg:
+		model, cmd = m.handleClipboardSent(msg)
+	case linkOpenedMsg:
+		model, cmd = m.handleLinkOpened(msg)
+	case unsubscribedMsg:
+		model, cmd = m.handleUnsubscribed(msg)
+	case senderThreadsMsg:
+		model, cmd = m.handleSenderThreads(msg)
 	case clearImageFlagMsg:
 		m.image.needsClear = false
 		model = m
 	case attachmentLoadedMsg:
 		model, cmd = m.handleAttachmentLoaded(msg)
 	case messageRawLoadedMsg:
-		model = m.handleMessageRawLoaded(msg)
+		model, cmd = m.handleMessageRawLoaded(msg)
 	case searchDebounceMsg:
 		model, cmd = m.handleSearchDebounce(msg)
 	case searchRemoteLoadedMsg:
 		model, cmd = m.handleSearchRemoteLoaded(msg)
 	case autoRefreshMsg:
-		model, cmd = m.handleAutoRefresh()
+		model, cmd = m.handleAutoRefresh(msg)
+	case outboxLoadedMsg:
+		model, cmd = m.handleOutboxLoaded(msg)
+	case outboxReplayedMsg:
+		model, cmd = m.handleOutboxReplayed(msg)
+	case outboxRetryMsg:
+		model, cmd = m.handleOutboxRetry()
+	case imageEncodedMsg:
+		model, cmd = m.handleImageEncoded(msg)
+	case framesDecodedMsg:
+		model, cmd = m.handleFramesDecoded(msg)
+	case animationTickMsg:
+		model, cmd = m.handleAnimationTick(msg)
+	case galleryImageLoadedMsg:
+		model, cmd = m.handleGalleryImageLoaded(msg)
+	case gallerySavedMsg:
+		model, cmd = m.handleGallerySaved(msg)
+	case previewTickMsg:
+		model, cmd = m.handlePreviewTick(msg)
+	case labelNamesLoadedMsg:
+		model = m.handleLabelNamesLoaded(msg)
 	case linkScanFinishedMsg:
-		model = m.handleLinkScanFinished(msg)
+		model, cmd = m.handleLinkScanFinished(msg)
 	case tea.WindowSizeMsg:
 		model, cmd = m.handleWindowSize(msg)
 	default:
@@ -71,5 +115,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return model, cmd
 	}
 	updatedModel, alertCmd := updatedModel.updateAlerts(msg)
-	return updatedModel, tea.Batch(cmd, alertCmd)
+	updatedModel, previewCmd := updatedModel.syncPreview()
+	updatedModel, imageCmd := updatedModel.syncImage()
+	return updatedModel, tea.Batch(cmd, alertCmd, previewCmd, imageCmd)
 }
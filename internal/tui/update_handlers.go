@@ -1,15 +1,17 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"slices"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
-	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 
+	"go.withmatt.com/inbox/internal/archive"
 	"go.withmatt.com/inbox/internal/gmail"
 )
 
@@ -35,6 +37,18 @@ func (m Model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.ui.err = nil
 		return m, nil
 	}
+	if m.palette.show {
+		return m.handlePaletteKey(msg)
+	}
+	if m.command.active {
+		return m.handleCommandKey(msg)
+	}
+	if m.outbox.showFailed {
+		return m.handleFailedActionsKey(msg)
+	}
+	if m.linkPicker.show {
+		return m.handleLinkPickerKey(msg)
+	}
 	// Handle attachments modal separately since it needs navigation
 	if m.attachments.modal.show {
 		return m.handleAttachmentsModalKey(msg)
@@ -42,6 +56,12 @@ func (m Model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.search.active {
 		return m.handleSearchKey(msg)
 	}
+	if m.inbox.delete.pending {
+		return m.handleDeleteConfirmKey(msg)
+	}
+	if m.detail.unsubscribe.pending {
+		return m.handleUnsubscribeConfirmKey(msg)
+	}
 
 	switch m.currentView {
 	case viewList:
@@ -89,15 +109,8 @@ func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 			}
 		case tea.MouseButtonLeft:
 			if msg.Action == tea.MouseActionPress {
-				// Calculate which thread was clicked based on Y position
-				// Each card is listCardHeight lines (including the blank separator).
-				clickedCardIndex := (msg.Y - listHeaderHeight) / m.listCardHeight()
-
-				// Map clicked card to actual thread index using visible range
-				start, end := m.getVisibleThreadRange()
-				actualIndex := start + clickedCardIndex
-
-				if actualIndex >= start && actualIndex < end {
+				// Map the clicked line to a row, skipping group headers
+				if actualIndex := m.displayIndexAtLine(msg.Y); actualIndex >= 0 {
 					m.inbox.cursor = actualIndex
 					m.ensureCursorVisible()
 					if idx := m.selectedThreadIndex(); idx >= 0 && idx < len(m.inbox.threads) {
@@ -130,167 +143,33 @@ func (m Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m Model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	km := m.keyMap()
-	if m.inbox.delete.pending {
-		switch msg.String() {
-		case "y", "Y", "enter":
-			refs := append([]threadRef(nil), m.inbox.delete.targets...)
-			action := m.inbox.delete.action
-			m.inbox.delete.pending = false
-			m.inbox.delete.targets = nil
-			m.inbox.delete.action = deleteActionTrash
-			if len(refs) == 0 {
-				return m, nil
-			}
-			m.inbox.delete.inProgress = true
-			return m, m.threadActionCmd(action, refs)
-		case "n", "N", "esc":
-			m.inbox.delete.pending = false
-			m.inbox.delete.targets = nil
-			m.inbox.delete.action = deleteActionTrash
-			return m, nil
-		default:
-			return m, nil
-		}
-	}
-	switch {
-	case key.Matches(msg, km.list.Quit):
-		return m, tea.Quit
-	case key.Matches(msg, km.list.Help):
-		m.ui.showHelp = true
-		return m, nil
-	case key.Matches(msg, km.list.Refresh):
-		// Refresh inbox (non-disruptive)
-		tea.Printf("USER: Pressed 'r' to refresh, current threads=%d", len(m.inbox.threads))
-		m.inbox.refreshing = true
-		return m, m.loadInboxCmd(inboxLoadManual)
-	case key.Matches(msg, km.list.ToggleRead):
-		// Toggle read/unread status
-		if idx := m.selectedThreadIndex(); idx >= 0 && idx < len(m.inbox.threads) {
-			thread := &m.inbox.threads[idx]
-			// Calculate new state before optimistic update
-			newUnreadState := !thread.Unread
-			// Optimistically update UI immediately
-			thread.Unread = newUnreadState
-			// Send API request in background with the target state
-			return m, m.markThreadUnreadCmd(thread.ThreadID, newUnreadState, thread.AccountIndex)
-		}
-	case key.Matches(msg, km.list.ToggleSelect):
-		if idx := m.selectedThreadIndex(); idx >= 0 && idx < len(m.inbox.threads) {
-			m.toggleThreadSelection(idx)
-		}
-		return m, nil
-	case key.Matches(msg, km.list.ClearSelection):
-		m.clearSelection()
-		return m, nil
-	case key.Matches(msg, km.list.Archive):
-		refs := m.selectionOrCurrent()
+// handleDeleteConfirmKey answers the archive, trash or delete
+// confirmation, which an unsubscribe can raise from the detail view too.
+func (m Model) handleDeleteConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "y", "Y", "enter":
+		refs := append([]threadRef(nil), m.inbox.delete.targets...)
+		action := m.inbox.delete.action
+		m.inbox.delete = deleteState{}
 		if len(refs) == 0 {
 			return m, nil
 		}
-		m.inbox.delete.pending = true
-		m.inbox.delete.targets = refs
-		m.inbox.delete.action = deleteActionArchive
+		m.inbox.delete.inProgress = true
+		return m, m.threadActionCmd(action, refs)
+	case "n", "N", "esc":
+		m.inbox.delete = deleteState{}
 		return m, nil
-	case key.Matches(msg, km.list.Delete):
-		refs := m.selectionOrCurrent()
-		if len(refs) == 0 {
-			return m, nil
-		}
-		m.inbox.delete.pending = true
-		m.inbox.delete.targets = refs
-		m.inbox.delete.action = deleteActionTrash
-		return m, nil
-	case key.Matches(msg, km.list.DeleteForever):
-		refs := m.selectionOrCurrent()
-		if len(refs) == 0 {
-			return m, nil
-		}
-		m.inbox.delete.pending = true
-		m.inbox.delete.targets = refs
-		m.inbox.delete.action = deleteActionPermanent
+	default:
 		return m, nil
-	case key.Matches(msg, km.list.Undo):
-		if !m.undoAvailable() || m.inbox.undo.inProgress {
-			return m, nil
-		}
-		refs := m.undoRefs()
-		if len(refs) == 0 {
-			return m, nil
-		}
-		action := m.inbox.undo.action
-		m.inbox.undo.inProgress = true
-		m = m.clearAlerts()
-		return m, m.undoThreadsCmd(action, refs)
-	case key.Matches(msg, km.list.PageUp):
-		// Jump up by visible page size
-		start, end := m.getVisibleThreadRange()
-		pageSize := end - start
-		if pageSize > 0 {
-			m.inbox.cursor = max(0, m.inbox.cursor-pageSize)
-			m.ensureCursorVisible()
-			return m, m.loadVisibleThreadsCmd()
-		}
-	case key.Matches(msg, km.list.PageDown):
-		// Jump down by visible page size
-		start, end := m.getVisibleThreadRange()
-		pageSize := end - start
-		count := m.displayCount()
-		if pageSize > 0 {
-			if count > 0 {
-				m.inbox.cursor = min(count-1, m.inbox.cursor+pageSize)
-			}
-			m.ensureCursorVisible()
-			cmd := m.loadVisibleThreadsCmd()
-
-			// If near bottom, trigger loading more
-			if count > 0 && m.inbox.cursor >= count-10 && m.inbox.nextPageToken != "" &&
-				!m.inbox.loadingMore {
-				m.inbox.loadingMore = true
-				return m, tea.Batch(cmd, m.loadMoreThreadsCmd())
-			}
-			return m, cmd
-		}
-	case key.Matches(msg, km.list.Up):
-		if m.inbox.cursor > 0 {
-			m.inbox.cursor--
-			m.ensureCursorVisible()
-			// Load visible threads when scrolling
-			return m, m.loadVisibleThreadsCmd()
-		}
-	case key.Matches(msg, km.list.Down):
-		if m.inbox.cursor < m.displayCount()-1 {
-			m.inbox.cursor++
-			m.ensureCursorVisible()
-			// Load visible threads when scrolling
-			cmd := m.loadVisibleThreadsCmd()
-
-			// If near bottom (within 10 threads), trigger loading more
-			if count := m.displayCount(); count > 0 &&
-				m.inbox.cursor >= count-10 && m.inbox.nextPageToken != "" && !m.inbox.loadingMore {
-				m.inbox.loadingMore = true
-				return m, tea.Batch(cmd, m.loadMoreThreadsCmd())
-			}
-			return m, cmd
-		}
-	case key.Matches(msg, km.list.Open):
-		if idx := m.selectedThreadIndex(); idx >= 0 && idx < len(m.inbox.threads) {
-			thread := m.inbox.threads[idx]
-			return m, m.openThread(thread)
-		}
-	case key.Matches(msg, km.list.Search):
-		m.search.previousQuery = m.search.query
-		m.search.active = true
-		m.search.input.SetValue(m.search.query)
-		m.search.input.CursorEnd()
-		m.search.input.Focus()
-		m.search.input.Width = max(10, m.ui.width-4)
-		m.logf("Search open query=%q", m.search.query)
-		return m, textinput.Blink
 	}
+}
 
-	return m, nil
+func (m Model) handleListKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m, action, count, match := m.resolveKey(msg)
+	if match != keyMatched {
+		return m, nil
+	}
+	return m.runAction(action, count)
 }
 
 func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -306,19 +185,9 @@ func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.logf("Search cancel restore=%q", m.search.previousQuery)
 		return m, nil
 	case key.Matches(msg, km.search.Submit):
-		query := strings.TrimSpace(m.search.input.Value())
 		m.search.active = false
 		m.search.input.Blur()
-		m.applyFilter(query)
-		m.logf("Search submit query=%q", query)
-		gen := m.search.remoteGeneration + 1
-		m.search.remoteGeneration = gen
-		if query == "" {
-			m.search.remoteLoading = false
-			m.pruneSearchOnly()
-			return m, nil
-		}
-		return m, m.searchDebounceCmd(query, gen)
+		return m, m.submitSearch(m.search.input.Value())
 	}
 
 	var cmd tea.Cmd
@@ -337,95 +206,47 @@ func (m Model) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmd, m.searchDebounceCmd(query, gen))
 }
 
+// submitSearch applies query locally and schedules the server-side search.
+func (m *Model) submitSearch(query string) tea.Cmd {
+	query = strings.TrimSpace(query)
+	m.applyFilter(query)
+	m.logf("Search submit query=%q", query)
+	gen := m.search.remoteGeneration + 1
+	m.search.remoteGeneration = gen
+	if query == "" {
+		m.search.remoteLoading = false
+		m.pruneSearchOnly()
+		return nil
+	}
+	return m.searchDebounceCmd(query, gen)
+}
+
 func (m Model) handleDetailKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-	km := m.keyMap()
-	switch {
-	case key.Matches(msg, km.detail.Quit):
-		return m, tea.Quit
-	case msg.String() == "X":
+	if msg.String() == "X" {
 		m.debugDumpCurrentMessage()
 		return m, nil
-	case key.Matches(msg, km.detail.Help):
-		m.ui.showHelp = true
-		return m, nil
-	case key.Matches(msg, km.detail.Attachments):
-		// Show attachments modal if current message has attachments
-		if m.detail.selectedMessageIdx >= 0 &&
-			m.detail.selectedMessageIdx < len(m.detail.messages) {
-			msg := m.detail.messages[m.detail.selectedMessageIdx]
-			if len(msg.Attachments) > 0 {
-				m.showAttachmentsModal(msg)
-				return m, m.setWindowTitleCmd()
-			}
-		}
+	}
+	m, action, count, match := m.resolveKey(msg)
+	switch match {
+	case keyPending:
 		return m, nil
-	case key.Matches(msg, km.detail.Back):
-		// Go back to list view
-		return m, m.exitDetailView()
-	case key.Matches(msg, km.detail.ToggleView):
-		// Toggle view mode for the selected message.
-		if m.detail.selectedMessageIdx >= 0 &&
-			m.detail.selectedMessageIdx < len(m.detail.messages) {
-			selected := m.detail.messages[m.detail.selectedMessageIdx]
-			m.detail.messageViewMode = nextMessageViewMode(m.detail.messageViewMode, selected)
-		}
-		var rawCmd tea.Cmd
-		if m.detail.messageViewMode == viewModeRaw {
-			rawCmd = m.loadRawForExpandedMessages()
-		}
-		// Re-render the message and reset viewport position
-		body := m.renderThreadBody()
-		m.detail.viewport.SetContent(body)
-		m.detail.viewport.GotoTop()
-		m.detail.viewport.YOffset = 0
-		// Force full redraw
-		if rawCmd != nil {
-			return m, tea.Batch(tea.ClearScreen, rawCmd)
-		}
-		return m, tea.ClearScreen
-	case key.Matches(msg, km.detail.Down):
-		if m.detail.selectedMessageIdx < len(m.detail.messages)-1 {
-			m.detail.selectedMessageIdx++
-			// Re-render
-			body := m.renderThreadBody()
-			m.detail.viewport.SetContent(body)
-		}
-	case key.Matches(msg, km.detail.Up):
-		if m.detail.selectedMessageIdx > 0 {
-			m.detail.selectedMessageIdx--
-			// Re-render
-			body := m.renderThreadBody()
-			m.detail.viewport.SetContent(body)
-		}
-	case key.Matches(msg, km.detail.ToggleExpand):
-		// Toggle expand/collapse for selected message
-		if m.detail.selectedMessageIdx >= 0 &&
-			m.detail.selectedMessageIdx < len(m.detail.messages) {
-			msgID := m.detail.messages[m.detail.selectedMessageIdx].ID
-			m.detail.expandedMessages[msgID] = !m.detail.expandedMessages[msgID]
-			var cmds []tea.Cmd
-			if m.detail.messageViewMode == viewModeRaw {
-				cmds = append(cmds, m.loadRawForExpandedMessages())
-			}
-			if m.detail.expandedMessages[msgID] {
-				if scanCmd := m.scanMessageLinksCmd(
-					m.detail.messages[m.detail.selectedMessageIdx],
-				); scanCmd != nil {
-					cmds = append(cmds, scanCmd)
-				}
-			}
-			// Re-render
-			body := m.renderThreadBody()
-			m.detail.viewport.SetContent(body)
-			if len(cmds) > 0 {
-				return m, tea.Batch(cmds...)
-			}
+	case keyMatched:
+		next, cmd := m.runAction(action, count)
+		if !action.passthrough || cmd != nil {
+			return next, cmd
 		}
+		m = next
+	case keyNoMatch:
 	}
 
-	var cmd tea.Cmd
-	m.detail.viewport, cmd = m.detail.viewport.Update(msg)
-	return m, cmd
+	// Unbound keys and passthrough actions scroll the viewport
+	cmds := make([]tea.Cmd, 0, max(1, count))
+	for range max(1, count) {
+		var cmd tea.Cmd
+		m.detail.viewport, cmd = m.detail.viewport.Update(msg)
+		cmds = append(cmds, cmd)
+	}
+	return m, tea.Batch(cmds...)
 }
 
 func (m Model) handleAttachmentKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
@@ -449,6 +270,20 @@ func (m Model) handleImageKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case key.Matches(msg, km.image.Back):
 		return m, m.exitImageView()
+	case key.Matches(msg, km.image.Pause):
+		return m.toggleAnimation()
+	case key.Matches(msg, km.image.Step):
+		return m.stepAnimation(1)
+	case key.Matches(msg, km.image.StepBack):
+		return m.stepAnimation(-1)
+	case key.Matches(msg, km.image.Next):
+		return m.stepGallery(1)
+	case key.Matches(msg, km.image.Prev):
+		return m.stepGallery(-1)
+	case key.Matches(msg, km.image.Save):
+		return m.saveGalleryImage()
+	case key.Matches(msg, km.image.SaveAll):
+		return m.saveAllGalleryImages()
 	}
 
 	return m, nil
@@ -469,6 +304,9 @@ func (m Model) handleInboxLoaded(msg inboxLoadedMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	// A successful load means we're online, so flush anything queued
+	syncCmd := m.outboxSyncCmd()
+
 	// Store pagination token
 	m.inbox.nextPageToken = msg.nextPageToken
 
@@ -476,8 +314,9 @@ func (m Model) handleInboxLoaded(msg inboxLoadedMsg) (tea.Model, tea.Cmd) {
 	if msg.append {
 		tea.Printf("INBOX: Append mode, adding to end")
 		m.inbox.threads = append(m.inbox.threads, msg.threads...)
+		m.applyPendingActions()
 		// Load metadata for newly added threads if visible
-		return m, m.loadVisibleThreadsCmd()
+		return m, tea.Batch(m.loadVisibleThreadsCmd(), syncCmd)
 	}
 
 	// If this was a refresh, merge new threads with existing ones
@@ -531,6 +370,7 @@ func (m Model) handleInboxLoaded(msg inboxLoadedMsg) (tea.Model, tea.Cmd) {
 		tea.Printf("INBOX: Initial load, no existing threads to merge")
 		m.inbox.threads = msg.threads
 	}
+	m.applyPendingActions()
 
 	// Ensure cursor is still valid
 	if m.inbox.cursor >= len(m.inbox.threads) {
@@ -550,7 +390,7 @@ func (m Model) handleInboxLoaded(msg inboxLoadedMsg) (tea.Model, tea.Cmd) {
 	if needsLoading == 0 {
 		sortThreadsByDate(m.inbox.threads)
 	}
-	if m.search.query != "" {
+	if m.filterActive() {
 		m.applyFilter(m.search.query)
 	} else {
 		m.inbox.filteredIdx = nil
@@ -572,16 +412,16 @@ func (m Model) handleInboxLoaded(msg inboxLoadedMsg) (tea.Model, tea.Cmd) {
 		tea.Printf("INBOX: Starting metadata load for %d threads", needsLoading)
 		cmd := m.loadAllThreadsMetadataCmd(false)
 		if notify {
-			return m, tea.Batch(cmd, bellCmd())
+			return m, tea.Batch(cmd, bellCmd(), syncCmd)
 		}
-		return m, cmd
+		return m, tea.Batch(cmd, syncCmd)
 	}
 
 	tea.Printf("INBOX: No threads need loading")
 	if notify {
-		return m, bellCmd()
+		return m, tea.Batch(bellCmd(), syncCmd)
 	}
-	return m, nil
+	return m, syncCmd
 }
 
 func (m Model) handleThreadMetadataLoaded(msg threadMetadataLoadedMsg) Model {
@@ -619,9 +459,11 @@ func (m Model) handleThreadMetadataLoaded(msg threadMetadataLoadedMsg) Model {
 			msg.thread.Subject,
 		)
 
+		m.applyPendingActions()
+
 		// Re-sort threads after each update for streaming effect
 		sortThreadsByDate(m.inbox.threads)
-		if m.search.query != "" {
+		if m.filterActive() {
 			m.applyFilter(m.search.query)
 		}
 	}
@@ -663,15 +505,23 @@ func (m Model) handleBatchThreadMetadataLoaded(
 			m.inbox.loadingThreads--
 		}
 	}
+	m.applyPendingActions()
 	// Re-sort threads by date after loading metadata
 	sortThreadsByDate(m.inbox.threads)
-	if m.search.query != "" {
+	if m.filterActive() {
 		m.applyFilter(m.search.query)
 	}
 	return m
 }
 
 func (m Model) handleThreadLoaded(msg threadLoadedMsg) (tea.Model, tea.Cmd) {
+	current := m.detail.currentThread
+	if current == nil || current.ThreadID != msg.threadID ||
+		current.AccountIndex != msg.accountIndex {
+		// The preview moved on before this thread finished loading
+		m.logf("GetThread stale account=%d thread=%s", msg.accountIndex, msg.threadID)
+		return m, nil
+	}
 	m.detail.loading = false
 	if msg.err != nil {
 		if m.detail.currentThread != nil {
@@ -708,32 +558,36 @@ func (m Model) handleThreadLoaded(msg threadLoadedMsg) (tea.Model, tea.Cmd) {
 	}
 
 	// Update viewport size first
-	m.detail.viewport.Width = m.ui.width
-	m.detail.viewport.Height = detailViewportHeight(m.ui.height)
+	m.sizeDetailViewport()
 	// Set viewport content
 	body := m.renderThreadBody()
 	m.detail.viewport.SetContent(body)
 	// Reset scroll position to top
 	m.detail.viewport.GotoTop()
+	if prerenderCmd := m.prerenderBodiesCmd(); prerenderCmd != nil {
+		cmds = append(cmds, prerenderCmd)
+	}
 	if len(cmds) == 0 {
 		return m, nil
 	}
 	return m, tea.Batch(cmds...)
 }
 
-func (m Model) handleLinkScanFinished(msg linkScanFinishedMsg) Model {
-	if m.currentView != viewDetail || msg.messageID == "" {
-		return m
+func (m Model) handleLinkScanFinished(msg linkScanFinishedMsg) (Model, tea.Cmd) {
+	// The scan may have resolved links in any cached body
+	m.renderers.linkGeneration++
+	if !m.detailVisible() || msg.messageID == "" {
+		return m, nil
 	}
 	if !m.detail.expandedMessages[msg.messageID] {
-		return m
+		return m, m.prerenderBodiesCmd()
 	}
 	body := m.renderThreadBody()
 	m.detail.viewport.SetContent(body)
-	return m
+	return m, m.prerenderBodiesCmd()
 }
 
-func (m Model) handleThreadMarked(msg threadMarkedMsg) Model {
+func (m Model) handleThreadMarked(msg threadMarkedMsg) (tea.Model, tea.Cmd) {
 	if msg.err != nil {
 		// Revert optimistic update on error
 		for i := range m.inbox.threads {
@@ -747,10 +601,13 @@ func (m Model) handleThreadMarked(msg threadMarkedMsg) Model {
 		}
 		m.ui.err = msg.err
 		m.ui.showError = true
-		return m
+		return m, nil
+	}
+	if msg.queued {
+		return m, m.loadOutboxCmd(false)
 	}
 	// Success - the optimistic update was correct, no need to update again
-	return m
+	return m, nil
 }
 
 func (m Model) handleThreadsAction(msg threadsActionMsg) (tea.Model, tea.Cmd) {
@@ -778,7 +635,7 @@ func (m Model) handleThreadsAction(msg threadsActionMsg) (tea.Model, tea.Cmd) {
 	if len(succeeded) > 0 {
 		undoThreads = m.threadsForRefs(succeeded)
 		m.removeThreadsByRefs(succeeded)
-		if m.search.query != "" {
+		if m.filterActive() {
 			m.reapplyFilterPreserveCursor()
 		} else {
 			m.clampCursor()
@@ -805,14 +662,17 @@ func (m Model) handleThreadsAction(msg threadsActionMsg) (tea.Model, tea.Cmd) {
 	if msg.err == nil && len(undoThreads) > 0 {
 		toastCmd = m.undoToastCmd(msg.action, len(undoThreads))
 	}
+	if msg.queued > 0 {
+		return m, tea.Batch(toastCmd, m.loadOutboxCmd(false))
+	}
 
 	return m, toastCmd
 }
 
-func (m Model) handleThreadsUndo(msg threadsUndoMsg) Model {
+func (m Model) handleThreadsUndo(msg threadsUndoMsg) (tea.Model, tea.Cmd) {
 	m.inbox.undo.inProgress = false
 	if len(msg.refs) == 0 {
-		return m
+		return m, nil
 	}
 
 	failed := make(map[string]struct{}, len(msg.failed))
@@ -833,7 +693,7 @@ func (m Model) handleThreadsUndo(msg threadsUndoMsg) Model {
 
 	if len(reinsert) > 0 {
 		m.inbox.threads = append(m.inbox.threads, reinsert...)
-		if m.search.query != "" {
+		if m.filterActive() {
 			m.reapplyFilterPreserveCursor()
 		} else {
 			sortThreadsByDate(m.inbox.threads)
@@ -852,12 +712,66 @@ func (m Model) handleThreadsUndo(msg threadsUndoMsg) Model {
 		m.ui.showError = true
 	}
 
-	return m
+	// Undoing may have cancelled queued actions, so always refresh the counts
+	return m, m.loadOutboxCmd(false)
+}
+
+func (m Model) handleThreadsLabeled(msg threadsLabeledMsg) (tea.Model, tea.Cmd) {
+	if len(msg.refs) == 0 {
+		return m, nil
+	}
+
+	failed := make(map[string]struct{}, len(msg.failed))
+	for _, ref := range msg.failed {
+		failed[threadKey(ref.threadID, ref.accountIndex)] = struct{}{}
+	}
+	succeeded := make([]threadRef, 0, len(msg.refs)-len(msg.failed))
+	for _, ref := range msg.refs {
+		if _, ok := failed[threadKey(ref.threadID, ref.accountIndex)]; ok {
+			continue
+		}
+		succeeded = append(succeeded, ref)
+	}
+
+	var cmds []tea.Cmd
+	if len(succeeded) > 0 {
+		if containsLabel(msg.remove, "INBOX") {
+			// Removing INBOX archives the thread
+			m.removeThreadsByRefs(succeeded)
+			if m.filterActive() {
+				m.reapplyFilterPreserveCursor()
+			} else {
+				m.clampCursor()
+			}
+		} else {
+			indices := make([]int, 0, len(succeeded))
+			for _, ref := range succeeded {
+				if idx := findThreadIndex(m.inbox.threads, ref.accountIndex, ref.threadID); idx >= 0 {
+					indices = append(indices, idx)
+				}
+			}
+			cmds = append(cmds, m.loadThreadsMetadataCmd(indices))
+		}
+		cmds = append(cmds, m.labelToastCmd(len(succeeded)))
+	}
+	if msg.queued > 0 {
+		cmds = append(cmds, m.loadOutboxCmd(false))
+	}
+
+	if msg.err != nil {
+		m.ui.err = msg.err
+		m.ui.showError = true
+	}
+	return m, tea.Batch(cmds...)
 }
 
-func (m Model) handleAttachmentDownloaded(msg attachmentDownloadedMsg) Model {
+func (m Model) handleAttachmentDownloaded(msg attachmentDownloadedMsg) (tea.Model, tea.Cmd) {
 	// Stop downloading state
-	m.attachments.modal.downloading = false
+	m.endDownload()
+	if errors.Is(msg.err, context.Canceled) {
+		// Stay in the modal so another attachment can be picked
+		return m, m.toastCmd("Download cancelled")
+	}
 	// Close attachments modal
 	m.attachments.modal.show = false
 	m.attachments.modal.attachments = nil
@@ -867,9 +781,10 @@ func (m Model) handleAttachmentDownloaded(msg attachmentDownloadedMsg) Model {
 		// Show error modal
 		m.ui.err = fmt.Errorf("failed to download %s: %w", msg.filename, msg.err)
 		m.ui.showError = true
+		return m, nil
 	}
-	// On success, just close the modal silently
-	return m
+	// The name may have gained a suffix, so say where it went
+	return m, m.toastCmd("Saved " + tildePath(msg.path))
 }
 
 func (m Model) handleAttachmentLoaded(msg attachmentLoadedMsg) (tea.Model, tea.Cmd) {
@@ -883,9 +798,10 @@ func (m Model) handleAttachmentLoaded(msg attachmentLoadedMsg) (tea.Model, tea.C
 		return m, nil
 	}
 
+	var cmd tea.Cmd
 	switch {
 	case isImageMimeType(msg.mimeType):
-		m.enterImageView(msg)
+		cmd = m.enterImageView(msg)
 	case isTextAttachment(msg.mimeType, msg.filename):
 		if err := m.enterAttachmentView(msg); err != nil {
 			m.ui.err = err
@@ -901,20 +817,24 @@ func (m Model) handleAttachmentLoaded(msg attachmentLoadedMsg) (tea.Model, tea.C
 	// Close attachments modal
 	m.attachments.modal.show = false
 
-	return m, m.setWindowTitleCmd()
+	return m, tea.Batch(cmd, m.setWindowTitleCmd())
 }
 
-func (m Model) handleMessageRawLoaded(msg messageRawLoadedMsg) Model {
+func (m Model) handleMessageRawLoaded(msg messageRawLoadedMsg) (Model, tea.Cmd) {
 	delete(m.detail.rawLoading, msg.messageID)
+	pendingMime := m.detail.mimePending == msg.messageID
+	if pendingMime {
+		m.detail.mimePending = ""
+	}
 	if msg.err != nil {
 		if m.detail.currentThread != nil && m.detail.currentThread.ThreadID == msg.threadID {
 			m.ui.err = msg.err
 			m.ui.showError = true
 		}
-		return m
+		return m, nil
 	}
 	if m.detail.currentThread == nil || m.detail.currentThread.ThreadID != msg.threadID {
-		return m
+		return m, nil
 	}
 	for i := range m.detail.messages {
 		if m.detail.messages[i].ID == msg.messageID {
@@ -922,11 +842,14 @@ func (m Model) handleMessageRawLoaded(msg messageRawLoadedMsg) Model {
 			break
 		}
 	}
-	if m.currentView == viewDetail {
+	if m.detailVisible() {
 		body := m.renderThreadBody()
 		m.detail.viewport.SetContent(body)
 	}
-	return m
+	if pendingMime {
+		return m, parseMimeCmd(msg.messageID, msg.raw)
+	}
+	return m, nil
 }
 
 func (m Model) handleSearchDebounce(msg searchDebounceMsg) (tea.Model, tea.Cmd) {
@@ -981,15 +904,16 @@ func (m Model) handleSearchRemoteLoaded(msg searchRemoteLoadedMsg) (tea.Model, t
 
 	m.pruneSearchOnly()
 	newIndices := m.mergeSearchResults(msg.threads)
-	if m.search.query != "" {
+	if m.filterActive() {
 		m.applyFilter(m.search.query)
 	}
 	m.logf("Search remote merged new=%d total=%d", len(newIndices), len(m.inbox.threads))
 	return m, m.loadThreadsMetadataCmd(newIndices)
 }
 
-func (m Model) handleAutoRefresh() (tea.Model, tea.Cmd) {
-	if m.uiConfig.RefreshIntervalSeconds <= 0 {
+func (m Model) handleAutoRefresh(msg autoRefreshMsg) (tea.Model, tea.Cmd) {
+	// Ticks from before the interval was changed are dropped
+	if msg.generation != m.inbox.refreshGeneration || m.uiConfig.RefreshIntervalSeconds <= 0 {
 		return m, nil
 	}
 	if m.inbox.loading || m.inbox.refreshing || m.inbox.loadingMore {
@@ -1008,34 +932,51 @@ func (m Model) handleWindowSize(msg tea.WindowSizeMsg) (tea.Model, tea.Cmd) {
 		m.ui.alert = newAlertModel(m.theme, msg.Width)
 	}
 
-	// Update viewport size for detail view
-	if m.currentView == viewDetail {
-		m.detail.viewport.Width = msg.Width
-		m.detail.viewport.Height = detailViewportHeight(msg.Height)
+	// Update viewport size for the detail view or preview pane
+	if m.currentView == viewDetail || m.currentView == viewList {
+		m.sizeDetailViewport()
 	}
 	if m.currentView == viewAttachment {
 		m.detail.viewport.Width = msg.Width
 		m.detail.viewport.Height = attachmentViewportHeight(msg.Height)
 	}
 	if m.currentView == viewImage {
-		return m, tea.Batch(clearKittyImagesCmd(), tea.ClearScreen)
+		return m, tea.Batch(clearImagesCmd(m.image.protocol), tea.ClearScreen)
 	}
 
 	if m.currentView == viewList {
 		m.ensureCursorVisible()
 	}
+	if msg.Width != oldWidth && m.detailVisible() {
+		return m, m.prerenderBodiesCmd()
+	}
 	return m, nil
 }
 
 // handleAttachmentsModalKey handles keyboard input in the attachments modal
 func (m Model) handleAttachmentsModalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.attachments.modal.saveAs.active {
+		return m.handleSaveAsKey(msg)
+	}
 	km := m.keyMap()
 	switch {
 	case key.Matches(msg, km.attachmentsModalKeys.Close):
-		// Don't allow closing while downloading
+		// Closing while downloading cancels the download instead
 		if m.attachments.modal.downloading {
+			if cancel := m.attachments.modal.download.cancel; cancel != nil {
+				cancel()
+			}
+			return m, nil
+		}
+		// Leaving an archive goes back to the attachment list
+		if m.attachments.modal.archive.active {
+			m.closeArchive()
 			return m, nil
 		}
+		if m.attachments.modal.mime.active {
+			m.closeMimeTree()
+			return m, m.setWindowTitleCmd()
+		}
 		// Close attachments modal
 		m.attachments.modal.show = false
 		m.attachments.modal.attachments = nil
@@ -1046,6 +987,18 @@ func (m Model) handleAttachmentsModalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.attachments.modal.downloading {
 			return m, nil
 		}
+		if state := &m.attachments.modal.archive; state.active {
+			if state.selectedIdx < len(state.entries)-1 {
+				state.selectedIdx++
+			}
+			return m, nil
+		}
+		if state := &m.attachments.modal.mime; state.active {
+			if state.selectedIdx < len(state.parts)-1 {
+				state.selectedIdx++
+			}
+			return m, nil
+		}
 		// Navigate down
 		if m.attachments.modal.selectedIdx < len(m.attachments.modal.attachments)-1 {
 			m.attachments.modal.selectedIdx++
@@ -1056,6 +1009,18 @@ func (m Model) handleAttachmentsModalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.attachments.modal.downloading {
 			return m, nil
 		}
+		if state := &m.attachments.modal.archive; state.active {
+			if state.selectedIdx > 0 {
+				state.selectedIdx--
+			}
+			return m, nil
+		}
+		if state := &m.attachments.modal.mime; state.active {
+			if state.selectedIdx > 0 {
+				state.selectedIdx--
+			}
+			return m, nil
+		}
 		// Navigate up
 		if m.attachments.modal.selectedIdx > 0 {
 			m.attachments.modal.selectedIdx--
@@ -1066,12 +1031,38 @@ func (m Model) handleAttachmentsModalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.attachments.modal.downloading || m.attachments.modal.loadingPreview {
 			return m, nil
 		}
+		if m.attachments.modal.archive.active {
+			return m.extractArchiveEntry()
+		}
+		if m.attachments.modal.mime.active {
+			return m.saveMimePart()
+		}
 		// Download selected attachment
 		if m.attachments.modal.selectedIdx >= 0 &&
 			m.attachments.modal.selectedIdx < len(m.attachments.modal.attachments) {
-			m.attachments.modal.downloading = true
+			ctx, written := m.beginDownload()
 			return m, tea.Batch(
-				m.downloadAttachmentCmd(m.attachments.modal.selectedIdx),
+				m.downloadAttachmentCmd(ctx, written, m.attachments.modal.selectedIdx, ""),
+				m.ui.spinner.Tick,
+			)
+		}
+		return m, nil
+	case key.Matches(msg, km.attachmentsModalKeys.SaveAs):
+		if m.attachments.modal.downloading || m.attachments.modal.loadingPreview ||
+			m.attachments.modal.archive.active || m.attachments.modal.mime.active {
+			return m, nil
+		}
+		return m.openSaveAs()
+	case key.Matches(msg, km.attachmentsModalKeys.OpenWith):
+		if m.attachments.modal.downloading || m.attachments.modal.loadingPreview ||
+			m.attachments.modal.archive.active || m.attachments.modal.mime.active {
+			return m, nil
+		}
+		if m.attachments.modal.selectedIdx >= 0 &&
+			m.attachments.modal.selectedIdx < len(m.attachments.modal.attachments) {
+			ctx, written := m.beginDownload()
+			return m, tea.Batch(
+				m.openAttachmentWithCmd(ctx, written, m.attachments.modal.selectedIdx),
 				m.ui.spinner.Tick,
 			)
 		}
@@ -1081,9 +1072,22 @@ func (m Model) handleAttachmentsModalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		if m.attachments.modal.downloading || m.attachments.modal.loadingPreview {
 			return m, nil
 		}
+		if m.attachments.modal.archive.active {
+			return m.previewArchiveEntry()
+		}
+		if m.attachments.modal.mime.active {
+			return m.previewMimePart()
+		}
 		if m.attachments.modal.selectedIdx >= 0 &&
 			m.attachments.modal.selectedIdx < len(m.attachments.modal.attachments) {
 			att := m.attachments.modal.attachments[m.attachments.modal.selectedIdx]
+			if format, ok := archive.Detect(att.Filename, att.MimeType); ok {
+				ctx, written := m.beginDownload()
+				return m, tea.Batch(
+					m.openArchiveCmd(ctx, written, m.attachments.modal.selectedIdx, format),
+					m.ui.spinner.Tick,
+				)
+			}
 			if isImageMimeType(att.MimeType) || isTextAttachment(att.MimeType, att.Filename) {
 				m.attachments.modal.loadingPreview = true
 				return m, tea.Batch(
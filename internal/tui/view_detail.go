@@ -9,8 +9,12 @@ import (
 )
 
 func (m *Model) renderDetailView() string {
-	body := ""
-	footer := ""
+	body, footer := m.renderDetailParts()
+	return renderFixedLayout(m.ui.height, body, footer)
+}
+
+// renderDetailParts renders the thread viewport and its statusline.
+func (m *Model) renderDetailParts() (body, footer string) {
 
 	switch {
 	case m.detail.loading:
@@ -37,7 +41,7 @@ func (m *Model) renderDetailView() string {
 		footer = m.renderDetailStatusline(scrollPercent, canToggle, false, selectedMode)
 	}
 
-	return renderFixedLayout(m.ui.height, body, footer)
+	return body, footer
 }
 
 func (m *Model) renderDetailStatusline(
@@ -46,6 +50,9 @@ func (m *Model) renderDetailStatusline(
 	loading bool,
 	selectedMode messageViewMode,
 ) string {
+	if m.command.active {
+		return m.renderCommandStatusline()
+	}
 	label := "MESSAGE"
 	if len(m.detail.messages) > 1 {
 		label = "THREAD"
@@ -65,6 +72,9 @@ func (m *Model) renderDetailStatusline(
 	}
 
 	right := []statusSegment{}
+	if m.sequencePending() {
+		right = append(right, statusTextSegment(m.theme, m.sequenceLabel()))
+	}
 	if loading {
 		right = append(right, statusDimSegment(m.theme, m.ui.spinner.View()+" loading"))
 	} else {
@@ -132,8 +142,7 @@ func (m *Model) renderThreadBody() string {
 
 	selectedPrefix := selectedBarStyle.Render("┃") + " "
 	normalPrefix := "  "
-	prefixWidth := max(lipgloss.Width(selectedPrefix), lipgloss.Width(normalPrefix))
-	contentWidth := max(m.ui.width-prefixWidth, 0)
+	contentWidth := m.threadBodyWidth()
 
 	writeLines := func(prefix string, lines []string) {
 		for i, line := range lines {
@@ -203,12 +212,17 @@ func (m *Model) renderThreadBody() string {
 					content.WriteString("\n")
 				}
 
-				writeHeader("From: ", msg.From)
-				writeHeader("To:   ", msg.To)
-				if msg.Cc != "" {
-					writeHeader("Cc:   ", msg.Cc)
+				content.WriteString(m.renderWarnings(msg, contentWidth))
+				if m.detail.allHeaders && len(msg.Headers) > 0 {
+					content.WriteString(m.renderAllHeaders(msg, contentWidth))
+				} else {
+					writeHeader("From: ", msg.From)
+					writeHeader("To:   ", msg.To)
+					if msg.Cc != "" {
+						writeHeader("Cc:   ", msg.Cc)
+					}
+					writeHeader("Date: ", msg.Date.Format("Mon, Jan 2, 2006 at 3:04 PM"))
 				}
-				writeHeader("Date: ", msg.Date.Format("Mon, Jan 2, 2006 at 3:04 PM"))
 
 				// Show attachment count if any
 				if len(msg.Attachments) > 0 {
@@ -218,37 +232,17 @@ func (m *Model) renderThreadBody() string {
 					}
 					writeHeader("Attachments: ", attachmentText)
 				}
+				if len(msg.InlineImages) > 0 {
+					writeHeader("Images: ", fmt.Sprintf(
+						"%d inline (%s to open, prefix a count for others)",
+						len(msg.InlineImages),
+						m.keyMap().detail.InlineImage.Help().Key,
+					))
+				}
 
 				content.WriteString("\n")
 
-				// Render body based on view mode
-				var bodyText string
-				switch {
-				case effectiveMode == viewModeHTML && msg.BodyHTML != "":
-					// Clean and convert HTML to markdown, then render with glamour
-					cleanedHTML := cleanHTMLForConversion(msg.BodyHTML)
-					markdown, err := m.renderers.htmlConverter.ConvertString(cleanedHTML)
-					if err != nil {
-						// Fallback to raw HTML if conversion fails
-						bodyText = msg.BodyHTML
-					} else {
-						bodyText = m.renderMarkdown(markdown, contentWidth)
-					}
-				case effectiveMode == viewModeText && msg.BodyText != "":
-					// Show plain text through glamour
-					bodyText = m.renderMarkdown(msg.BodyText, contentWidth)
-				case msg.BodyHTML != "":
-					// Fallback: clean and convert HTML to markdown if no plain text
-					cleanedHTML := cleanHTMLForConversion(msg.BodyHTML)
-					markdown, err := m.renderers.htmlConverter.ConvertString(cleanedHTML)
-					if err != nil {
-						bodyText = msg.BodyHTML
-					} else {
-						bodyText = m.renderMarkdown(markdown, contentWidth)
-					}
-				default:
-					bodyText = lipgloss.NewStyle().Italic(true).Render("[No message body]")
-				}
+				bodyText := m.renderBody(msg, effectiveMode, contentWidth)
 				content.WriteString(strings.TrimSpace(bodyText))
 			}
 		} else {
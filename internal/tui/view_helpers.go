@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"regexp"
@@ -9,8 +10,12 @@ import (
 	"unicode"
 	"unicode/utf8"
 
+	"github.com/charmbracelet/glamour"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
+
+	"go.withmatt.com/inbox/internal/gmail"
+	"go.withmatt.com/inbox/internal/links"
 )
 
 // Helper to format relative time.
@@ -32,50 +37,10 @@ func formatRelativeTime(t time.Time) string {
 	}
 }
 
-// cleanHTMLForConversion preprocesses HTML to remove problematic elements.
-func cleanHTMLForConversion(html string) string {
-	// Remove style and script tags entirely
-	styleRe := regexp.MustCompile(`(?is)<style[^>]*>.*?</style>`)
-	html = styleRe.ReplaceAllString(html, "")
-	scriptRe := regexp.MustCompile(`(?is)<script[^>]*>.*?</script>`)
-	html = scriptRe.ReplaceAllString(html, "")
-
-	// Convert tables to simpler structure - just extract text content
-	// This is a hack but marketing emails use tables for layout, not data
-	tableRe := regexp.MustCompile(`(?is)<table[^>]*>.*?</table>`)
-	html = tableRe.ReplaceAllStringFunc(html, func(table string) string {
-		// Remove table structure tags but keep content
-		cleaned := strings.ReplaceAll(table, "<tr>", "\n")
-		cleaned = strings.ReplaceAll(cleaned, "</tr>", "\n")
-		cleaned = strings.ReplaceAll(cleaned, "<td>", "")
-		cleaned = strings.ReplaceAll(cleaned, "</td>", " ")
-		cleaned = strings.ReplaceAll(cleaned, "<th>", "")
-		cleaned = strings.ReplaceAll(cleaned, "</th>", " ")
-		// Remove table wrapper tags
-		cleaned = regexp.MustCompile(`</?table[^>]*>`).ReplaceAllString(cleaned, "")
-		cleaned = regexp.MustCompile(`</?tbody[^>]*>`).ReplaceAllString(cleaned, "")
-		cleaned = regexp.MustCompile(`</?thead[^>]*>`).ReplaceAllString(cleaned, "")
-		return cleaned
-	})
-
-	return html
-}
-
 // renderMarkdown renders markdown/plaintext with glamour (reuses model's renderer).
 func (m *Model) renderMarkdown(text string, width int) string {
-	text = sanitizeImageMarkdown(text)
-	text = m.linkResolver.ResolveText(m.ctx, text)
 	m.ensureGlamourRenderer(width)
-	if m.renderers.glamourRenderer == nil {
-		return text
-	}
-	rendered, err := m.renderers.glamourRenderer.Render(text)
-	if err != nil {
-		// If rendering fails, return raw text
-		return text
-	}
-	rendered = normalizeOSC8LineBreaks(rendered)
-	rendered = restoreLinkTextSentinels(rendered)
+	rendered := renderMarkdownWith(m.ctx, m.linkResolver, m.renderers.glamourRenderer, text)
 	if !m.renderers.loggedHyperlink && strings.Contains(text, "http") {
 		m.renderers.loggedHyperlink = true
 		m.logf(
@@ -86,7 +51,29 @@ func (m *Model) renderMarkdown(text string, width int) string {
 			os.Getenv("TERM_PROGRAM"),
 		)
 	}
+	return rendered
+}
 
+// renderMarkdownWith renders markdown/plaintext with the given renderer. It
+// touches no model state, so background renders can use it too.
+func renderMarkdownWith(
+	ctx context.Context,
+	resolver *links.Resolver,
+	renderer *glamour.TermRenderer,
+	text string,
+) string {
+	text = sanitizeImageMarkdown(text)
+	text = resolver.ResolveText(ctx, text)
+	if renderer == nil {
+		return text
+	}
+	rendered, err := renderer.Render(text)
+	if err != nil {
+		// If rendering fails, return raw text
+		return text
+	}
+	rendered = normalizeOSC8LineBreaks(rendered)
+	rendered = restoreLinkTextSentinels(rendered)
 	return strings.TrimSpace(rendered)
 }
 
@@ -178,6 +165,28 @@ func normalizeOSC8LineBreaks(text string) string {
 
 var imageMarkdownRe = regexp.MustCompile(`!\[[^\]]*\]\(([^)]+)\)`)
 
+var cidImageMarkdownRe = regexp.MustCompile(`!\[([^\]]*)\]\(<?(cid:[^)\s>]+)>?[^)]*\)`)
+
+// inlineImagePlaceholders replaces cid: images, which would otherwise become
+// dead links, with numbered placeholders matching the inline image count.
+func inlineImagePlaceholders(markdown string, msg gmail.Message) string {
+	if len(msg.InlineImages) == 0 || !strings.Contains(markdown, "cid:") {
+		return markdown
+	}
+	return cidImageMarkdownRe.ReplaceAllStringFunc(markdown, func(match string) string {
+		sub := cidImageMarkdownRe.FindStringSubmatch(match)
+		_, idx, ok := msg.InlineImage(sub[2])
+		if !ok {
+			return match
+		}
+		label := fmt.Sprintf("image %d", idx+1)
+		if alt := strings.Join(strings.Fields(sub[1]), " "); alt != "" {
+			label += ": " + strings.ReplaceAll(alt, "`", "'")
+		}
+		return "`[" + label + "]`"
+	})
+}
+
 func sanitizeImageMarkdown(markdown string) string {
 	if !strings.Contains(markdown, "![") {
 		return markdown
@@ -2,48 +2,106 @@ package tui
 
 import (
 	"fmt"
+	stdimage "image"
 	"io"
+	"slices"
 	"strings"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 
 	"go.withmatt.com/inbox/internal/image"
 )
 
+// maxEncodedSizes bounds how many display sizes of an image stay encoded, so
+// resizing back and forth doesn't keep every multi-megabyte rendering.
+const maxEncodedSizes = 3
+
+type imageEncodedMsg struct {
+	generation int
+	key        imageKey
+	encoded    string
+	err        error
+}
+
+// imageEncodeRequest holds everything encoding needs, so it can run off the
+// UI loop without touching the model.
+type imageEncodeRequest struct {
+	data     string
+	mimeType string
+	protocol graphicsProtocol
+	// frames is set for animations, with frame the one to encode
+	frames []image.Frame
+	frame  int
+	box    imageBox
+}
+
 func (m *Model) renderImageView() string {
 	var b strings.Builder
 
-	// Create transformer and get dimensions
-	transformer, imgWidth, imgHeight, err := image.NewImageTransformer(
-		m.image.data,
-		m.image.mimeType,
-	)
-	if err != nil {
-		// Error creating transformer - show error and instructions
-		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Image.ErrorFg))
-		return fmt.Sprintf(
-			"\n\n%s\n\nPress ESC to go back",
-			errorStyle.Render(fmt.Sprintf("Error loading image: %v", err)),
-		)
+	if m.image.err != nil {
+		return m.renderImageError(m.image.err)
 	}
-	defer transformer.Close()
 
-	// Leave margins and reserve the statusline row.
-	maxCols := m.ui.width - 4
-	maxRows := m.ui.height - 2
-	if maxCols < 1 {
-		maxCols = 1
+	footer := m.renderImageStatusline()
+	if m.gallery.active {
+		footer = lipgloss.JoinVertical(lipgloss.Left, m.renderGalleryStrip(), footer)
+	}
+
+	encoded, ok := m.image.encoded[m.imageCacheKey(m.imageBox())]
+	if !ok {
+		// Encoding runs in the background and the view is redrawn when done
+		loadingStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Image.MetaFg))
+		return m.image.protocol.clearCommands() +
+			renderFixedLayout(m.ui.height, "\n"+loadingStyle.Render("  Rendering image…"), footer)
+	}
+	if m.animated() && m.image.protocol == graphicsKitty {
+		encoded += kittyAnimationControl(m.image.paused, m.image.frame)
+	}
+
+	// Half blocks are ordinary text, so they go through the normal layout
+	if m.image.protocol == graphicsHalfBlocks {
+		return renderFixedLayout(m.ui.height, encoded, footer)
+	}
+
+	// Clear the screen with blank lines to make a clean canvas
+	// Fill the entire terminal height with newlines
+	b.WriteString(m.image.protocol.clearCommands())
+	for i := 0; i < m.ui.height; i++ {
+		b.WriteString("\n")
+	}
+
+	// Move cursor back to top
+	b.WriteString("\x1b[H")
+	b.WriteString(encoded)
+
+	// Position each footer line explicitly; a newline could scroll the image
+	footerLines := strings.Split(footer, "\n")
+	firstRow := m.ui.height - len(footerLines) + 1
+	for i, line := range footerLines {
+		fmt.Fprintf(&b, "\x1b[%d;1H", max(1, firstRow+i))
+		b.WriteString(line)
 	}
-	if maxRows < 1 {
-		maxRows = 1
+
+	return b.String()
+}
+
+// imageBox fits the image into the screen, leaving margins and room for the
+// statusline and gallery strip.
+func (m *Model) imageBox() imageBox {
+	maxCols := max(1, m.ui.width-4)
+	maxRows := m.ui.height - 2
+	if m.gallery.active {
+		maxRows -= galleryStripHeight
 	}
+	maxRows = max(1, maxRows)
 
 	pixelsPerColumn, pixelsPerRow := terminalPixelsPerCell()
 
 	dstCols := maxCols
 	dstRows := maxRows
-	if imgWidth > 0 && imgHeight > 0 {
-		imgRatio := float64(imgWidth) / float64(imgHeight)
+	if m.image.width > 0 && m.image.height > 0 {
+		imgRatio := float64(m.image.width) / float64(m.image.height)
 		termRatio := float64(maxCols*pixelsPerColumn) / float64(maxRows*pixelsPerRow)
 		if imgRatio > termRatio {
 			dstCols = maxCols
@@ -53,42 +111,218 @@ func (m *Model) renderImageView() string {
 			dstCols = int(float64(dstRows*pixelsPerRow) * imgRatio / float64(pixelsPerColumn))
 		}
 	}
-	if dstCols < 1 {
-		dstCols = 1
+
+	return imageBox{
+		cols:            min(max(dstCols, 1), maxCols),
+		rows:            min(max(dstRows, 1), maxRows),
+		pixelsPerColumn: pixelsPerColumn,
+		pixelsPerRow:    pixelsPerRow,
+	}
+}
+
+// imageSize reads an image's dimensions from its header.
+func imageSize(data, mimeType string) (int, int, error) {
+	transformer, width, height, err := image.NewImageTransformer(data, mimeType)
+	if err != nil {
+		return 0, 0, err
+	}
+	transformer.Close()
+	return width, height, nil
+}
+
+func (m *Model) imageCacheKey(box imageBox) imageKey {
+	key := imageKey{box: box}
+	if m.animated() {
+		key.frame = m.image.frame
+		if m.image.protocol == graphicsKitty {
+			// Kitty gets every frame at once
+			key.frame = -1
+		}
+	}
+	return key
+}
+
+// syncImage starts encoding the image for the current screen size and frame
+// when that isn't cached yet. One encode runs at a time; when it finishes,
+// this runs again and picks up whatever changed meanwhile.
+func (m Model) syncImage() (Model, tea.Cmd) {
+	if m.currentView != viewImage || m.image.err != nil || m.image.encoding {
+		return m, nil
+	}
+	key := m.imageCacheKey(m.imageBox())
+	if _, ok := m.image.encoded[key]; ok {
+		if key.frame < 0 || !m.animated() {
+			return m, nil
+		}
+		// Get the next frame ready, so playback never waits on a blank screen
+		key.frame = (key.frame + 1) % len(m.image.frames)
+		if _, ok := m.image.encoded[key]; ok {
+			return m, nil
+		}
 	}
-	if dstRows < 1 {
-		dstRows = 1
+	m.image.encoding = true
+	req := imageEncodeRequest{
+		data:     m.image.data,
+		mimeType: m.image.mimeType,
+		protocol: m.image.protocol,
+		frame:    key.frame,
+		box:      key.box,
 	}
-	if dstCols > maxCols {
-		dstCols = maxCols
+	if m.animated() {
+		req.frames = m.image.frames
 	}
-	if dstRows > maxRows {
-		dstRows = maxRows
+	gen := m.image.generation
+	return m, func() tea.Msg {
+		encoded, err := req.encode()
+		return imageEncodedMsg{generation: gen, key: key, encoded: encoded, err: err}
 	}
-	constraintParam := fmt.Sprintf("c=%d,r=%d", dstCols, dstRows)
+}
 
-	// Clear the screen with blank lines to make a clean canvas
-	// Fill the entire terminal height with newlines
-	b.WriteString(kittyClearCommands)
-	for i := 0; i < m.ui.height; i++ {
-		b.WriteString("\n")
+func (m Model) handleImageEncoded(msg imageEncodedMsg) (tea.Model, tea.Cmd) {
+	if msg.generation != m.image.generation {
+		return m, nil
+	}
+	m.image.encoding = false
+	if msg.err != nil {
+		m.logf("Image encode failed file=%s: %v", m.image.filename, msg.err)
+		m.image.err = msg.err
+		return m, nil
 	}
+	m.image.storeEncoded(msg.key, msg.encoded)
+	return m, nil
+}
 
-	// Move cursor back to top
-	b.WriteString("\x1b[H")
+// storeEncoded caches an encoding, dropping the sizes used longest ago.
+func (s *imageState) storeEncoded(key imageKey, encoded string) {
+	if s.encoded == nil {
+		s.encoded = make(map[imageKey]string)
+	}
+	if !slices.Contains(s.encodedBoxes, key.box) {
+		s.encodedBoxes = append(s.encodedBoxes, key.box)
+		if len(s.encodedBoxes) > maxEncodedSizes {
+			oldest := s.encodedBoxes[0]
+			s.encodedBoxes = s.encodedBoxes[1:]
+			for k := range s.encoded {
+				if k.box == oldest {
+					delete(s.encoded, k)
+				}
+			}
+		}
+	}
+	s.encoded[key] = encoded
+}
 
-	// Render image with Kitty protocol
-	// f=100 is PNG format, a=T is transmit and display
-	fmt.Fprintf(&b, "\x1b_Gf=100,a=T,t=d,%s;", constraintParam)
+// encode renders the image for the detected protocol, downscaled to the
+// pixels the cell box can actually show.
+func (req imageEncodeRequest) encode() (string, error) {
+	var b strings.Builder
+	box := req.box
+	pixelWidth := box.cols * box.pixelsPerColumn
+	pixelHeight := box.rows * box.pixelsPerRow
+	animated := len(req.frames) > 1
+	switch req.protocol {
+	case graphicsKitty:
+		if animated {
+			return encodeKittyAnimation(req.frames, box)
+		}
+		transformer, _, _, err := image.NewImageTransformer(req.data, req.mimeType)
+		if err != nil {
+			return "", err
+		}
+		defer transformer.Close()
+		transformer.FitWithin(pixelWidth, pixelHeight)
+		// f=100 is PNG format, a=T is transmit and display
+		fmt.Fprintf(&b, "\x1b_Gf=100,a=T,t=d,c=%d,r=%d;", box.cols, box.rows)
+		if _, err := io.Copy(&b, transformer); err != nil {
+			return "", err
+		}
+		b.WriteString("\x1b\\")
+	case graphicsITerm2:
+		// OSC 1337 takes base64 file contents, sized in cells
+		fmt.Fprintf(&b, "\x1b]1337;File=inline=1;width=%d;height=%d;preserveAspectRatio=1:", box.cols, box.rows)
+		if animated {
+			data, err := image.EncodePNG(req.frames[req.frame].Image, pixelWidth, pixelHeight)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(data)
+		} else {
+			transformer, _, _, err := image.NewImageTransformer(req.data, req.mimeType)
+			if err != nil {
+				return "", err
+			}
+			defer transformer.Close()
+			transformer.FitWithin(pixelWidth, pixelHeight)
+			if _, err := io.Copy(&b, transformer); err != nil {
+				return "", err
+			}
+		}
+		b.WriteString("\a")
+	case graphicsSixel:
+		// Sixel has no scaling of its own, so send exactly the cell box
+		img, err := req.image()
+		if err != nil {
+			return "", err
+		}
+		if err := image.EncodeSixel(&b, image.Scale(img, pixelWidth, pixelHeight)); err != nil {
+			return "", err
+		}
+	case graphicsHalfBlocks:
+		img, err := req.image()
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(image.RenderHalfBlocks(image.Scale(img, box.cols, box.rows*2)))
+	}
+	return b.String(), nil
+}
 
-	// Stream image data
-	io.Copy(&b, transformer)
+// image is the decoded image, or the requested frame when animated.
+func (req imageEncodeRequest) image() (stdimage.Image, error) {
+	if len(req.frames) > 1 {
+		return req.frames[req.frame].Image, nil
+	}
+	return image.Decode(req.data)
+}
 
-	// End Kitty escape sequence
-	b.WriteString("\x1b\\")
+func (m *Model) renderImageError(err error) string {
+	errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(m.theme.Image.ErrorFg))
+	return fmt.Sprintf(
+		"\n\n%s\n\nPress ESC to go back",
+		errorStyle.Render(fmt.Sprintf("Error loading image: %v", err)),
+	)
+}
 
+func (m *Model) renderImageStatusline() string {
 	left := []statusSegment{statusModeSegment(m.theme, "ATTACH")}
-	right := []statusSegment{statusDimSegment(m.theme, "esc/q back")}
+	right := []statusSegment{}
+	if m.gallery.active {
+		left = append(left, statusTextSegment(m.theme, fmt.Sprintf(
+			"%d/%d", m.gallery.index+1, len(m.gallery.items),
+		)))
+		if m.gallery.waiting {
+			right = append(right, statusTextSegment(m.theme, "loading…"))
+		} else if m.gallery.status != "" {
+			right = append(right, statusTextSegment(m.theme, m.gallery.status))
+		}
+		right = append(right, statusDimSegment(m.theme, "h/l prev/next • s/S save"))
+	}
+	if m.animated() {
+		if m.image.paused {
+			right = append(right, statusTextSegment(m.theme, fmt.Sprintf(
+				"paused %d/%d", m.image.frame+1, len(m.image.frames),
+			)))
+		} else {
+			right = append(right, statusTextSegment(m.theme, "playing"))
+		}
+		if m.image.totalFrames > len(m.image.frames) {
+			right = append(right, statusTextSegment(m.theme, fmt.Sprintf(
+				"first %d of %d frames", len(m.image.frames), m.image.totalFrames,
+			)))
+		}
+		right = append(right, statusDimSegment(m.theme, "space pause"))
+	}
+	right = append(right, statusDimSegment(m.theme, "esc/q back"))
 
 	infoParts := make([]string, 0, 2)
 	if m.image.filename != "" {
@@ -116,9 +350,5 @@ func (m *Model) renderImageView() string {
 		}
 	}
 
-	footer := renderStatusline(m.theme, m.ui.width, left, right)
-	fmt.Fprintf(&b, "\x1b[%d;1H", max(1, m.ui.height))
-	b.WriteString(footer)
-
-	return b.String()
+	return renderStatusline(m.theme, m.ui.width, left, right)
 }
@@ -0,0 +1,25 @@
+package tui
+
+import "testing"
+
+func TestStoreEncodedKeepsRecentSizes(t *testing.T) {
+	var s imageState
+	boxes := []imageBox{{cols: 10}, {cols: 20}, {cols: 30}, {cols: 40}}
+	for _, box := range boxes {
+		s.storeEncoded(imageKey{box: box}, "still")
+		s.storeEncoded(imageKey{box: box, frame: 1}, "frame")
+	}
+	if len(s.encodedBoxes) != maxEncodedSizes {
+		t.Fatalf("kept %d sizes, want %d", len(s.encodedBoxes), maxEncodedSizes)
+	}
+	for _, key := range []imageKey{{box: boxes[0]}, {box: boxes[0], frame: 1}} {
+		if _, ok := s.encoded[key]; ok {
+			t.Errorf("oldest size %+v still cached", key)
+		}
+	}
+	for _, box := range boxes[1:] {
+		if s.encoded[imageKey{box: box, frame: 1}] != "frame" {
+			t.Errorf("size %+v dropped", box)
+		}
+	}
+}
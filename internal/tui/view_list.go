@@ -2,14 +2,32 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/reflow/truncate"
+
+	"go.withmatt.com/inbox/internal/config"
 )
 
 type rightPart struct {
-	text  string
-	style lipgloss.Style
+	column string
+	text   string
+	style  lipgloss.Style
+}
+
+// rightPartDropOrder lists the line 1 parts to give up, in order, when the
+// row is too narrow.
+var rightPartDropOrder = []string{
+	config.ColumnLabels,
+	config.ColumnBadge,
+	config.ColumnAttachment,
+	config.ColumnCount,
+}
+
+func isFlexColumn(column string) bool {
+	return column == config.ColumnSubject || column == config.ColumnSnippet
 }
 
 func rightPartsWidth(parts []rightPart) int {
@@ -76,18 +94,16 @@ func (m *Model) getVisibleThreadRange() (start, end int) {
 		return 0, 0
 	}
 
-	visibleCards := m.visibleCardCount()
-	if visibleCards <= 0 || total <= visibleCards {
-		return 0, total
-	}
-
-	maxStart := max(total-visibleCards, 0)
-	start = min(max(m.inbox.scrollOffset, 0), maxStart)
-	end = start + visibleCards
-	return start, end
+	start = min(max(m.inbox.scrollOffset, 0), m.maxScrollOffset())
+	return start, m.visibleEnd(start)
 }
 
 func (m *Model) renderListView() string {
+	return m.renderListLayout("", m.renderListBody())
+}
+
+// renderListBody renders the visible thread cards without the statusline.
+func (m *Model) renderListBody() string {
 	var body strings.Builder
 
 	// Styles
@@ -108,13 +124,18 @@ func (m *Model) renderListView() string {
 	unreadSnippetStyle := lipgloss.NewStyle().
 		Foreground(lipgloss.Color(m.theme.Status.Dim))
 
-	snippetLines := m.uiConfig.ListSnippetLines
-	if snippetLines <= 0 {
-		snippetLines = 1
+	columns := m.uiConfig.List.Columns
+	compact := m.listCompact()
+	snippetLines := m.listSnippetLines()
+	cardLines := m.listCardLines()
+	dateWidth := m.listDateWidth()
+	rowSeparator := "\n\n"
+	if compact {
+		rowSeparator = "\n"
 	}
 
 	cardPadding := 0
-	cardBodyHeight := snippetLines + 2
+	cardBodyHeight := cardLines
 	cardStyle := lipgloss.NewStyle().
 		Padding(0, cardPadding).
 		Height(cardBodyHeight).
@@ -130,7 +151,8 @@ func (m *Model) renderListView() string {
 		Foreground(lipgloss.Color(m.theme.List.UnreadFg)).
 		Bold(true)
 
-	contentWidth := max(m.ui.width-2*cardPadding, 0)
+	listWidth, _ := m.listPaneSize()
+	contentWidth := max(listWidth-2*cardPadding, 0)
 
 	padToWidth := func(text string, width int) string {
 		if width <= 0 {
@@ -146,15 +168,18 @@ func (m *Model) renderListView() string {
 	// Show loading state
 	if m.inbox.loading {
 		body.WriteString("Loading inbox...")
-		return m.renderListLayout("", body.String())
+		return body.String()
 	}
 	emptyMessage := ""
 	if len(m.inbox.threads) == 0 {
 		emptyMessage = "No messages"
 	} else if m.displayCount() == 0 {
-		if m.search.remoteLoading {
+		switch {
+		case m.search.remoteLoading:
 			emptyMessage = "Searching..."
-		} else {
+		case m.search.query == "":
+			emptyMessage = fmt.Sprintf("No threads for %s", m.accountFilterName())
+		default:
 			emptyMessage = fmt.Sprintf("No results for \"%s\"", m.search.query)
 		}
 	}
@@ -172,6 +197,10 @@ func (m *Model) renderListView() string {
 				continue
 			}
 			thread := m.inbox.threads[threadIndex]
+			if header := m.groupHeaderAt(i, start); header != "" {
+				body.WriteString(m.renderGroupHeader(header, contentWidth))
+				body.WriteString("\n")
+			}
 
 			isSelected := i == m.inbox.cursor
 			isBulkSelected := m.isThreadSelected(thread)
@@ -221,42 +250,33 @@ func (m *Model) renderListView() string {
 			}
 			lead := space
 
-			// Show loading state if metadata not loaded
-			if !thread.Loaded {
-				var cardContent strings.Builder
-
-				// Line 1: Empty line (matches from + date line)
-				cardContent.WriteString(prefix)
-				blankLine := strings.Repeat(" ", lineWidth)
-				if useBulkBg {
-					blankLine = lineSpaceStyle.Render(blankLine)
-				}
-				cardContent.WriteString(blankLine)
-				cardContent.WriteString(suffix)
-				cardContent.WriteString("\n")
-
-				// Line 2: Loading message (matches subject line)
-				loadingText := "Loading..."
-				cardContent.WriteString(prefix)
-				loadingLine := padToWidth(loadingText, lineWidth)
+			blankLine := func() string {
+				line := strings.Repeat(" ", lineWidth)
 				if useBulkBg {
-					loadingLine = lineDimStyle.Render(loadingLine)
+					line = lineSpaceStyle.Render(line)
 				}
-				cardContent.WriteString(loadingLine)
-				cardContent.WriteString(suffix)
-				for line := 0; line < snippetLines; line++ {
-					cardContent.WriteString("\n")
-					cardContent.WriteString(prefix)
-					blankLine = strings.Repeat(" ", lineWidth)
+				return prefix + line + suffix
+			}
+
+			// Show loading state if metadata not loaded
+			if !thread.Loaded {
+				lines := make([]string, cardLines)
+				// The loading message sits where the subject would be
+				loadingIdx := min(1, cardLines-1)
+				for lineIdx := range lines {
+					if lineIdx != loadingIdx {
+						lines[lineIdx] = blankLine()
+						continue
+					}
+					loadingLine := padToWidth("Loading...", lineWidth)
 					if useBulkBg {
-						blankLine = lineSpaceStyle.Render(blankLine)
+						loadingLine = lineDimStyle.Render(loadingLine)
 					}
-					cardContent.WriteString(blankLine)
-					cardContent.WriteString(suffix)
+					lines[lineIdx] = prefix + loadingLine + suffix
 				}
 
-				body.WriteString(cardStyle.Render(cardContent.String()))
-				body.WriteString("\n\n")
+				body.WriteString(cardStyle.Render(strings.Join(lines, "\n")))
+				body.WriteString(rowSeparator)
 				continue
 			}
 
@@ -271,14 +291,23 @@ func (m *Model) renderListView() string {
 				from = from[:37] + "..."
 			}
 
-			// Format date and indicators
-			date := formatRelativeTime(thread.Date)
+			date := ""
+			if m.hasListColumn(config.ColumnDate) {
+				date = m.formatListDate(thread.Date)
+			}
 
-			// Line 1: From + date/indicators
 			indicatorsText := ""
 			if thread.MessageCount > 1 {
 				indicatorsText = fmt.Sprintf("(%d)", thread.MessageCount)
 			}
+			attachmentText := ""
+			if thread.HasAttachment {
+				attachmentText = attachmentIndicator
+			}
+			labelsText := ""
+			if m.hasListColumn(config.ColumnLabels) {
+				labelsText = truncateToWidth(stripZeroWidth(m.threadLabelNames(thread)), labelsMaxWidth)
+			}
 
 			// Add account name if multiple accounts
 			accountName := thread.AccountName
@@ -310,30 +339,122 @@ func (m *Model) renderListView() string {
 				}
 			}
 
-			buildParts := func(indicators, account, date string) []rightPart {
-				parts := []rightPart{}
-				if indicators != "" {
-					parts = append(parts, rightPart{text: indicators, style: lineDimStyle})
+			// Style based on read/unread
+			fromStyle := lineReadStyle
+			subjectStyle := lineReadStyle
+			if thread.Unread {
+				fromStyle = lineUnreadStyle
+				subjectStyle = lineUnreadStyle
+				lineSnippetStyle = lineUnreadSnippetStyle
+			}
+
+			subject := stripZeroWidth(thread.Subject)
+			snippet := stripZeroWidth(strings.TrimSpace(thread.Snippet))
+
+			if compact {
+				cells := make([]rightPart, 0, len(columns))
+				for _, column := range columns {
+					switch column {
+					case config.ColumnSender:
+						text := padToWidth(truncateToWidth(from, compactSenderWidth), compactSenderWidth)
+						cells = append(cells, rightPart{column: column, text: text, style: fromStyle})
+					case config.ColumnCount:
+						text := padToWidth(indicatorsText, compactCountWidth)
+						cells = append(cells, rightPart{column: column, text: text, style: lineDimStyle})
+					case config.ColumnAttachment:
+						text := padToWidth(attachmentText, lipgloss.Width(attachmentIndicator))
+						cells = append(cells, rightPart{column: column, text: text, style: lineDimStyle})
+					case config.ColumnLabels:
+						if labelsText != "" {
+							cells = append(cells, rightPart{column: column, text: labelsText, style: lineDimStyle})
+						}
+					case config.ColumnBadge:
+						if accountText != "" {
+							cells = append(cells, rightPart{column: column, text: accountText, style: accountStyle})
+						}
+					case config.ColumnDate:
+						text := strings.Repeat(" ", max(0, dateWidth-lipgloss.Width(date))) + date
+						cells = append(cells, rightPart{column: column, text: text, style: lineDimStyle})
+					case config.ColumnSubject:
+						cells = append(cells, rightPart{column: column, text: subject, style: subjectStyle})
+					case config.ColumnSnippet:
+						cells = append(cells, rightPart{column: column, text: snippet, style: lineSnippetStyle})
+					}
+				}
+
+				// Subject and snippet share whatever the fixed columns leave
+				remaining := lineWidth - max(0, len(cells)-1)
+				lastFlex := -1
+				for idx, cell := range cells {
+					if isFlexColumn(cell.column) {
+						lastFlex = idx
+					} else {
+						remaining -= lipgloss.Width(cell.text)
+					}
 				}
-				if account != "" {
-					parts = append(parts, rightPart{text: account, style: accountStyle})
+				for idx := range cells {
+					if !isFlexColumn(cells[idx].column) {
+						continue
+					}
+					width := min(lipgloss.Width(cells[idx].text), max(0, remaining))
+					if idx == lastFlex {
+						width = max(0, remaining)
+					}
+					cells[idx].text = padToWidth(truncateToWidth(cells[idx].text, width), width)
+					remaining -= width
 				}
-				if date != "" {
-					parts = append(parts, rightPart{text: date, style: lineDimStyle})
+
+				line := renderRightParts(cells, space, "")
+				line = truncate.String(line, uint(lineWidth))
+				if pad := lineWidth - lipgloss.Width(line); pad > 0 {
+					line += strings.Repeat(space, pad)
 				}
-				return parts
+				renderStyle := cardStyle
+				if useBulkBg {
+					renderStyle = renderStyle.Background(lipgloss.Color(selectedBg))
+				}
+				body.WriteString(renderStyle.Render(prefix + line + suffix))
+				body.WriteString(rowSeparator)
+				continue
 			}
 
-			parts := buildParts(indicatorsText, accountText, date)
+			// Line 1: From + indicators, in column order
+			parts := []rightPart{}
+			for _, column := range columns {
+				switch column {
+				case config.ColumnCount:
+					if indicatorsText != "" {
+						parts = append(parts, rightPart{column: column, text: indicatorsText, style: lineDimStyle})
+					}
+				case config.ColumnAttachment:
+					if attachmentText != "" {
+						parts = append(parts, rightPart{column: column, text: attachmentText, style: lineDimStyle})
+					}
+				case config.ColumnLabels:
+					if labelsText != "" {
+						parts = append(parts, rightPart{column: column, text: labelsText, style: lineDimStyle})
+					}
+				case config.ColumnBadge:
+					if accountText != "" {
+						parts = append(parts, rightPart{column: column, text: accountText, style: accountStyle})
+					}
+				case config.ColumnDate:
+					if date != "" {
+						parts = append(parts, rightPart{column: column, text: date, style: lineDimStyle})
+					}
+				case config.ColumnSender, config.ColumnSubject, config.ColumnSnippet:
+				}
+			}
 
+			// Drop the least important parts until line 1 fits
 			maxRightWidth := max(lineWidth, 0)
-			if maxRightWidth > 0 && rightPartsWidth(parts) > maxRightWidth {
-				accountText = ""
-				parts = buildParts(indicatorsText, accountText, date)
-			}
-			if maxRightWidth > 0 && rightPartsWidth(parts) > maxRightWidth {
-				indicatorsText = ""
-				parts = buildParts(indicatorsText, accountText, date)
+			for _, column := range rightPartDropOrder {
+				if maxRightWidth == 0 || rightPartsWidth(parts) <= maxRightWidth {
+					break
+				}
+				parts = slices.DeleteFunc(parts, func(part rightPart) bool {
+					return part.column == column
+				})
 			}
 
 			rightInfoRendered := ""
@@ -348,6 +469,9 @@ func (m *Model) renderListView() string {
 				}
 			}
 
+			if !m.hasListColumn(config.ColumnSender) {
+				from = ""
+			}
 			availableFrom := max(lineWidth-lipgloss.Width(rightInfoRendered), 0)
 			fromMax := min(availableFrom, 40)
 			if fromMax > 0 {
@@ -356,11 +480,6 @@ func (m *Model) renderListView() string {
 				from = ""
 			}
 
-			fromStyle := lineReadStyle
-			if thread.Unread {
-				fromStyle = lineUnreadStyle
-			}
-
 			line1Left := prefix + fromStyle.Render(from)
 			if lineWidth > 0 {
 				leftWidth := lipgloss.Width(from)
@@ -370,39 +489,20 @@ func (m *Model) renderListView() string {
 				}
 			}
 			line1 := line1Left + rightInfoRendered + suffix
-
-			// Style based on read/unread
-			subjectStyle := lineReadStyle
-			if thread.Unread {
-				subjectStyle = lineUnreadStyle
-			}
+			lines := []string{line1}
 
 			// Line 2: Subject
-			subject := thread.Subject
-			subject = stripZeroWidth(subject)
 			subjectWidth := max(lineWidth, 0)
-			if subjectWidth > 0 {
-				subject = truncateToWidth(subject, subjectWidth)
-				subject = padToWidth(subject, subjectWidth)
+			if m.hasListColumn(config.ColumnSubject) {
+				if subjectWidth > 0 {
+					subject = truncateToWidth(subject, subjectWidth)
+					subject = padToWidth(subject, subjectWidth)
+				}
+				lines = append(lines, prefix+subjectStyle.Render(subject)+suffix)
 			}
-			line2 := prefix + subjectStyle.Render(subject) + suffix
 
 			// Snippet lines
-			snippet := thread.Snippet
-			if subjectWidth > 0 {
-				snippet = strings.TrimSpace(snippet)
-			}
-			snippet = stripZeroWidth(snippet)
 			snippetText := wrapTextLines(snippet, subjectWidth, snippetLines)
-
-			// Build card content
-			var cardContent strings.Builder
-			cardContent.WriteString(line1)
-			cardContent.WriteString("\n")
-			cardContent.WriteString(line2)
-			if thread.Unread {
-				lineSnippetStyle = lineUnreadSnippetStyle
-			}
 			for lineIdx := 0; lineIdx < snippetLines; lineIdx++ {
 				line := ""
 				if lineIdx < len(snippetText) {
@@ -410,22 +510,19 @@ func (m *Model) renderListView() string {
 				}
 				line = stripLeadingZeroWidth(stripZeroWidth(line))
 				line = padToWidth(line, subjectWidth)
-				cardContent.WriteString("\n")
-				cardContent.WriteString(prefix)
-				cardContent.WriteString(lineSnippetStyle.Render(line))
-				cardContent.WriteString(suffix)
+				lines = append(lines, prefix+lineSnippetStyle.Render(line)+suffix)
 			}
 
 			renderStyle := cardStyle
 			if useBulkBg {
 				renderStyle = renderStyle.Background(lipgloss.Color(selectedBg))
 			}
-			body.WriteString(renderStyle.Render(cardContent.String()))
-			body.WriteString("\n\n")
+			body.WriteString(renderStyle.Render(strings.Join(lines, "\n")))
+			body.WriteString(rowSeparator)
 		}
 	}
 
-	return m.renderListLayout("", body.String())
+	return body.String()
 }
 
 func (m *Model) renderListLayout(header string, body string) string {
@@ -446,6 +543,9 @@ func (m *Model) renderListLayout(header string, body string) string {
 }
 
 func (m *Model) renderListStatusline() string {
+	if m.command.active {
+		return m.renderCommandStatusline()
+	}
 	count := m.displayCount()
 	pos := 0
 	if count > 0 {
@@ -461,12 +561,15 @@ func (m *Model) renderListStatusline() string {
 		)
 	} else {
 		left = append(left, statusModeSegment(m.theme, "INBOX"))
+		if m.search.accountFilter != allAccounts {
+			left = append(left, statusDimSegment(m.theme, "account: "+m.accountFilterName()))
+		}
 		if m.search.query != "" {
 			left = append(left, statusDimSegment(m.theme, "filter: "+m.search.query))
 		}
 	}
 
-	if total > 0 && (m.search.query != "" || m.search.active) {
+	if total > 0 && (m.filterActive() || m.search.active) {
 		left = append(left, statusTextSegment(m.theme, fmt.Sprintf("threads %d/%d", count, total)))
 	} else {
 		left = append(left, statusTextSegment(m.theme, fmt.Sprintf("threads %d", count)))
@@ -474,8 +577,17 @@ func (m *Model) renderListStatusline() string {
 	if selectedCount := m.selectedCount(); selectedCount > 0 {
 		left = append(left, statusTextSegment(m.theme, fmt.Sprintf("selected %d", selectedCount)))
 	}
+	if pending := len(m.outbox.pending); pending > 0 {
+		left = append(left, statusTextSegment(m.theme, fmt.Sprintf("pending %d", pending)))
+	}
+	if failed := len(m.outbox.failed); failed > 0 {
+		left = append(left, statusTabSegment(m.theme, fmt.Sprintf("failed %d", failed)))
+	}
 
 	right := []statusSegment{}
+	if m.sequencePending() {
+		right = append(right, statusTextSegment(m.theme, m.sequenceLabel()))
+	}
 	switch {
 	case m.inbox.refreshing:
 		right = append(right, statusDimSegment(m.theme, "refreshing"))
@@ -492,6 +604,8 @@ func (m *Model) renderListStatusline() string {
 		right = append(right, statusDimSegment(m.theme, "searching"))
 	case m.inbox.undo.inProgress:
 		right = append(right, statusDimSegment(m.theme, "undoing"))
+	case m.outbox.replaying:
+		right = append(right, statusDimSegment(m.theme, "syncing"))
 	case m.inbox.delete.inProgress:
 		label := "deleting"
 		switch m.inbox.delete.action {
@@ -520,7 +634,16 @@ func (m *Model) renderListStatusline() string {
 			statusDimSegment(m.theme, "enter apply"),
 			statusDimSegment(m.theme, "esc cancel"),
 		)
+	case m.outbox.showFailed:
+		right = append(
+			right,
+			statusDimSegment(m.theme, "r retry"),
+			statusDimSegment(m.theme, "c clear"),
+		)
 	default:
+		if len(m.outbox.failed) > 0 {
+			right = append(right, statusDimSegment(m.theme, "! failed"))
+		}
 		right = append(
 			right,
 			statusDimSegment(m.theme, "? help"),
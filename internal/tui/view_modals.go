@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"cmp"
 	"fmt"
 	"strings"
 
@@ -158,7 +159,13 @@ func (m *Model) renderDeleteModal() string {
 		case deleteActionTrash:
 			b.WriteString(fmt.Sprintf("Move %d threads to trash?", count))
 		case deleteActionArchive:
-			b.WriteString(fmt.Sprintf("Archive %d threads?", count))
+			if sender := m.inbox.delete.sender; sender != "" {
+				b.WriteString(truncateToWidth(
+					fmt.Sprintf("Archive all %d threads from %s?", count, sender), modalWidth,
+				))
+			} else {
+				b.WriteString(fmt.Sprintf("Archive %d threads?", count))
+			}
 		case deleteActionPermanent:
 			b.WriteString(fmt.Sprintf("Permanently delete %d threads? This cannot be undone.", count))
 		}
@@ -178,10 +185,18 @@ func (m *Model) renderDeleteModal() string {
 	return b.String()
 }
 
+const attachmentsModalWidth = 70
+
 func (m *Model) renderAttachmentsModal() string {
+	if m.attachments.modal.archive.active {
+		return m.renderArchiveModal()
+	}
+	if m.attachments.modal.mime.active {
+		return m.renderMimeModal()
+	}
 	var b strings.Builder
 
-	modalWidth := 70
+	modalWidth := attachmentsModalWidth
 
 	// Title - properly centered
 	titleStyle := lipgloss.NewStyle().
@@ -221,14 +236,25 @@ func (m *Model) renderAttachmentsModal() string {
 		Align(lipgloss.Center).
 		Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
 
+	if m.attachments.modal.saveAs.active {
+		b.WriteString(m.attachments.modal.saveAs.input.View())
+		b.WriteString("\n")
+		b.WriteString(footerStyle.Render("tab complete • enter save • esc cancel"))
+		return b.String()
+	}
+
 	var footer string
 	switch {
 	case m.attachments.modal.downloading:
 		footer = m.ui.spinner.View() + " Downloading..."
+		if progress := m.renderDownloadProgress(); progress != "" {
+			footer = m.ui.spinner.View() + " " + progress
+		}
+		footer += " • esc cancel"
 	case m.attachments.modal.loadingPreview:
 		footer = m.ui.spinner.View() + " Loading preview..."
 	default:
-		footer = "j/k navigate • d download • v view • esc close"
+		footer = "j/k move • d download • s save as • o open • v view • esc close"
 		if m.attachments.modal.selectedIdx >= 0 &&
 			m.attachments.modal.selectedIdx < len(m.attachments.modal.attachments) {
 			selected := m.attachments.modal.attachments[m.attachments.modal.selectedIdx]
@@ -241,3 +267,168 @@ func (m *Model) renderAttachmentsModal() string {
 
 	return b.String()
 }
+
+// renderArchiveModal lists the files in an archive attachment, scrolled to
+// keep the selection in view.
+func (m *Model) renderArchiveModal() string {
+	var b strings.Builder
+	state := m.attachments.modal.archive
+
+	titleStyle := lipgloss.NewStyle().
+		Width(attachmentsModalWidth).
+		Align(lipgloss.Center).
+		Bold(true)
+	b.WriteString(titleStyle.Render(truncateToWidth(fmt.Sprintf(
+		"%s (%d %s)", state.filename, len(state.entries), pluralize(len(state.entries), "file"),
+	), attachmentsModalWidth)))
+	b.WriteString("\n\n")
+
+	// Border, padding, title and footer take the rest of the screen
+	rows := max(3, m.ui.height-10)
+	start := 0
+	if state.selectedIdx >= rows {
+		start = state.selectedIdx - rows + 1
+	}
+	end := min(len(state.entries), start+rows)
+	if len(state.entries) == 0 {
+		b.WriteString("    (empty)\n")
+	}
+	for i := start; i < end; i++ {
+		entry := state.entries[i]
+		prefix := "    "
+		if i == state.selectedIdx {
+			prefix = "  > "
+		}
+		modified := ""
+		if !entry.Modified.IsZero() {
+			modified = entry.Modified.Local().Format("2006-01-02 15:04")
+		}
+		details := fmt.Sprintf("%10s  %16s", formatAttachmentSize(entry.Size), modified)
+		nameWidth := attachmentsModalWidth - len(prefix) - len(details) - 2
+		name := truncatePathToWidth(entry.Name, nameWidth)
+		b.WriteString(prefix + name + strings.Repeat(" ", max(0, nameWidth-lipgloss.Width(name))+2) + details)
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().
+		Width(attachmentsModalWidth).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+	footer := "j/k move • v view • d extract • esc back"
+	if m.attachments.modal.loadingPreview {
+		footer = m.ui.spinner.View() + " Loading preview..."
+	}
+	b.WriteString(footerStyle.Render(footer))
+
+	return b.String()
+}
+
+// renderMimeModal draws a message's MIME tree, one indented row per part,
+// with the details of the selected part below it.
+func (m *Model) renderMimeModal() string {
+	var b strings.Builder
+	state := m.attachments.modal.mime
+
+	titleStyle := lipgloss.NewStyle().
+		Width(attachmentsModalWidth).
+		Align(lipgloss.Center).
+		Bold(true)
+	b.WriteString(titleStyle.Render(fmt.Sprintf(
+		"MIME structure (%d %s)", len(state.parts), pluralize(len(state.parts), "part"),
+	)))
+	b.WriteString("\n\n")
+
+	// Border, padding, title, details and footer take the rest of the screen
+	rows := max(3, m.ui.height-12)
+	start := 0
+	if state.selectedIdx >= rows {
+		start = state.selectedIdx - rows + 1
+	}
+	end := min(len(state.parts), start+rows)
+	for i := start; i < end; i++ {
+		part := state.parts[i]
+		prefix := "    "
+		if i == state.selectedIdx {
+			prefix = "  > "
+		}
+		label := strings.Repeat("  ", part.Depth)
+		if part.Section != "" {
+			label += part.Section + " "
+		}
+		label += part.ContentType
+		if part.Filename != "" {
+			label += " " + part.Filename
+		}
+		size := fmt.Sprintf("%10s", formatAttachmentSize(part.Size))
+		labelWidth := attachmentsModalWidth - len(prefix) - len(size) - 2
+		label = truncateToWidth(label, labelWidth)
+		b.WriteString(prefix + label + strings.Repeat(" ", max(0, labelWidth-lipgloss.Width(label))+2) + size)
+		b.WriteString("\n")
+	}
+
+	detailStyle := lipgloss.NewStyle().
+		Width(attachmentsModalWidth).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Detail.HeaderLabelFg))
+	if part, ok := m.selectedMimePart(); ok {
+		details := []string{
+			"charset " + cmp.Or(part.Charset, "none"),
+			cmp.Or(part.Encoding, "7bit"),
+			cmp.Or(part.Disposition, "no disposition"),
+		}
+		b.WriteString("\n")
+		b.WriteString(detailStyle.Render(truncateToWidth(strings.Join(details, " • "), attachmentsModalWidth)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	footerStyle := lipgloss.NewStyle().
+		Width(attachmentsModalWidth).
+		Align(lipgloss.Center).
+		Foreground(lipgloss.Color(m.theme.Modal.FooterFg))
+	footer := "j/k move • v view decoded • d save • esc close"
+	if m.attachments.modal.loadingPreview {
+		footer = m.ui.spinner.View() + " Loading preview..."
+	}
+	b.WriteString(footerStyle.Render(footer))
+
+	return b.String()
+}
+
+// truncatePathToWidth keeps the end of a path, where the filename is.
+func truncatePathToWidth(name string, maxWidth int) string {
+	if lipgloss.Width(name) <= maxWidth {
+		return name
+	}
+	runes := []rune(name)
+	for len(runes) > 0 && lipgloss.Width(string(runes))+3 > maxWidth {
+		runes = runes[1:]
+	}
+	return "..." + string(runes)
+}
+
+// downloadBarWidth is the width of the attachment download progress bar
+const downloadBarWidth = 20
+
+// renderDownloadProgress shows how much of the attachment has been written,
+// as a bar when the size is known.
+func (m *Model) renderDownloadProgress() string {
+	download := m.attachments.modal.download
+	if download.written == nil {
+		return ""
+	}
+	written := download.written.Load()
+	if download.total <= 0 {
+		return formatAttachmentSize(written)
+	}
+	fraction := min(1, float64(written)/float64(download.total))
+	filled := int(fraction * downloadBarWidth)
+	return fmt.Sprintf(
+		"%s %3d%% %s / %s",
+		strings.Repeat("█", filled)+strings.Repeat("░", downloadBarWidth-filled),
+		int(fraction*100),
+		formatAttachmentSize(written),
+		formatAttachmentSize(download.total),
+	)
+}
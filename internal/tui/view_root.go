@@ -17,27 +17,15 @@ func (m Model) View() string {
 	// If we just left image view, clear images and the screen
 	if m.image.needsClear {
 		var b strings.Builder
-		b.WriteString(kittyClearCommands)
+		b.WriteString(m.image.protocol.clearCommands())
 		b.WriteString("\x1b[2J") // Clear entire screen
 		b.WriteString("\x1b[H")  // Move cursor to home
 
-		// Render base view
-		var baseView string
-		if m.currentView == viewDetail {
-			baseView = m.renderDetailView()
-		} else {
-			baseView = m.renderListView()
-		}
-
-		b.WriteString(baseView)
+		b.WriteString(m.renderBaseView())
 		output = b.String()
 	} else {
 		// Normal rendering without clearing
-		if m.currentView == viewDetail {
-			output = m.renderDetailView()
-		} else {
-			output = m.renderListView()
-		}
+		output = m.renderBaseView()
 	}
 
 	// Overlay modals on top of base view
@@ -48,9 +36,32 @@ func (m Model) View() string {
 		output = m.overlayModal(output, m.renderErrorModal())
 	case m.inbox.delete.pending:
 		output = m.overlayModal(output, m.renderDeleteModal())
+	case m.detail.unsubscribe.pending:
+		output = m.overlayModal(output, m.renderUnsubscribeModal())
+	case len(m.sequence.keys) > 0:
+		output = m.overlayModal(output, m.renderWhichKeyModal())
+	case m.palette.show:
+		output = m.overlayModal(output, m.renderPaletteModal())
+	case m.outbox.showFailed:
+		output = m.overlayModal(output, m.renderFailedActionsModal())
+	case m.linkPicker.show:
+		output = m.overlayModal(output, m.renderLinkPickerModal())
 	case m.attachments.modal.show:
 		output = m.overlayModal(output, m.renderAttachmentsModal())
 	}
 
-	return m.ui.alert.Render(output)
+	// A pending clipboard copy rides along with the frame
+	return m.ui.clipboard + m.ui.alert.Render(output)
+}
+
+// renderBaseView renders the list, the thread, or both side by side.
+func (m *Model) renderBaseView() string {
+	switch {
+	case m.splitActive():
+		return m.renderSplitView()
+	case m.currentView == viewDetail:
+		return m.renderDetailView()
+	default:
+		return m.renderListView()
+	}
 }
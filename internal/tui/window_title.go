@@ -16,9 +16,15 @@ func (m *Model) setWindowTitleCmd() tea.Cmd {
 }
 
 func (m *Model) windowTitle() string {
+	if m.attachments.modal.show && m.attachments.modal.mime.active {
+		return formatWindowTitle("MIME structure")
+	}
 	if m.attachments.modal.show {
 		return formatWindowTitle("Attachments")
 	}
+	if m.linkPicker.show {
+		return formatWindowTitle("Links")
+	}
 
 	switch m.currentView {
 	case viewList: